@@ -0,0 +1,367 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/jcmturner/goidentity/v6"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"gopkg.in/yaml.v2"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// newAdminAuth returns the middleware protecting the admin endpoints, nil
+// for none: they can reconfigure the exporter and run commands, so they are
+// only served authenticated.
+func newAdminAuth(mode, keytabPath, spn, groups, tokenFile string) (func(http.Handler) http.Handler, error) {
+	switch mode {
+	case "none":
+		if keytabPath != "" || spn != "" || groups != "" || tokenFile != "" {
+			return nil, errors.New("admin authentication settings are given but --web.admin-auth is none, which doesn't serve the admin endpoints")
+		}
+		return nil, nil
+	case "token":
+		if tokenFile == "" {
+			return nil, errors.New("--web.admin-token-file is required for token authentication")
+		}
+		if _, err := readAdminToken(tokenFile); err != nil {
+			return nil, err
+		}
+		return func(h http.Handler) http.Handler { return requireToken(h, tokenFile) }, nil
+	case "negotiate":
+		if keytabPath == "" {
+			return nil, errors.New("--web.admin-keytab is required for negotiate authentication")
+		}
+		kt, err := keytab.Load(keytabPath)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load keytab %s: %w", keytabPath, err)
+		}
+		allowed := splitList(groups)
+		settings := []func(*service.Settings){service.DecodePAC(len(allowed) > 0)}
+		if spn != "" {
+			settings = append(settings, service.KeytabPrincipal(spn))
+		}
+		return func(h http.Handler) http.Handler {
+			return spnego.SPNEGOKRB5Authenticate(requireGroups(h, allowed), kt, settings...)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown admin authentication %q", mode)
+}
+
+// requireGroups only lets authenticated users in one of the AD groups
+// through, or every authenticated user if groups is empty.
+func requireGroups(h http.Handler, groups []string) http.Handler {
+	if len(groups) == 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		creds, ok := goidentity.FromHTTPRequestContext(r).(*credentials.Credentials)
+		if ok {
+			for _, sid := range creds.GetADCredentials().GroupMembershipSIDs {
+				for _, group := range groups {
+					if strings.EqualFold(sid, group) {
+						h.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+		}
+		user := ""
+		if ok {
+			user = creds.UserName()
+		}
+		level.Warn(baseLogger).Log("msg", "admin request denied, user not in an allowed group", "user", user, "path", r.URL.Path)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	})
+}
+
+// requireToken only lets requests with the bearer token held by tokenFile
+// through. The file is read on every request, so that rotated tokens are
+// used without a restart.
+func requireToken(h http.Handler, tokenFile string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := readAdminToken(tokenFile)
+		if err != nil {
+			level.Error(baseLogger).Log("msg", "failed to read admin token", "path", tokenFile, "err", err)
+			http.Error(w, "Couldn't read the admin token", http.StatusInternalServerError)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(token)) != 1 {
+			level.Warn(baseLogger).Log("msg", "admin request denied, invalid token", "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// readAdminToken returns the token held by path.
+func readAdminToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read admin token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("admin token file %s is empty", path)
+	}
+	return token, nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// registerAdminHandlers adds the admin endpoints to mux behind auth.
+func registerAdminHandlers(mux *http.ServeMux, auth func(http.Handler) http.Handler, configPath string) {
+	mux.Handle("POST /-/reload", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := reload(configPath); err != nil {
+			level.Error(baseLogger).Log("msg", "failed to reload configuration", "path", configPath, "err", err)
+			http.Error(w, fmt.Sprintf("Couldn't reload configuration: %s", err), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, "Configuration reloaded.")
+	})))
+	mux.Handle("GET /config", auth(http.HandlerFunc(configHandler)))
+	mux.Handle("PUT /api/v1/config", auth(configPushHandler(configPath)))
+	mux.Handle("PUT /api/v1/collectors/{name}", auth(http.HandlerFunc(collectorToggleHandler)))
+	mux.Handle("POST /api/v1/collect", auth(http.HandlerFunc(collectHandler)))
+	mux.Handle("POST /api/v1/licenses/{name}/mute", auth(http.HandlerFunc(muteHandler)))
+	mux.Handle("DELETE /api/v1/licenses/{name}/mute", auth(http.HandlerFunc(unmuteHandler)))
+	mux.Handle("GET /debug/diff", auth(http.HandlerFunc(diffHandler)))
+
+	mux.Handle("/debug/pprof/", auth(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", auth(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", auth(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", auth(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", auth(http.HandlerFunc(pprof.Trace)))
+}
+
+// configHandler serves the configuration in use as YAML, without secrets.
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	stateMu.RLock()
+	cfg := appConfig.Redacted()
+	stateMu.RUnlock()
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Couldn't marshal configuration: %s", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write(out); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write configuration", "err", err)
+	}
+}
+
+// collectorToggleHandler enables or disables a collector given the enabled
+// query parameter, without restarting the exporter.
+func collectorToggleHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "The enabled parameter must be true or false", http.StatusBadRequest)
+		return
+	}
+	if err := collector.SetCollectorEnabled(name, enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	level.Info(baseLogger).Log("msg", "collector toggled", "collector", name, "enabled", enabled)
+
+	stateMu.Lock()
+	if cacheInterval > 0 {
+		// The cache keeps its collectors, replace it.
+		nc, err := collector.NewFlexlmCollector()
+		if err != nil {
+			stateMu.Unlock()
+			http.Error(w, fmt.Sprintf("Couldn't create collector: %s", err), http.StatusInternalServerError)
+			return
+		}
+		startCache(nc)
+	}
+	stateMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"collector": name, "enabled": enabled}); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write collector state", "err", err)
+	}
+}
+
+// collectHandler collects the license given by the license query parameter
+// right away, refreshing its cached metrics, and returns the samples as JSON.
+// Collection failures are reported with 502 Bad Gateway.
+func collectHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("license")
+	if name == "" {
+		http.Error(w, "The license parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	stateMu.RLock()
+	c := cache
+	stateMu.RUnlock()
+
+	var (
+		result *collector.LicenseCollection
+		err    error
+	)
+	if c != nil {
+		result, err = c.RefreshLicense(r.Context(), name)
+	} else {
+		var nc *collector.RlmlmCollector
+		if nc, err = collector.NewFlexlmCollector(); err == nil {
+			result, err = nc.CollectLicense(r.Context(), name)
+		}
+	}
+	if errors.Is(err, collector.ErrLicenseNotFound) {
+		http.Error(w, fmt.Sprintf("License %q not found", name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		level.Error(baseLogger).Log("msg", "failed to collect license", "license", name, "err", err)
+		http.Error(w, fmt.Sprintf("Couldn't collect license: %s", err), http.StatusInternalServerError)
+		return
+	}
+	level.Info(baseLogger).Log("msg", "license collected on demand", "license", name, "samples", len(result.Samples), "err", result.Error)
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Error != "" {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write collection", "license", name, "err", err)
+	}
+}
+
+// muteHandler stops collecting a license for the duration query parameter,
+// like 2h, so that planned server maintenance neither fires alerts nor
+// wastes scrape time on timeouts.
+func muteHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+	if err != nil || duration <= 0 {
+		http.Error(w, "The duration parameter must be a positive duration like 2h", http.StatusBadRequest)
+		return
+	}
+	if !licenseConfigured(name) {
+		http.Error(w, fmt.Sprintf("License %q not found", name), http.StatusNotFound)
+		return
+	}
+	until := collector.MuteLicense(name, duration)
+	level.Info(baseLogger).Log("msg", "license muted", "license", name, "until", until)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"license_name": name, "muted_until": until.UTC().Format(time.RFC3339)}); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write mute state", "err", err)
+	}
+}
+
+// unmuteHandler resumes collecting a muted license right away.
+func unmuteHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !collector.UnmuteLicense(name) {
+		http.Error(w, fmt.Sprintf("License %q isn't muted", name), http.StatusNotFound)
+		return
+	}
+	level.Info(baseLogger).Log("msg", "license unmuted", "license", name)
+
+	stateMu.RLock()
+	if cache != nil {
+		cache.Refresh(name)
+	}
+	stateMu.RUnlock()
+	fmt.Fprintln(w, "License unmuted.")
+}
+
+// licenseConfigured returns whether the configuration in use has a license
+// called name.
+func licenseConfigured(name string) bool {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	if appConfig == nil {
+		return false
+	}
+	for _, license := range appConfig.Licenses {
+		if license.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reload loads the configuration at path and the product mapping and swaps
+// them in, restarting the background cache if enabled. The running
+// configuration is kept on error.
+func reload(path string) error {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := collector.LoadProductMapping(); err != nil {
+		return err
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if err := applyConfig(cfg); err != nil {
+		return err
+	}
+	level.Info(baseLogger).Log("msg", "configuration reloaded", "path", path, "licenses", len(cfg.Licenses))
+	return nil
+}
+
+// applyConfig swaps cfg in, restarting the background cache if enabled. The
+// running configuration is kept if the collector of cfg can't be created.
+// The caller must hold stateMu.
+func applyConfig(cfg *config.Config) error {
+	var nc *collector.RlmlmCollector
+	if cacheInterval > 0 {
+		var err error
+		if nc, err = collector.NewRlmlmCollector(cfg, baseLogger); err != nil {
+			return err
+		}
+	}
+	appConfig = cfg
+	collector.SetConfig(cfg)
+	_ = collector.CheckRlmstatBinary(baseLogger)
+	if nc != nil {
+		startCache(nc)
+	}
+	return nil
+}
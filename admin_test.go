@@ -0,0 +1,196 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jcmturner/goidentity/v6"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestRequireGroups(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := requireGroups(ok, splitList("S-1-5-21-1-2-3-512, S-1-5-21-1-2-3-1000"))
+
+	for sids, expected := range map[string]int{
+		"S-1-5-21-1-2-3-1000": http.StatusOK,
+		"S-1-5-21-1-2-3-513":  http.StatusForbidden,
+	} {
+		creds := credentials.New("user1", "EXAMPLE.COM")
+		creds.SetADCredentials(credentials.ADCredentials{GroupMembershipSIDs: []string{sids}})
+		r := goidentity.AddToHTTPRequestContext(creds, httptest.NewRequest("GET", "/config", nil))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != expected {
+			t.Fatalf("Unexpected status %d for group %s", w.Code, sids)
+		}
+	}
+
+	// Requests without credentials never get through.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/config", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Unexpected status %d without credentials", w.Code)
+	}
+}
+
+func TestNewAdminAuthNone(t *testing.T) {
+	auth, err := newAdminAuth("none", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != nil {
+		t.Fatal("Expected the admin endpoints to be disabled without authentication")
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	if _, err := newAdminAuth("none", "", "", "", "admin.token"); err == nil {
+		t.Fatal("Expected an error for a token file without authentication")
+	}
+	if _, err := newAdminAuth("token", "", "", "", ""); err == nil {
+		t.Fatal("Expected an error without token file")
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "admin.token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := newAdminAuth("token", "", "", "", tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for header, expected := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Basic s3cret":  http.StatusUnauthorized,
+		"Bearer s3cret": http.StatusOK,
+	} {
+		r := httptest.NewRequest("PUT", "/api/v1/config", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != expected {
+			t.Fatalf("Unexpected status %d with Authorization %q", w.Code, header)
+		}
+	}
+}
+
+func TestCollectorToggleHandler(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "admin.token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := newAdminAuth("token", "", "", "", tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	registerAdminHandlers(mux, auth, "")
+	defer collector.SetCollectorEnabled("lint", false)
+
+	for _, step := range []struct {
+		url, token string
+		expected   int
+	}{
+		{"/api/v1/collectors/lint?enabled=true", "", http.StatusUnauthorized},
+		{"/api/v1/collectors/lint?enabled=maybe", "s3cret", http.StatusBadRequest},
+		{"/api/v1/collectors/unknown?enabled=true", "s3cret", http.StatusNotFound},
+		{"/api/v1/collectors/lint?enabled=true", "s3cret", http.StatusOK},
+		{"/api/v1/collectors/lint?enabled=false", "s3cret", http.StatusOK},
+	} {
+		r := httptest.NewRequest("PUT", step.url, nil)
+		if step.token != "" {
+			r.Header.Set("Authorization", "Bearer "+step.token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != step.expected {
+			t.Fatalf("Unexpected status %d for %s: %s", w.Code, step.url, w.Body)
+		}
+	}
+}
+
+func TestConfigHandlerRedacted(t *testing.T) {
+	cfg, err := config.Parse([]byte(`tenants:
+  - name: eng
+    token_file: /etc/rlmlm/eng.token
+licenses:
+  - name: app1
+    license_server: 5053@host1
+    tenant: eng
+    on_exhausted: /usr/local/bin/page {{.Feature}}
+    env:
+      RLM_LICENSE_PASSWORD: hunter2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appConfig = cfg
+	defer func() { appConfig = nil }()
+
+	w := httptest.NewRecorder()
+	configHandler(w, httptest.NewRequest("GET", "/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body)
+	}
+	for _, secret := range []string{"hunter2", "/etc/rlmlm/eng.token", "/usr/local/bin/page"} {
+		if strings.Contains(w.Body.String(), secret) {
+			t.Fatalf("Expected %q to be redacted, got:\n%s", secret, w.Body)
+		}
+	}
+	if !strings.Contains(w.Body.String(), "RLM_LICENSE_PASSWORD: <redacted>") {
+		t.Fatalf("Expected the env name to be shown, got:\n%s", w.Body)
+	}
+	if cfg.Licenses[0].Env["RLM_LICENSE_PASSWORD"] != "hunter2" {
+		t.Fatal("Expected the configuration in use to keep its env")
+	}
+}
+
+func TestMuteHandler(t *testing.T) {
+	appConfig = &config.Config{Licenses: []config.License{{Name: "app1", LicenseServer: "5053@host1"}}}
+	defer func() { appConfig = nil }()
+	mux := http.NewServeMux()
+	registerAdminHandlers(mux, func(h http.Handler) http.Handler { return h }, "")
+
+	for _, step := range []struct {
+		method, url string
+		expected    int
+	}{
+		{"POST", "/api/v1/licenses/app1/mute", http.StatusBadRequest},
+		{"POST", "/api/v1/licenses/app1/mute?duration=-1h", http.StatusBadRequest},
+		{"POST", "/api/v1/licenses/app2/mute?duration=2h", http.StatusNotFound},
+		{"DELETE", "/api/v1/licenses/app1/mute", http.StatusNotFound},
+		{"POST", "/api/v1/licenses/app1/mute?duration=2h", http.StatusOK},
+		{"DELETE", "/api/v1/licenses/app1/mute", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(step.method, step.url, nil))
+		if w.Code != step.expected {
+			t.Fatalf("Unexpected status %d for %s %s: %s", w.Code, step.method, step.url, w.Body)
+		}
+	}
+}
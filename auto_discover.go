@@ -0,0 +1,52 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+// runAutoDiscover rescans the auto_discover_dir of the license groups every
+// interval until ctx is done, swapping the configuration in when the ISVs
+// found changed.
+func runAutoDiscover(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rediscover()
+		}
+	}
+}
+
+// rediscover rescans the license groups of the running configuration once.
+func rediscover() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	cfg, changed := appConfig.Rediscover()
+	if !changed {
+		return
+	}
+	if err := applyConfig(cfg); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to apply discovered licenses", "err", err)
+		return
+	}
+	level.Info(baseLogger).Log("msg", "discovered licenses changed", "licenses", len(cfg.Licenses))
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// billingConfig returns the billing settings in use and the licenses the
+// tenant of r sees, nil for every license. If billing isn't configured or r
+// may not be served, the error is written to w and nil returned.
+func billingConfig(w http.ResponseWriter, r *http.Request) (*config.Billing, map[string]bool) {
+	stateMu.RLock()
+	cfg := appConfig
+	stateMu.RUnlock()
+	tenant, ok := requestTenant(w, r, cfg)
+	if !ok {
+		return nil, nil
+	}
+	if cfg == nil || cfg.Billing == nil {
+		http.Error(w, "Billing isn't configured", http.StatusNotFound)
+		return nil, nil
+	}
+	var scope map[string]bool
+	if tenant != "" {
+		scope = tenantLicenses(cfg, tenant)
+	}
+	return cfg.Billing, scope
+}
+
+// billingPeriodsHandler serves the start days of the closed billing periods
+// as a JSON list.
+func billingPeriodsHandler(w http.ResponseWriter, r *http.Request) {
+	cfg, _ := billingConfig(w, r)
+	if cfg == nil {
+		return
+	}
+	periods, err := collector.BillingPeriods(cfg)
+	if err != nil {
+		level.Error(baseLogger).Log("msg", "failed to list billing periods", "dir", cfg.Dir, "err", err)
+		http.Error(w, fmt.Sprintf("Couldn't list billing periods: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string][]string{"periods": periods}); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write billing periods", "err", err)
+	}
+}
+
+// billingPeriodHandler serves the seat-hours of a closed billing period as
+// CSV, for chargeback to departments, only those of the licenses of the
+// tenant of the request if any.
+func billingPeriodHandler(w http.ResponseWriter, r *http.Request) {
+	cfg, scope := billingConfig(w, r)
+	if cfg == nil {
+		return
+	}
+	period := r.PathValue("period")
+	path, err := collector.BillingPeriodFile(cfg, period)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, fmt.Sprintf("Billing period %s isn't closed or doesn't exist", period), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		level.Error(baseLogger).Log("msg", "failed to open billing period", "path", path, "err", err)
+		http.Error(w, fmt.Sprintf("Couldn't open billing period: %s", err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Couldn't open billing period: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"billing-%s.csv\"", period))
+	if scope == nil {
+		http.ServeContent(w, r, "", info.ModTime(), f)
+		return
+	}
+	if err := writeTenantBilling(w, f, scope); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write billing period", "path", path, "err", err)
+	}
+}
+
+// writeTenantBilling copies the header and the rows of the licenses in scope
+// of a billing period file.
+func writeTenantBilling(w io.Writer, f io.Reader, scope map[string]bool) error {
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(records) == 0 {
+		return err
+	}
+	column := slices.Index(records[0], "license_name")
+	out := csv.NewWriter(w)
+	out.Write(records[0])
+	for _, record := range records[1:] {
+		if column >= 0 && column < len(record) && scope[record[column]] {
+			out.Write(record)
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
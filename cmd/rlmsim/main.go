@@ -0,0 +1,194 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command rlmsim simulates an RLM installation for end-to-end tests of the
+// exporter without a licensed RLM server.
+//
+// Used as --path.rlmstat, it answers the rlmstat invocations of the
+// collectors with canned output:
+//
+//	rlmsim -version            version.txt
+//	rlmsim -a -c <target> -dq  status_dq.txt
+//	rlmsim -a -c <target>      status.txt
+//	rlmsim -f <feature> ...    as -a, the whole status
+//	rlmsim -i -c <target>      info.txt
+//
+// The files are looked up in $RLMSIM_DIR/<target>/, then in $RLMSIM_DIR/, and
+// default to built-in output. A target missing from $RLMSIM_DIR/ while other
+// targets have a directory is reported like an unreachable server.
+//
+// "rlmsim serve [address]" serves the files over HTTP instead, status.txt at
+// /status and activation_keys.csv at /activation_keys.csv, as a stand-in for
+// the RLM web interface and an RLM Activation Pro server.
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed canned
+var canned embed.FS
+
+const (
+	defaultAddress = ":5054"
+	// exitServerDown is the exit code of rlmstat when it can't reach a server.
+	exitServerDown = 1
+	exitUsage      = 2
+)
+
+var errServerDown = errors.New("error connecting to the rlm server")
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "serve" {
+		address := defaultAddress
+		if len(args) > 1 {
+			address = args[1]
+		}
+		fmt.Fprintf(os.Stderr, "rlmsim: listening on %s\n", address)
+		if err := http.ListenAndServe(address, newHandler(os.Getenv("RLMSIM_DIR"))); err != nil {
+			fmt.Fprintf(os.Stderr, "rlmsim: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(args, os.Getenv("RLMSIM_DIR"), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "rlmsim: %s\n", err)
+		if errors.Is(err, errServerDown) {
+			os.Exit(exitServerDown)
+		}
+		os.Exit(exitUsage)
+	}
+}
+
+// run writes the canned output for the rlmstat arguments args to w.
+func run(args []string, dir string, w io.Writer) error {
+	var (
+		name     string
+		target   string
+		parsable bool
+	)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-version", "-v":
+			name = "version.txt"
+		case "-a":
+			name = "status.txt"
+		case "-f":
+			if i+1 == len(args) {
+				return errors.New("-f needs a feature")
+			}
+			i++
+			name = "status.txt"
+		case "-i":
+			name = "info.txt"
+		case "-dq":
+			parsable = true
+		case "-c":
+			if i+1 == len(args) {
+				return errors.New("-c needs a license file or port@host")
+			}
+			i++
+			target = args[i]
+		default:
+			return fmt.Errorf("unsupported argument %q", args[i])
+		}
+	}
+	if name == "" {
+		return errors.New("usage: rlmsim -version | -a|-f <feature> -c <target> [-dq] | -i -c <target> | serve [address]")
+	}
+	if name == "status.txt" && parsable {
+		name = "status_dq.txt"
+	}
+
+	out, err := readCanned(dir, target, name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// readCanned returns the file name for target.
+func readCanned(dir, target, name string) ([]byte, error) {
+	if dir != "" {
+		if target != "" {
+			// License file targets are looked up by file name.
+			out, err := os.ReadFile(filepath.Join(dir, filepath.Base(target), name))
+			if err == nil {
+				return out, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			if hasTargetDirs(dir) {
+				return nil, fmt.Errorf("%w on %s", errServerDown, target)
+			}
+		}
+		out, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return out, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return canned.ReadFile("canned/" + name)
+}
+
+// hasTargetDirs returns whether dir has per target directories.
+func hasTargetDirs(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// newHandler serves the canned status and activation keys.
+func newHandler(dir string) http.Handler {
+	mux := http.NewServeMux()
+	for path, name := range map[string]string{
+		"/status":              "status.txt",
+		"/activation_keys.csv": "activation_keys.csv",
+	} {
+		mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+			out, err := readCanned(dir, r.URL.Query().Get("target"), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			if strings.HasSuffix(name, ".csv") {
+				w.Header().Set("Content-Type", "text/csv")
+			} else {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			}
+			_, _ = w.Write(out)
+		})
+	}
+	return mux
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	for args, expected := range map[string]string{
+		"-version":             "rlmstat v14.2 build 2",
+		"-a -c 5053@host1":     "license pool status",
+		"-a -c 5053@host1 -dq": "server fqdn=host1",
+		"-f f1 -c 5053@host1":  "license pool status",
+		"-i -c /opt/rlm/x.lic": "#licenses",
+	} {
+		var out bytes.Buffer
+		if err := run(strings.Fields(args), "", &out); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", args, err)
+		}
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("Unexpected output for %s: %s", args, out.String())
+		}
+	}
+
+	if err := run([]string{"-x"}, "", &bytes.Buffer{}); err == nil {
+		t.Fatal("Expected error for an unsupported argument")
+	}
+}
+
+func TestRunTargetDirs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "5053@host1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "5053@host1", "status.txt"), []byte("custom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"-a", "-c", "5053@host1"}, dir, &out); err != nil || out.String() != "custom" {
+		t.Fatalf("Unexpected output %q, error %v", out.String(), err)
+	}
+	// Targets without a directory are down.
+	if err := run([]string{"-a", "-c", "5053@host2"}, dir, &bytes.Buffer{}); !errors.Is(err, errServerDown) {
+		t.Fatalf("Unexpected error for a missing target: %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	newHandler("").ServeHTTP(w, httptest.NewRequest("GET", "/activation_keys.csv", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "akey,") {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+}
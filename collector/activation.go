@@ -0,0 +1,219 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	activationTimeout = kingpin.Flag("collector.activation.timeout",
+		"Timeout of fetching the activation key report of an activation server.").Default("10s").Duration()
+
+	activationUpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "activation", "up"),
+		"Whether the activation key report of an activation server could be fetched and parsed.",
+		[]string{"server"},
+		nil,
+	)
+	activationKeyCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "activation", "key_count"),
+		"Number of activations an activation key allows.",
+		[]string{"server", "key", "product"},
+		nil,
+	)
+	activationKeyFulfilledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "activation", "key_fulfilled"),
+		"Number of activations fulfilled with an activation key.",
+		[]string{"server", "key", "product"},
+		nil,
+	)
+	activationKeyActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "activation", "key_active"),
+		"Whether an activation key is enabled.",
+		[]string{"server", "key", "product"},
+		nil,
+	)
+)
+
+// activationKey is a row of the activation key report.
+type activationKey struct {
+	key       string
+	product   string
+	active    bool
+	count     float64
+	fulfilled float64
+}
+
+type activationCollector struct {
+	config *config.Config
+	logger log.Logger
+}
+
+func init() {
+	registerCollector("activation", false, NewActivationCollector)
+}
+
+// NewActivationCollector returns a collector exporting the activation keys
+// of the configured RLM Activation Pro servers.
+func NewActivationCollector(cfg *config.Config, logger log.Logger) (Collector, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &activationCollector{
+		config: cfg,
+		logger: logger,
+	}, nil
+}
+
+// Describe implements the Collector interface.
+func (c *activationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activationUpDesc
+	ch <- activationKeyCountDesc
+	ch <- activationKeyFulfilledDesc
+	ch <- activationKeyActiveDesc
+}
+
+// Update implements the Collector interface.
+func (c *activationCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if c.config == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, server := range c.config.ActivationServers {
+		if err := c.updateServer(ctx, ch, server); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// updateServer exports the activation keys of a single server.
+func (c *activationCollector) updateServer(ctx context.Context, ch chan<- prometheus.Metric, server config.ActivationServer) (err error) {
+	ctx, span := tracer.Start(ctx, "activation_server", trace.WithAttributes(attribute.String("server", server.Name)))
+	defer func() {
+		endSpan(span, err)
+		collectionErrors.record("activation", server.Name, err)
+	}()
+
+	keys, err := c.fetchKeys(ctx, server)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to collect activation server", "server", server.Name, "err", err)
+		ch <- constMetric(activationUpDesc, prometheus.GaugeValue, 0, server.Name)
+		return fmt.Errorf("activation server %s: %w", server.Name, err)
+	}
+
+	ch <- constMetric(activationUpDesc, prometheus.GaugeValue, 1, server.Name)
+	for _, k := range keys {
+		ch <- constMetric(activationKeyCountDesc, prometheus.GaugeValue, k.count, server.Name, k.key, k.product)
+		ch <- constMetric(activationKeyFulfilledDesc, prometheus.GaugeValue, k.fulfilled, server.Name, k.key, k.product)
+		ch <- constMetric(activationKeyActiveDesc, prometheus.GaugeValue, boolToFloat64(k.active), server.Name, k.key, k.product)
+	}
+	return nil
+}
+
+// fetchKeys downloads and parses the activation key report of server.
+func (c *activationCollector) fetchKeys(ctx context.Context, server config.ActivationServer) ([]activationKey, error) {
+	if server.URL == "" {
+		return nil, configError(errors.New("missing url"))
+	}
+	client, err := httpClient(server.HTTPClient, *activationTimeout)
+	if err != nil {
+		return nil, configError(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		return nil, configError(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, networkError(fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	keys, err := parseActivationKeys(resp.Body)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return keys, nil
+}
+
+// parseActivationKeys parses an activation key report, a CSV file with a
+// header naming at least the akey, count and fulfilled columns. The product
+// and active columns are optional; keys are active unless active is 0.
+func parseActivationKeys(r io.Reader) ([]activationKey, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty activation key report")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"akey", "count", "fulfilled"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column in activation key report", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	keys := make([]activationKey, 0, len(records)-1)
+	for _, record := range records[1:] {
+		count, err := strconv.ParseFloat(field(record, "count"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid count of key %s: %w", field(record, "akey"), err)
+		}
+		fulfilled, err := strconv.ParseFloat(field(record, "fulfilled"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fulfilled count of key %s: %w", field(record, "akey"), err)
+		}
+		keys = append(keys, activationKey{
+			key:       field(record, "akey"),
+			product:   field(record, "product"),
+			active:    field(record, "active") != "0",
+			count:     count,
+			fulfilled: fulfilled,
+		})
+	}
+	return keys, nil
+}
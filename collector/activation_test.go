@@ -0,0 +1,364 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const testActivationKeys = "fixtures/activation_keys.csv"
+
+func TestParseActivationKeys(t *testing.T) {
+	f, err := os.Open(testActivationKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	keys, err := parseActivationKeys(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("Expected 3 keys, got %d", len(keys))
+	}
+	if k := keys[0]; k.key != "1234-5678-9012-3456" || k.product != "prod1" || !k.active || k.count != 10 || k.fulfilled != 4 {
+		t.Fatalf("Unexpected key %+v", k)
+	}
+	if keys[2].active {
+		t.Fatalf("Expected %s to be inactive", keys[2].key)
+	}
+
+	if _, err := parseActivationKeys(strings.NewReader("akey,product\n1234,prod1\n")); err == nil {
+		t.Fatal("Expected an error without count columns")
+	}
+}
+
+func TestActivationCollector(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/keys.csv" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, testActivationKeys)
+	}))
+	defer ts.Close()
+
+	cfg := &config.Config{ActivationServers: []config.ActivationServer{
+		{Name: "act1", URL: ts.URL + "/keys.csv"},
+		{Name: "act2", URL: ts.URL + "/missing"},
+	}}
+	c, err := NewActivationCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Update(context.Background(), ch)
+		close(ch)
+	}()
+
+	up := make(map[string]float64)
+	var fulfilled float64
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		switch m.Desc() {
+		case activationUpDesc:
+			up[pb.GetLabel()[0].GetValue()] = pb.GetGauge().GetValue()
+		case activationKeyFulfilledDesc:
+			fulfilled += pb.GetGauge().GetValue()
+		}
+	}
+	if err := <-errCh; err == nil {
+		t.Fatal("Expected an error for the missing report")
+	}
+	if up["act1"] != 1 || up["act2"] != 0 {
+		t.Fatalf("Unexpected up values %v", up)
+	}
+	if fulfilled != 10 {
+		t.Fatalf("Expected 10 fulfilled activations, got %v", fulfilled)
+	}
+}
+
+func TestActivationCollectorTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, testActivationKeys)
+	}))
+	defer ts.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{ActivationServers: []config.ActivationServer{
+		{Name: "trusted", URL: ts.URL, HTTPClient: config.HTTPClient{CAFile: ca}},
+		{Name: "skipped", URL: ts.URL, HTTPClient: config.HTTPClient{InsecureSkipVerify: true}},
+		{Name: "untrusted", URL: ts.URL},
+	}}
+	c, err := NewActivationCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		_ = c.Update(context.Background(), ch)
+		close(ch)
+	}()
+
+	up := make(map[string]float64)
+	for m := range ch {
+		if m.Desc() != activationUpDesc {
+			continue
+		}
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		up[pb.GetLabel()[0].GetValue()] = pb.GetGauge().GetValue()
+	}
+	if up["trusted"] != 1 || up["skipped"] != 1 || up["untrusted"] != 0 {
+		t.Fatalf("Unexpected up values %v", up)
+	}
+
+	// Servers with the same settings share a client.
+	first, err := httpClient(config.HTTPClient{CAFile: ca}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := httpClient(config.HTTPClient{CAFile: ca}, 0); first != second {
+		t.Fatal("Expected the client to be shared")
+	}
+}
+
+func TestLoadSystemRoots(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca-certificates.crt")
+	if err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	roots, err := loadSystemRoots(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected the server to be trusted with the bundle, got %v", err)
+	}
+	resp.Body.Close()
+
+	if roots, err := loadSystemRoots(""); roots != nil || err != nil {
+		t.Fatalf("Expected the system roots without file, got %v, %v", roots, err)
+	}
+	if _, err := loadSystemRoots(filepath.Join(dir, "missing.crt")); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+	empty := filepath.Join(dir, "empty.crt")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSystemRoots(empty); err == nil || !strings.Contains(err.Error(), "no certificate found") {
+		t.Fatalf("Expected no certificate found, got %v", err)
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://act1.example.com/keys.csv", nil)
+	proxy := func(settings config.HTTPClient) *http.Transport {
+		t.Helper()
+		client, err := httpClient(settings, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.Transport.(*http.Transport)
+	}
+
+	if transport := proxy(config.HTTPClient{NoProxy: true}); transport.Proxy != nil {
+		t.Fatal("Expected no proxy with no_proxy")
+	}
+	if transport := proxy(config.HTTPClient{}); transport.Proxy == nil {
+		t.Fatal("Expected the proxy environment to be honored by default")
+	}
+	u, err := proxy(config.HTTPClient{ProxyURL: "http://proxy.example.com:3128"}).Proxy(req)
+	if err != nil || u == nil || u.Host != "proxy.example.com:3128" {
+		t.Fatalf("Unexpected proxy %v, %v", u, err)
+	}
+}
+
+func TestActivationCollectorPinnedKey(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, testActivationKeys)
+	}))
+	defer ts.Close()
+
+	hash := sha256.Sum256(ts.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+	other := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	cfg := &config.Config{ActivationServers: []config.ActivationServer{
+		{Name: "pinned", URL: ts.URL, HTTPClient: config.HTTPClient{PinnedSPKI: []string{other, "sha256//" + pin}}},
+		{Name: "mismatch", URL: ts.URL, HTTPClient: config.HTTPClient{PinnedSPKI: []string{other}}},
+	}}
+	c, err := NewActivationCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		_ = c.Update(context.Background(), ch)
+		close(ch)
+	}()
+
+	up := make(map[string]float64)
+	for m := range ch {
+		if m.Desc() != activationUpDesc {
+			continue
+		}
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		up[pb.GetLabel()[0].GetValue()] = pb.GetGauge().GetValue()
+	}
+	if up["pinned"] != 1 || up["mismatch"] != 0 {
+		t.Fatalf("Unexpected up values %v", up)
+	}
+}
+
+func TestHTTPClientBearerToken(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	client, err := httpClient(config.HTTPClient{BearerTokenFile: tokenFile}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{"first", "rotated"} {
+		if err := os.WriteFile(tokenFile, []byte(token+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if auth != "Bearer "+token {
+			t.Fatalf("Unexpected Authorization %q", auth)
+		}
+	}
+}
+
+// writeClientCertificate writes a self-signed certificate for cn and its key
+// to certFile and keyFile, modified at mtime.
+func writeClientCertificate(t *testing.T, certFile, keyFile, cn string, mtime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClientCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writeClientCertificate(t, certFile, keyFile, "first", time.Unix(1000, 0))
+
+	c := &clientCertificate{certFile: certFile, keyFile: keyFile}
+	commonName := func() string {
+		t.Helper()
+		cert, err := c.get(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaf.Subject.CommonName
+	}
+	if cn := commonName(); cn != "first" {
+		t.Fatalf("Unexpected certificate %s", cn)
+	}
+
+	writeClientCertificate(t, certFile, keyFile, "rotated", time.Unix(2000, 0))
+	if cn := commonName(); cn != "rotated" {
+		t.Fatalf("Rotated certificate not loaded, got %s", cn)
+	}
+
+	// A half written certificate keeps the previous one.
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cn := commonName(); cn != "rotated" {
+		t.Fatalf("Unexpected certificate %s after a failed reload", cn)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const (
+	// billingDateLayout names the period files after the day they start.
+	billingDateLayout = "2006-01-02"
+	// billingPartialExt is the extension of the file of the current period,
+	// renamed to billingExt when the period closes.
+	billingPartialExt = ".partial.csv"
+	billingExt        = ".csv"
+	// maxBillingInterval caps the time a scrape is billed for, so that the
+	// seats seen before the exporter or its server stopped aren't billed for
+	// the whole outage.
+	maxBillingInterval = 15 * time.Minute
+)
+
+var (
+	billingSeatHoursDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "billing", "period_seat_hours"),
+		"rlmlm_exporter: Seat-hours of the feature used by the group of users since the start of the current billing period.",
+		[]string{"license_name", "feature", "group"},
+		nil,
+	)
+
+	billingCSVHeader = []string{"period_start", "period_end", "license_name", "feature", "group", "seat_hours", "rate", "cost"}
+
+	billing = &billingTracker{}
+)
+
+// billingKey identifies the seat-hours of a group on a feature of a license.
+type billingKey struct {
+	license, feature, group string
+}
+
+// billingTracker adds up the seat-hours of the current period and keeps them
+// in a partial CSV file of the billing dir, so that restarts don't lose them.
+type billingTracker struct {
+	mu sync.Mutex
+	// dir and start are the dir and period the hours were loaded for.
+	dir   string
+	start time.Time
+	hours map[billingKey]float64
+	// last is when each license was last observed.
+	last map[string]time.Time
+}
+
+// observe bills the seats used by the exported features of license since it
+// was last observed, closing the previous period first if now is past it.
+func (t *billingTracker) observe(cfg *config.Billing, license string, data *lmstatData, exported map[string]bool, now time.Time) error {
+	if cfg == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start, end := cfg.Period(now)
+	if t.dir != cfg.Dir || !t.start.Equal(start) {
+		if err := t.open(cfg, start, end); err != nil {
+			return err
+		}
+	}
+
+	last, ok := t.last[license]
+	t.last[license] = now
+	if !ok {
+		return nil
+	}
+	if last.Before(start) {
+		last = start
+	}
+	elapsed := now.Sub(last)
+	if elapsed <= 0 {
+		return nil
+	}
+	if elapsed > maxBillingInterval {
+		elapsed = maxBillingInterval
+	}
+	hours := elapsed.Hours()
+	for name, f := range data.features {
+		if !exported[name] || f.used <= 0 {
+			continue
+		}
+		// Seats not listed by user, like those of features whose users
+		// aren't printed, go to the default group.
+		unassigned := f.used
+		for user, seats := range data.usersByFeature[name] {
+			t.hours[billingKey{license, name, cfg.Group(user)}] += seats * hours
+			unassigned -= seats
+		}
+		if unassigned > 0 {
+			t.hours[billingKey{license, name, cfg.DefaultGroup}] += unassigned * hours
+		}
+	}
+	return writeBillingFile(filepath.Join(cfg.Dir, start.Format(billingDateLayout)+billingPartialExt), cfg, start, end, t.hours)
+}
+
+// open switches to the period starting at start, closing the periods left
+// partial in the dir, like by a restart across a period boundary, and loading
+// the hours of the current one.
+func (t *billingTracker) open(cfg *config.Billing, start, end time.Time) error {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return err
+	}
+	current := start.Format(billingDateLayout)
+	partials, err := filepath.Glob(filepath.Join(cfg.Dir, "*"+billingPartialExt))
+	if err != nil {
+		return err
+	}
+	for _, path := range partials {
+		period := strings.TrimSuffix(filepath.Base(path), billingPartialExt)
+		if period >= current {
+			continue
+		}
+		if err := os.Rename(path, filepath.Join(cfg.Dir, period+billingExt)); err != nil {
+			return fmt.Errorf("couldn't close billing period %s: %w", period, err)
+		}
+	}
+
+	hours, err := readBillingFile(filepath.Join(cfg.Dir, current+billingPartialExt))
+	if err != nil {
+		return err
+	}
+	t.dir, t.start, t.hours = cfg.Dir, start, hours
+	if t.last == nil {
+		t.last = make(map[string]time.Time)
+	}
+	return nil
+}
+
+// collect sends the seat-hours of the current period.
+func (t *billingTracker) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, hours := range t.hours {
+		ch <- constMetric(billingSeatHoursDesc, prometheus.GaugeValue, hours, key.license, key.feature, key.group)
+	}
+}
+
+// readBillingFile returns the seat-hours of a period file, none if it doesn't
+// exist.
+func readBillingFile(path string) (map[billingKey]float64, error) {
+	hours := make(map[billingKey]float64)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return hours, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+	for i, record := range records {
+		if i == 0 || len(record) != len(billingCSVHeader) {
+			continue
+		}
+		seatHours, err := strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seat_hours on line %d of %s: %w", i+1, path, err)
+		}
+		hours[billingKey{record[2], record[3], record[4]}] = seatHours
+	}
+	return hours, nil
+}
+
+// writeBillingFile replaces the file at path with the seat-hours and their
+// cost at the current rates, sorted by license, feature and group.
+func writeBillingFile(path string, cfg *config.Billing, start, end time.Time, hours map[billingKey]float64) error {
+	keys := make([]billingKey, 0, len(hours))
+	for key := range hours {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.license != b.license {
+			return a.license < b.license
+		}
+		if a.feature != b.feature {
+			return a.feature < b.feature
+		}
+		return a.group < b.group
+	})
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := csv.NewWriter(tmp)
+	w.Write(billingCSVHeader)
+	for _, key := range keys {
+		rate := cfg.Rates[key.feature]
+		w.Write([]string{
+			start.Format(billingDateLayout),
+			end.Format(billingDateLayout),
+			key.license,
+			key.feature,
+			key.group,
+			strconv.FormatFloat(hours[key], 'f', 4, 64),
+			strconv.FormatFloat(rate, 'f', -1, 64),
+			strconv.FormatFloat(hours[key]*rate, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// BillingPeriods returns the start days of the closed periods of the billing
+// dir, oldest first.
+func BillingPeriods(cfg *config.Billing) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(cfg.Dir, "*"+billingExt))
+	if err != nil {
+		return nil, err
+	}
+	periods := []string{}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if strings.HasSuffix(name, billingPartialExt) {
+			continue
+		}
+		period := strings.TrimSuffix(name, billingExt)
+		if _, err := time.Parse(billingDateLayout, period); err == nil {
+			periods = append(periods, period)
+		}
+	}
+	sort.Strings(periods)
+	return periods, nil
+}
+
+// BillingPeriodFile returns the CSV file of the closed period starting on
+// period, a YYYY-MM-DD date.
+func BillingPeriodFile(cfg *config.Billing, period string) (string, error) {
+	if _, err := time.Parse(billingDateLayout, period); err != nil {
+		return "", fmt.Errorf("invalid period %q, must be a YYYY-MM-DD date", period)
+	}
+	return filepath.Join(cfg.Dir, period+billingExt), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestBillingTracker(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := config.Parse([]byte("billing:\n  dir: " + dir + "\n  groups:\n    - users: cad_.*\n      group: design\n  rates:\n    solver: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &lmstatData{
+		features: map[string]*feature{"solver": {issued: 10, used: 3}, "mesher": {issued: 5, used: 1}},
+		// The third seat of solver isn't listed by user.
+		usersByFeature: map[string]map[string]float64{"solver": {"cad_alice": 1, "bob": 1}},
+	}
+	exported := map[string]bool{"solver": true}
+
+	tracker := &billingTracker{}
+	start := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	for _, now := range []time.Time{start, start.Add(15 * time.Minute), start.Add(30 * time.Minute)} {
+		if err := tracker.observe(cfg.Billing, "app1", data, exported, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[billingKey]float64{
+		{"app1", "solver", "design"}:     0.5,
+		{"app1", "solver", "unassigned"}: 1,
+	}
+	for key, hours := range want {
+		if math.Abs(tracker.hours[key]-hours) > 1e-9 {
+			t.Fatalf("Expected %v seat-hours for %+v, got %v", hours, key, tracker.hours)
+		}
+	}
+	if len(tracker.hours) != len(want) {
+		t.Fatalf("Expected only the exported feature to be billed, got %v", tracker.hours)
+	}
+
+	// A restart resumes from the partial file and closes the period once
+	// past it, billing only the time since its start to the next one.
+	tracker = &billingTracker{}
+	if err := tracker.observe(cfg.Billing, "app1", data, exported, start.Add(30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(tracker.hours[billingKey{"app1", "solver", "design"}]-0.5) > 1e-9 {
+		t.Fatalf("Expected the seat-hours of the partial file, got %v", tracker.hours)
+	}
+	if err := tracker.observe(cfg.Billing, "app1", data, exported, start.Add(70*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(tracker.hours[billingKey{"app1", "solver", "design"}]-1.0/6) > 1e-9 {
+		t.Fatalf("Expected the seat-hours since the start of the period, got %v", tracker.hours)
+	}
+
+	periods, err := BillingPeriods(cfg.Billing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != 1 || periods[0] != "2025-01-01" {
+		t.Fatalf("Expected the closed period of January, got %v", periods)
+	}
+	path, err := BillingPeriodFile(cfg.Billing, periods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "period_start,period_end,license_name,feature,group,seat_hours,rate,cost\n" +
+		"2025-01-01,2025-02-01,app1,solver,design,0.5000,2,1.00\n" +
+		"2025-01-01,2025-02-01,app1,solver,unassigned,1.0000,2,2.00\n"
+	if string(csv) != wantCSV {
+		t.Fatalf("Unexpected CSV of the closed period:\n%s", csv)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2025-02-01.partial.csv")); err != nil {
+		t.Fatalf("Expected the partial file of the current period: %s", err)
+	}
+
+	if _, err := BillingPeriodFile(cfg.Billing, "../secrets"); err == nil || !strings.Contains(err.Error(), "YYYY-MM-DD") {
+		t.Fatalf("Expected an invalid period error, got %v", err)
+	}
+}
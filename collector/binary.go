@@ -0,0 +1,90 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	binaryAvailableDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rlm", "binary_available"),
+		"rlmlm_exporter: Whether the configured rlmstat binary was found and is executable.",
+		[]string{"path"},
+		nil,
+	)
+
+	// errRlmstatUnavailable is returned instead of executing a missing binary.
+	errRlmstatUnavailable = errors.New("rlmstat binary unavailable")
+
+	// The result of the last lookup is kept until --path.rlmstat changes.
+	binaryMu          sync.Mutex
+	binaryCheckedPath string
+	binaryCheckErr    error
+)
+
+// CheckRlmstatBinary looks up the configured rlmstat binary, logging a hint
+// if it is missing. Until the path changes all rlmstat invocations are
+// skipped while the binary is unavailable. It is meant to be called at
+// startup and whenever the configuration is reloaded.
+func CheckRlmstatBinary(logger log.Logger) error {
+	binaryMu.Lock()
+	defer binaryMu.Unlock()
+
+	return checkRlmstatBinaryLocked(logger)
+}
+
+func checkRlmstatBinaryLocked(logger log.Logger) error {
+	path := *rlmstatPath
+	binaryCheckedPath = path
+	if _, err := exec.LookPath(path); err != nil {
+		binaryCheckErr = fmt.Errorf("%w: %s", errRlmstatUnavailable, err)
+		level.Warn(logger).Log(
+			"msg", "rlmstat binary not found, skipping all rlmstat invocations until --path.rlmstat points to an executable",
+			"path", path, "err", err,
+		)
+		return binaryCheckErr
+	}
+	binaryCheckErr = nil
+	level.Debug(logger).Log("msg", "found rlmstat binary", "path", path)
+	return nil
+}
+
+// rlmstatAvailable returns the cached lookup result for the configured path,
+// looking the binary up again only if the path changed.
+func rlmstatAvailable() error {
+	binaryMu.Lock()
+	defer binaryMu.Unlock()
+
+	if binaryCheckedPath != *rlmstatPath {
+		return checkRlmstatBinaryLocked(defaultLogger)
+	}
+	return binaryCheckErr
+}
+
+// binaryAvailableMetric returns the rlmlm_rlm_binary_available sample.
+func binaryAvailableMetric() prometheus.Metric {
+	available := 1.0
+	if rlmstatAvailable() != nil {
+		available = 0
+	}
+	return constMetric(binaryAvailableDesc, prometheus.GaugeValue, available, *rlmstatPath)
+}
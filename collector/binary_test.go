@@ -0,0 +1,46 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestRlmstatAvailable(t *testing.T) {
+	oldPath := *rlmstatPath
+	defer func() { *rlmstatPath = oldPath }()
+
+	*rlmstatPath = "/nonexistent/rlmstat"
+	if err := CheckRlmstatBinary(log.NewNopLogger()); !errors.Is(err, errRlmstatUnavailable) {
+		t.Fatalf("Unexpected error for missing binary: %v", err)
+	}
+	if _, err := runRlmstatCommand(context.Background(), priorityStatus, nil, "-v"); !errors.Is(err, errRlmstatUnavailable) {
+		t.Fatalf("Unexpected error running missing binary: %v", err)
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh in PATH")
+	}
+	// Changing the path triggers a new lookup.
+	*rlmstatPath = sh
+	if err := rlmstatAvailable(); err != nil {
+		t.Fatalf("Unexpected error after path change: %v", err)
+	}
+}
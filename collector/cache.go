@@ -0,0 +1,356 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	cacheStagger = kingpin.Flag("cache.stagger",
+		"With --cache.interval, spread the first collections of the licenses after starting or reloading randomly over this long, at most the interval.").Default("30s").Duration()
+	cacheRetryDelay = kingpin.Flag("cache.retry-delay",
+		"With --cache.interval, retry a failed collection after about this long, doubling the delay after every failure, as long as the retry ends before the next scheduled collection. Zero disables retries.").Default("5s").Duration()
+	cacheTimestamps = kingpin.Flag("cache.timestamps",
+		"With --cache.interval, expose the license metrics with the time they were collected as sample timestamp, rather than letting Prometheus use the scrape time. Prometheus doesn't mark such series stale when they disappear.").Bool()
+
+	dataAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "data_age_seconds"),
+		"rlmlm_exporter: Seconds since the cached metrics of a license were last collected successfully.",
+		[]string{"license_name"},
+		nil,
+	)
+	cacheHitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "hits_total"),
+		"rlmlm_exporter: Scrapes served the cached metrics of a license.",
+		[]string{"license_name"},
+		nil,
+	)
+	cacheMissesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "misses_total"),
+		"rlmlm_exporter: Scrapes served no cached metrics of a license, as it was never collected successfully or is older than --cache.max-staleness.",
+		[]string{"license_name"},
+		nil,
+	)
+	cacheGenerationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cache", "generation"),
+		"rlmlm_exporter: Successful background collections of a license, the cached metrics served change with it.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	// latestAttemptDescs are the metrics telling whether the target is up,
+	// which always reflect the latest attempt rather than the last success.
+	latestAttemptDescs = map[*prometheus.Desc]bool{
+		lmstatupDesc:            true,
+		targetUpDesc:            true,
+		targetFailureReasonDesc: true,
+	}
+)
+
+// cacheEntry holds the collected metrics of one license.
+type cacheEntry struct {
+	// good holds the metrics of the last successful collection, collected at
+	// updated.
+	good    []prometheus.Metric
+	updated time.Time
+	// failed holds the metrics of the latest collection if it failed.
+	failed []prometheus.Metric
+	// attempted is when the latest collection ran.
+	attempted time.Time
+	// generation counts the successful collections.
+	generation uint64
+	// hits and misses count the scrapes served or not the good metrics,
+	// updated under the read lock.
+	hits, misses atomic.Uint64
+}
+
+// Cache collects every license in the background and serves the last
+// results on scrape, so slow license servers don't delay the scrape.
+type Cache struct {
+	collector    *RlmlmCollector
+	interval     time.Duration
+	maxStaleness time.Duration
+	logger       log.Logger
+	now          func() time.Time
+	// jitter returns a random duration in [0, d).
+	jitter func(d time.Duration) time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+	// refreshes wake up the collection of a license ahead of its interval.
+	refreshes map[string]chan struct{}
+	schedule  *schedulerStats
+}
+
+// NewCache returns a cache refreshing the licenses of c every interval.
+// Metrics of licenses that weren't collected successfully for longer than
+// maxStaleness are dropped from scrapes, zero keeps them forever.
+func NewCache(c *RlmlmCollector, interval, maxStaleness time.Duration, logger log.Logger) *Cache {
+	return &Cache{
+		collector:    c,
+		interval:     interval,
+		maxStaleness: maxStaleness,
+		logger:       logger,
+		now:          time.Now,
+		jitter:       randomDuration,
+		entries:      make(map[string]*cacheEntry),
+		refreshes:    make(map[string]chan struct{}),
+		schedule:     newSchedulerStats(),
+	}
+}
+
+// Start collects every license once and then keeps refreshing it until ctx
+// is done, and right away when its license file changes.
+func (c *Cache) Start(ctx context.Context) {
+	if c.collector.Config == nil {
+		return
+	}
+	for _, license := range c.collector.Config.Licenses {
+		refresh := make(chan struct{}, 1)
+		c.refreshes[license.Name] = refresh
+		go c.run(ctx, license, refresh)
+	}
+	watchLicenseFiles(ctx, c.collector.Config, c.logger, c.Refresh)
+}
+
+// Refresh collects the license name again without waiting for the interval.
+func (c *Cache) Refresh(name string) {
+	select {
+	case c.refreshes[name] <- struct{}{}:
+	default:
+		// Already pending.
+	}
+}
+
+func (c *Cache) run(ctx context.Context, license config.License, refresh <-chan struct{}) {
+	// Licenses collected all at once after a restart or reload would load
+	// the license servers at the same time.
+	stagger := *cacheStagger
+	if stagger > c.interval {
+		stagger = c.interval
+	}
+	next := c.now().Add(c.jitter(stagger))
+	c.schedule.plan(license.Name, next)
+	for {
+		if !c.wait(ctx, license, next, refresh) {
+			return
+		}
+		c.schedule.start(license.Name, c.now().Sub(next))
+		started := c.now()
+		_, err := c.refresh(ctx, license)
+		next = c.schedule.finish(license.Name, next, c.interval, c.now())
+		if err != nil && !c.retry(ctx, license, next, c.now().Sub(started), refresh) {
+			return
+		}
+	}
+}
+
+// wait waits until the time at, collecting license out of schedule when
+// refresh fires. It returns false if ctx is done first.
+func (c *Cache) wait(ctx context.Context, license config.License, at time.Time, refresh <-chan struct{}) bool {
+	for {
+		timer := time.NewTimer(at.Sub(c.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+			return true
+		case <-refresh:
+			// Out of schedule, the next collection stays due on time.
+			timer.Stop()
+			c.collectUnscheduled(ctx, license)
+		}
+	}
+}
+
+// retry collects license again after a failed collection that took took,
+// waiting about --cache.retry-delay and twice as long after every further
+// failure. The delays are jittered so that licenses failing together, like
+// after a network outage, don't retry together. Retries that wouldn't end
+// before the next scheduled collection at next are left to it. It returns
+// false if ctx is done.
+func (c *Cache) retry(ctx context.Context, license config.License, next time.Time, took time.Duration, refresh <-chan struct{}) bool {
+	for delay := *cacheRetryDelay; delay > 0; delay *= 2 {
+		at := c.now().Add(delay/2 + c.jitter(delay))
+		if at.Add(took).After(next) {
+			return true
+		}
+		if !c.wait(ctx, license, at, refresh) {
+			return false
+		}
+		c.schedule.retried(license.Name)
+		if c.collectUnscheduled(ctx, license) == nil {
+			return true
+		}
+	}
+	return true
+}
+
+// collectUnscheduled collects license out of schedule.
+func (c *Cache) collectUnscheduled(ctx context.Context, license config.License) error {
+	c.schedule.start(license.Name, -1)
+	defer c.schedule.finishUnscheduled()
+	_, err := c.refresh(ctx, license)
+	return err
+}
+
+// randomDuration returns a random duration in [0, d), zero if d isn't
+// positive.
+func randomDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// refresh collects license and updates its cache entry, unless it is muted.
+// After a failed collection the last good metrics keep being served, except
+// for the latestAttemptDescs. It returns the collected metrics and the
+// collection error.
+func (c *Cache) refresh(ctx context.Context, license config.License) ([]prometheus.Metric, error) {
+	// Muted licenses aren't collected, their entry is left as it is.
+	if mutes.muted(license.Name) {
+		return nil, nil
+	}
+	ctx, span := tracer.Start(ctx, "refresh", trace.WithAttributes(attribute.String("license_name", license.Name)))
+	metrics, err := c.collector.collectLicense(ctx, license)
+	endSpan(span, err)
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "background collection failed", "license", license.Name, "err", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[license.Name]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[license.Name] = entry
+	}
+	entry.attempted = c.now()
+	if err != nil {
+		entry.failed = metrics
+		return metrics, err
+	}
+	entry.good, entry.updated, entry.failed = metrics, entry.attempted, nil
+	entry.generation++
+	return metrics, nil
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *Cache) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+	c.schedule.describe(ch)
+	ch <- dataAgeDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheGenerationDesc
+}
+
+// Collect implements the prometheus.Collector interface. It doesn't count
+// cache hits and misses, see Scrape.
+func (c *Cache) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, false)
+}
+
+// Scrape returns the cache as a collector for /metrics, whose collections
+// count as cache hits or misses. Other readers of the cache, like remote
+// write, /metrics.json and reports, use the cache itself.
+func (c *Cache) Scrape() prometheus.Collector {
+	return cacheScrape{c}
+}
+
+// cacheScrape is the Cache as collected by scrapes.
+type cacheScrape struct {
+	*Cache
+}
+
+// Collect implements the prometheus.Collector interface.
+func (s cacheScrape) Collect(ch chan<- prometheus.Metric) {
+	s.collect(ch, true)
+}
+
+// collect sends the cached metrics to ch, counting the cache hits and misses
+// if scrape is set.
+func (c *Cache) collect(ch chan<- prometheus.Metric, scrape bool) {
+	c.collector.collectGlobal(context.Background(), ch)
+	c.schedule.collect(ch)
+
+	now := c.now()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, entry := range c.entries {
+		// Whether the target is up always reflects the latest attempt and
+		// is never suppressed.
+		latest := entry.good
+		if entry.failed != nil {
+			latest = entry.failed
+		}
+		for _, m := range latest {
+			if latestAttemptDescs[m.Desc()] {
+				ch <- withCollectionTime(m, entry.attempted)
+			}
+		}
+		age := now.Sub(entry.updated)
+		stale := c.maxStaleness > 0 && age > c.maxStaleness
+		switch {
+		case !scrape:
+		case entry.updated.IsZero() || stale:
+			entry.misses.Add(1)
+		default:
+			entry.hits.Add(1)
+		}
+		ch <- constMetric(cacheHitsDesc, prometheus.CounterValue, float64(entry.hits.Load()), name)
+		ch <- constMetric(cacheMissesDesc, prometheus.CounterValue, float64(entry.misses.Load()), name)
+		ch <- constMetric(cacheGenerationDesc, prometheus.CounterValue, float64(entry.generation), name)
+		if entry.updated.IsZero() {
+			continue
+		}
+
+		ch <- constMetric(dataAgeDesc, prometheus.GaugeValue, age.Seconds(), name)
+		if stale {
+			level.Debug(c.logger).Log("msg", "suppressing stale metrics", "license", name, "age", age)
+			continue
+		}
+		for _, m := range entry.good {
+			if latestAttemptDescs[m.Desc()] {
+				continue
+			}
+			ch <- withCollectionTime(m, entry.updated)
+		}
+	}
+}
+
+// withCollectionTime returns m with the timestamp t if --cache.timestamps is
+// set, m otherwise.
+func withCollectionTime(m prometheus.Metric, t time.Time) prometheus.Metric {
+	if !*cacheTimestamps {
+		return m
+	}
+	return prometheus.NewMetricWithTimestamp(t, m)
+}
@@ -0,0 +1,330 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// fakeLicenseCollector exports up and one feature gauge per license.
+type fakeLicenseCollector struct {
+	fail bool
+}
+
+func (f *fakeLicenseCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+func (f *fakeLicenseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lmstatupDesc
+	ch <- featureIssuedDesc
+}
+
+func (f *fakeLicenseCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error {
+	if f.fail {
+		ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, "N/A")
+		return errors.New("down")
+	}
+	ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 1, license.Name, "N/A")
+	ch <- prometheus.MustNewConstMetric(featureIssuedDesc, prometheus.GaugeValue, 10, license.Name, "feature1")
+	return nil
+}
+
+// collectCache scrapes c, and returns the values of the metrics by
+// descriptor.
+func collectCache(t *testing.T, c *Cache) map[*prometheus.Desc][]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Scrape().Collect(ch)
+		close(ch)
+	}()
+
+	values := make(map[*prometheus.Desc][]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("Unexpected error writing metric: %v", err)
+		}
+		value := pb.GetGauge().GetValue()
+		if pb.Counter != nil {
+			value = pb.GetCounter().GetValue()
+		}
+		values[m.Desc()] = append(values[m.Desc()], value)
+	}
+	return values
+}
+
+func TestCacheStaleness(t *testing.T) {
+	license := config.License{Name: "app1"}
+	fake := &fakeLicenseCollector{}
+	nc := &RlmlmCollector{
+		Config:     &config.Config{Licenses: []config.License{license}},
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"fake": fake},
+	}
+
+	now := time.Unix(1000, 0)
+	cache := NewCache(nc, time.Minute, 5*time.Minute, log.NewNopLogger())
+	cache.now = func() time.Time { return now }
+
+	cache.refresh(context.Background(), license)
+	values := collectCache(t, cache)
+	if got := values[dataAgeDesc]; len(got) != 1 || got[0] != 0 {
+		t.Fatalf("Unexpected data age after success: %v", got)
+	}
+	if got := values[featureIssuedDesc]; len(got) != 1 {
+		t.Fatalf("Missing feature metric after success: %v", got)
+	}
+
+	// A failure keeps the last good data but reports the latest up.
+	fake.fail = true
+	now = now.Add(2 * time.Minute)
+	cache.refresh(context.Background(), license)
+	values = collectCache(t, cache)
+	if got := values[dataAgeDesc]; len(got) != 1 || got[0] != 120 {
+		t.Fatalf("Unexpected data age after failure: %v", got)
+	}
+	if got := values[lmstatupDesc]; len(got) != 1 || got[0] != 0 {
+		t.Fatalf("Unexpected up after failure: %v", got)
+	}
+	if got := values[featureIssuedDesc]; len(got) != 1 {
+		t.Fatalf("Missing cached feature metric after failure: %v", got)
+	}
+
+	// Past the max staleness only up and the data age remain.
+	now = now.Add(4 * time.Minute)
+	values = collectCache(t, cache)
+	if got := values[featureIssuedDesc]; len(got) != 0 {
+		t.Fatalf("Stale feature metric not suppressed: %v", got)
+	}
+	if got := values[lmstatupDesc]; len(got) != 1 {
+		t.Fatalf("Missing up for stale license: %v", got)
+	}
+	if got := values[dataAgeDesc]; len(got) != 1 || got[0] != 360 {
+		t.Fatalf("Unexpected data age for stale license: %v", got)
+	}
+
+	// One successful collection, served by the first two scrapes only.
+	if got := values[cacheGenerationDesc]; len(got) != 1 || got[0] != 1 {
+		t.Fatalf("Unexpected cache generation: %v", got)
+	}
+	if got := values[cacheHitsDesc]; len(got) != 1 || got[0] != 2 {
+		t.Fatalf("Unexpected cache hits: %v", got)
+	}
+	if got := values[cacheMissesDesc]; len(got) != 1 || got[0] != 1 {
+		t.Fatalf("Unexpected cache misses: %v", got)
+	}
+
+	// Reading the cache other than by scrapes counts neither.
+	ch := make(chan prometheus.Metric)
+	go func() {
+		cache.Collect(ch)
+		close(ch)
+	}()
+	for range ch {
+	}
+	cache.mu.RLock()
+	hits, misses := cache.entries[license.Name].hits.Load(), cache.entries[license.Name].misses.Load()
+	cache.mu.RUnlock()
+	if hits != 2 || misses != 1 {
+		t.Fatalf("Expected no hits or misses outside of scrapes, got %d and %d", hits, misses)
+	}
+}
+
+func TestCacheMuted(t *testing.T) {
+	license := config.License{Name: "cache_muted_app"}
+	nc := &RlmlmCollector{
+		Config:     &config.Config{Licenses: []config.License{license}},
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"fake": &fakeLicenseCollector{}},
+	}
+	now := time.Unix(1000, 0)
+	cache := NewCache(nc, time.Minute, 0, log.NewNopLogger())
+	cache.now = func() time.Time { return now }
+	cache.refresh(context.Background(), license)
+
+	MuteLicense(license.Name, time.Hour)
+	defer UnmuteLicense(license.Name)
+	now = now.Add(2 * time.Minute)
+	cache.refresh(context.Background(), license)
+	values := collectCache(t, cache)
+	if got := values[cacheGenerationDesc]; len(got) != 1 || got[0] != 1 {
+		t.Fatalf("Expected a muted license not to count as collected, got generation %v", got)
+	}
+	if got := values[dataAgeDesc]; len(got) != 1 || got[0] != 120 {
+		t.Fatalf("Expected the data of a muted license to age, got %v", got)
+	}
+}
+
+func TestCacheTimestamps(t *testing.T) {
+	old := *cacheTimestamps
+	t.Cleanup(func() { *cacheTimestamps = old })
+	*cacheTimestamps = true
+
+	license := config.License{Name: "app1"}
+	fake := &fakeLicenseCollector{}
+	nc := &RlmlmCollector{
+		Config:     &config.Config{Licenses: []config.License{license}},
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"fake": fake},
+	}
+	now := time.Unix(1000, 0)
+	cache := NewCache(nc, time.Minute, 0, log.NewNopLogger())
+	cache.now = func() time.Time { return now }
+	cache.refresh(context.Background(), license)
+	// The failure is timestamped with the latest attempt, the features with
+	// the last success.
+	fake.fail = true
+	now = now.Add(time.Minute)
+	cache.refresh(context.Background(), license)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		cache.Collect(ch)
+		close(ch)
+	}()
+	timestamps := make(map[*prometheus.Desc]int64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		timestamps[m.Desc()] = pb.GetTimestampMs()
+	}
+	if timestamps[featureIssuedDesc] != 1000000 || timestamps[lmstatupDesc] != 1060000 {
+		t.Fatalf("Unexpected timestamps %v", timestamps)
+	}
+	// The exporter's own metrics are current.
+	if timestamps[dataAgeDesc] != 0 {
+		t.Fatalf("Unexpected timestamp of the data age %d", timestamps[dataAgeDesc])
+	}
+}
+
+func TestCacheRefreshLicense(t *testing.T) {
+	license := config.License{Name: "app1"}
+	fake := &fakeLicenseCollector{}
+	nc := &RlmlmCollector{
+		Config:     &config.Config{Licenses: []config.License{license}},
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"fake": fake},
+	}
+	cache := NewCache(nc, time.Minute, 0, log.NewNopLogger())
+
+	if _, err := cache.RefreshLicense(context.Background(), "app2"); !errors.Is(err, ErrLicenseNotFound) {
+		t.Fatalf("Unexpected error for unknown license: %v", err)
+	}
+
+	result, err := cache.RefreshLicense(context.Background(), "app1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Error != "" || len(result.Samples) != 2 {
+		t.Fatalf("Unexpected collection %+v", result)
+	}
+	issued := result.Samples[0]
+	if issued.Name != "rlmlm_feature_issued" || issued.Labels["feature"] != "feature1" || issued.Value != 10 {
+		t.Fatalf("Unexpected sample %+v", issued)
+	}
+	if got := collectCache(t, cache)[featureIssuedDesc]; len(got) != 1 {
+		t.Fatalf("Refresh didn't update the cache: %v", got)
+	}
+
+	fake.fail = true
+	if result, err = cache.RefreshLicense(context.Background(), "app1"); err != nil || result.Error == "" {
+		t.Fatalf("Expected the collection error in the result, got %+v, %v", result, err)
+	}
+}
+
+// flakyLicenseCollector fails the first failures collections and signals
+// every collection.
+type flakyLicenseCollector struct {
+	failures  int
+	collected chan error
+}
+
+func (f *flakyLicenseCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+func (f *flakyLicenseCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (f *flakyLicenseCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error {
+	var err error
+	if f.failures > 0 {
+		f.failures--
+		err = errors.New("down")
+	}
+	f.collected <- err
+	return err
+}
+
+func TestCacheRetry(t *testing.T) {
+	old := *cacheRetryDelay
+	t.Cleanup(func() { *cacheRetryDelay = old })
+	*cacheRetryDelay = 20 * time.Millisecond
+
+	flaky := &flakyLicenseCollector{failures: 2, collected: make(chan error, 10)}
+	license := config.License{Name: "app1"}
+	nc := &RlmlmCollector{
+		Config:     &config.Config{Licenses: []config.License{license}},
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"flaky": flaky},
+	}
+	cache := NewCache(nc, time.Hour, 0, log.NewNopLogger())
+	cache.jitter = func(time.Duration) time.Duration { return 0 }
+
+	// A retry that wouldn't end before the next scheduled collection is left
+	// to it.
+	if !cache.retry(context.Background(), license, cache.now().Add(time.Second), time.Minute, nil) {
+		t.Fatal("Unexpected stop")
+	}
+	if len(flaky.collected) != 0 {
+		t.Fatal("Unexpected retry past the next scheduled collection")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx)
+	for i, want := range []bool{true, true, false} {
+		select {
+		case err := <-flaky.collected:
+			if (err != nil) != want {
+				t.Fatalf("Collection %d: unexpected error %v", i, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Collection %d not retried", i)
+		}
+	}
+	select {
+	case <-flaky.collected:
+		t.Fatal("Unexpected retry after a successful collection")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cache.schedule.mu.Lock()
+	defer cache.schedule.mu.Unlock()
+	if retries := cache.schedule.licenses["app1"].retries; retries != 2 {
+		t.Fatalf("Expected 2 retries, got %v", retries)
+	}
+}
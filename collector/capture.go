@@ -0,0 +1,106 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const capturePrefix = "rlmstat-"
+
+var (
+	captureDir = kingpin.Flag("debug.capture-dir",
+		"Save the raw rlmstat output of parse failures to this directory, empty disables it.").Default("").String()
+	captureMaxFiles = kingpin.Flag("debug.capture-max-files",
+		"Maximum number of parse failure captures kept, older ones are removed.").Default("20").Int()
+	captureInterval = kingpin.Flag("debug.capture-interval",
+		"Minimum time between two parse failure captures of the same license.").Default("10m").Duration()
+
+	// unsafeFileChars are replaced in license names used in capture file names.
+	unsafeFileChars = regexp.MustCompile(`[^\w\-\.]+`)
+
+	// lastCapture is the time of the last capture by license name.
+	lastCapture   = make(map[string]time.Time)
+	lastCaptureMu sync.Mutex
+)
+
+// captureParseFailure saves the output of a command that couldn't be parsed
+// to --debug.capture-dir, at most once per --debug.capture-interval per
+// license, keeping the newest --debug.capture-max-files captures.
+func captureParseFailure(logger log.Logger, license string, args []string, out []byte, parseErr error) {
+	if *captureDir == "" {
+		return
+	}
+
+	now := time.Now()
+	lastCaptureMu.Lock()
+	if last, ok := lastCapture[license]; ok && now.Sub(last) < *captureInterval {
+		lastCaptureMu.Unlock()
+		return
+	}
+	lastCapture[license] = now
+	lastCaptureMu.Unlock()
+
+	name := fmt.Sprintf("%s%s-%s.txt", capturePrefix, now.UTC().Format("20060102T150405.000Z"),
+		unsafeFileChars.ReplaceAllString(license, "_"))
+	path := filepath.Join(*captureDir, name)
+	header := fmt.Sprintf("# time: %s\n# license: %s\n# command: %s\n# error: %s\n",
+		now.UTC().Format(time.RFC3339), license, rlmstatCommand(license, args...), parseErr)
+	if err := os.WriteFile(path, append([]byte(header), out...), 0o600); err != nil {
+		level.Warn(logger).Log("msg", "couldn't save parse failure capture", "path", path, "err", err)
+		return
+	}
+	level.Info(logger).Log("msg", "saved rlmstat output that couldn't be parsed", "license", license, "path", path)
+
+	if err := pruneCaptures(*captureDir, *captureMaxFiles); err != nil {
+		level.Warn(logger).Log("msg", "couldn't prune parse failure captures", "dir", *captureDir, "err", err)
+	}
+}
+
+// pruneCaptures removes the oldest captures in dir beyond max. Capture names
+// start with their timestamp, so they sort chronologically.
+func pruneCaptures(dir string, max int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var captures []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), capturePrefix) {
+			captures = append(captures, entry.Name())
+		}
+	}
+	if len(captures) <= max {
+		return nil
+	}
+	sort.Strings(captures)
+	for _, name := range captures[:len(captures)-max] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestCaptureParseFailure(t *testing.T) {
+	oldDir, oldMax, oldInterval := *captureDir, *captureMaxFiles, *captureInterval
+	defer func() {
+		*captureDir, *captureMaxFiles, *captureInterval = oldDir, oldMax, oldInterval
+		lastCapture = make(map[string]time.Time)
+	}()
+
+	*captureDir = t.TempDir()
+	*captureMaxFiles = 2
+	*captureInterval = time.Hour
+	logger := log.NewNopLogger()
+
+	captureParseFailure(logger, "app1", []string{"-a"}, []byte("garbage"), errUnparseableOutput)
+	// Throttled, app1 was captured less than an hour ago.
+	captureParseFailure(logger, "app1", []string{"-a"}, []byte("garbage"), errUnparseableOutput)
+	entries, err := os.ReadDir(*captureDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 capture, found %d", len(entries))
+	}
+	data, err := os.ReadFile(*captureDir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# license: app1") || !strings.HasSuffix(string(data), "garbage") {
+		t.Fatalf("Unexpected capture content %q", data)
+	}
+
+	captureParseFailure(logger, "app 2", []string{"-a"}, []byte("garbage"), errUnparseableOutput)
+	captureParseFailure(logger, "app3", []string{"-a"}, []byte("garbage"), errUnparseableOutput)
+	entries, err = os.ReadDir(*captureDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected captures to be pruned to 2, found %d", len(entries))
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "app1") || strings.Contains(entry.Name(), " ") {
+			t.Fatalf("Unexpected capture %s", entry.Name())
+		}
+	}
+}
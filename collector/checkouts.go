@@ -0,0 +1,108 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	featureCheckoutEventsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "checkout_events_total"),
+		"Estimated number of checkouts of a feature since the exporter started, from the changes between collections.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+
+	checkouts = newCheckoutTracker(writeCheckoutEvents)
+)
+
+// featureKey identifies a feature of a license.
+type featureKey struct {
+	license string
+	feature string
+}
+
+// checkoutSnapshot is the usage of a feature at the previous collection.
+type checkoutSnapshot struct {
+	used  float64
+	users map[string]float64
+}
+
+// checkoutTracker estimates checkouts from the usage of consecutive
+// collections. Checkouts and checkins between two collections that cancel
+// out go unnoticed, so the estimate is a lower bound.
+type checkoutTracker struct {
+	mu    sync.Mutex
+	prev  map[featureKey]checkoutSnapshot
+	total map[featureKey]float64
+	// record, if set, receives the checkouts and checkins of users.
+	record func([]checkoutEvent)
+}
+
+func newCheckoutTracker(record func([]checkoutEvent)) *checkoutTracker {
+	return &checkoutTracker{
+		prev:   make(map[featureKey]checkoutSnapshot),
+		total:  make(map[featureKey]float64),
+		record: record,
+	}
+}
+
+// observe records the usage of feature and returns its checkout count. The
+// count is the increase of used licenses, or the licenses users gained if
+// that is larger: a user checking in while another one checks out leaves the
+// total unchanged. The first observation of a feature counts nothing.
+func (t *checkoutTracker) observe(license, feature string, used float64, users map[string]float64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := featureKey{license, feature}
+	prev, seen := t.prev[key]
+	snapshot := checkoutSnapshot{used: used, users: make(map[string]float64, len(users))}
+	for user, n := range users {
+		snapshot.users[user] = n
+	}
+	t.prev[key] = snapshot
+	if !seen {
+		return t.total[key]
+	}
+	if t.record != nil && usersKnown(prev.used, prev.users) && usersKnown(used, users) {
+		if events := userEvents(license, feature, prev.users, users); len(events) > 0 {
+			t.record(events)
+		}
+	}
+
+	events := used - prev.used
+	var gained float64
+	for user, n := range users {
+		if n > prev.users[user] {
+			gained += n - prev.users[user]
+		}
+	}
+	if gained > events {
+		events = gained
+	}
+	if events > 0 {
+		t.total[key] += events
+	}
+	return t.total[key]
+}
+
+// usersKnown returns whether users lists the holders of used licenses, as
+// rlmstat output doesn't always list them.
+func usersKnown(used float64, users map[string]float64) bool {
+	return len(users) > 0 || used == 0
+}
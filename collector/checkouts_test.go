@@ -0,0 +1,41 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestCheckoutTracker(t *testing.T) {
+	tr := newCheckoutTracker(nil)
+	for i, step := range []struct {
+		used     float64
+		users    map[string]float64
+		expected float64
+	}{
+		// The first collection is the baseline.
+		{5, map[string]float64{"user1": 5}, 0},
+		{7, map[string]float64{"user1": 5, "user2": 2}, 2},
+		// user2 checked in and user3 out, the total is unchanged.
+		{7, map[string]float64{"user1": 5, "user3": 2}, 4},
+		{3, map[string]float64{"user1": 3}, 4},
+		// Without users, the increase of the total counts.
+		{6, nil, 7},
+	} {
+		if total := tr.observe("app1", "feature1", step.used, step.users); total != step.expected {
+			t.Fatalf("Step %d: expected %v checkouts, got %v", i, step.expected, total)
+		}
+	}
+	if total := tr.observe("app2", "feature1", 10, nil); total != 0 {
+		t.Fatalf("Expected no checkouts for a new license, got %v", total)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// ErrLicenseNotFound is returned for license names missing from the configuration.
+var ErrLicenseNotFound = errors.New("license not found")
+
+// LicenseCollection is the result of collecting a single license on demand.
+type LicenseCollection struct {
+	License string   `json:"license_name"`
+	Samples []Sample `json:"samples"`
+	// Error is set if any collector failed, Samples then holds what was
+	// collected anyway.
+	Error string `json:"error,omitempty"`
+}
+
+// Sample is a single collected sample.
+type Sample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// CollectLicense runs every collector for the license named name right away.
+func (c RlmlmCollector) CollectLicense(ctx context.Context, name string) (*LicenseCollection, error) {
+	license, ok := c.license(name)
+	if !ok {
+		return nil, ErrLicenseNotFound
+	}
+	metrics, err := c.collectLicense(ctx, license)
+	return newLicenseCollection(name, metrics, err)
+}
+
+// RefreshLicense collects the license named name right away and updates its
+// cached metrics.
+func (c *Cache) RefreshLicense(ctx context.Context, name string) (*LicenseCollection, error) {
+	license, ok := c.collector.license(name)
+	if !ok {
+		return nil, ErrLicenseNotFound
+	}
+	metrics, err := c.refresh(ctx, license)
+	return newLicenseCollection(name, metrics, err)
+}
+
+// license returns the configured license named name.
+func (c RlmlmCollector) license(name string) (config.License, bool) {
+	if c.Config == nil {
+		return config.License{}, false
+	}
+	for _, license := range c.Config.Licenses {
+		if license.Name == name {
+			return license, true
+		}
+	}
+	return config.License{}, false
+}
+
+// metricList sends a fixed list of metrics, to gather them with a registry.
+type metricList []prometheus.Metric
+
+func (l metricList) Describe(ch chan<- *prometheus.Desc) {}
+
+func (l metricList) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range l {
+		ch <- m
+	}
+}
+
+func newLicenseCollection(name string, metrics []prometheus.Metric, err error) (*LicenseCollection, error) {
+	registry := prometheus.NewRegistry()
+	if regErr := registry.Register(metricList(metrics)); regErr != nil {
+		return nil, regErr
+	}
+	families, gatherErr := registry.Gather()
+	if gatherErr != nil {
+		return nil, gatherErr
+	}
+
+	result := &LicenseCollection{License: name, Samples: []Sample{}}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			result.Samples = append(result.Samples, Sample{Name: family.GetName(), Labels: labels, Value: sampleValue(m)})
+		}
+	}
+	sort.SliceStable(result.Samples, func(i, j int) bool { return result.Samples[i].Name < result.Samples[j].Name })
+	return result, nil
+}
+
+// sampleValue returns the value of a gauge, counter or untyped metric.
+func sampleValue(m *dto.Metric) float64 {
+	switch {
+	case m.Gauge != nil:
+		return m.GetGauge().GetValue()
+	case m.Counter != nil:
+		return m.GetCounter().GetValue()
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
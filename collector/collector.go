@@ -16,6 +16,8 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -24,6 +26,8 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/iambengiey/rlmlm_exporter/config" // Import config package
 )
@@ -44,6 +48,18 @@ var (
 		[]string{"collector"},
 		nil,
 	)
+	configTargetInvalidDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "config", "target_invalid"),
+		"rlmlm_exporter: License entries rejected at config load because of an invalid target, labeled by reason.",
+		[]string{"license_name", "reason"},
+		nil,
+	)
+	configInvalidEntriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "config", "invalid_entries"),
+		"rlmlm_exporter: License entries skipped at config load because they are invalid, labeled by reason.",
+		[]string{"license_name", "reason"},
+		nil,
+	)
 )
 
 const (
@@ -54,8 +70,11 @@ const (
 var (
 	factories      = make(map[string]func(*config.Config, log.Logger) (Collector, error))
 	collectorState = make(map[string]*bool)
-	defaultConfig  *config.Config
-	defaultLogger  log.Logger = log.NewNopLogger()
+	// collectorStateMu guards the values of collectorState, which can be
+	// toggled at runtime.
+	collectorStateMu sync.RWMutex
+	defaultConfig    *config.Config
+	defaultLogger    log.Logger = log.NewNopLogger()
 )
 
 // SetConfig allows the main package to provide the parsed configuration so that
@@ -63,6 +82,7 @@ var (
 // operate without requiring callers to thread the value through manually.
 func SetConfig(cfg *config.Config) {
 	defaultConfig = cfg
+	scrapeCollectors.reset()
 }
 
 // SetLogger stores a reusable logger for helper constructors and collectors
@@ -70,6 +90,7 @@ func SetConfig(cfg *config.Config) {
 func SetLogger(logger log.Logger) {
 	if logger != nil {
 		defaultLogger = logger
+		scrapeCollectors.reset()
 	}
 }
 
@@ -77,13 +98,27 @@ func SetLogger(logger log.Logger) {
 // that only provided a list of collector filters. It relies on the
 // configuration and logger set via SetConfig/SetLogger.
 func NewFlexlmCollector(filters ...string) (*RlmlmCollector, error) {
-	return NewRlmlmCollector(defaultConfig, defaultLogger, filters...)
+	return NewRlmlmCollector(defaultConfig, defaultLogger, WithFilters(filters...))
 }
 
 // Collector is the interface a collector has to implement.
 type Collector interface {
 	// Get new metrics and expose them via prometheus registry.
-	Update(ch chan<- prometheus.Metric) error
+	Update(ctx context.Context, ch chan<- prometheus.Metric) error
+	// Describe sends the descriptors of every metric Update can send.
+	Describe(ch chan<- *prometheus.Desc)
+}
+
+// licenseUpdater is implemented by collectors that can collect a single
+// license on its own, as the background cache does.
+type licenseUpdater interface {
+	UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error
+}
+
+// globalUpdater is implemented by license updaters that also export metrics
+// not tied to any license.
+type globalUpdater interface {
+	UpdateGlobal(ctx context.Context, ch chan<- prometheus.Metric) error
 }
 
 func registerCollector(collector string, isDefaultEnabled bool, factory func(*config.Config, log.Logger) (Collector, error)) {
@@ -104,15 +139,89 @@ func registerCollector(collector string, isDefaultEnabled bool, factory func(*co
 	factories[collector] = factory
 }
 
+// SetCollectorEnabled enables or disables a collector at runtime. It applies
+// to collectors created afterwards, and to scrapes.
+func SetCollectorEnabled(name string, enabled bool) error {
+	collectorStateMu.Lock()
+	state, ok := collectorState[name]
+	if !ok {
+		collectorStateMu.Unlock()
+		return fmt.Errorf("missing collector: %s", name)
+	}
+	*state = enabled
+	collectorStateMu.Unlock()
+
+	// Building the scrape collectors takes the state lock, so they are reset
+	// once it is released.
+	scrapeCollectors.reset()
+	return nil
+}
+
 // RlmlmCollector implements the prometheus.Collector interface, storing config and logger.
 type RlmlmCollector struct {
 	Config     *config.Config
 	Logger     log.Logger
 	Collectors map[string]Collector
+	// Deadline, if set, is when scrapes stop waiting for licenses.
+	Deadline time.Time
+}
+
+// Option configures a collector created by NewRlmlmCollector.
+type Option func(*options)
+
+type options struct {
+	// state overrides the collector flags by collector name.
+	state    map[string]bool
+	filters  []string
+	licenses []string
+	deadline time.Time
+}
+
+// WithEnabled enables the named collectors regardless of their flags.
+func WithEnabled(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.state[name] = true
+		}
+	}
+}
+
+// WithDisabled disables the named collectors regardless of their flags.
+func WithDisabled(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.state[name] = false
+		}
+	}
+}
+
+// WithFilters restricts the collector to the named collectors, which must be
+// enabled.
+func WithFilters(names ...string) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, names...)
+	}
+}
+
+// WithLicenses restricts the collector to the named licenses of the
+// configuration, which must exist.
+func WithLicenses(names ...string) Option {
+	return func(o *options) {
+		o.licenses = append(o.licenses, names...)
+	}
+}
+
+// WithDeadline makes scrapes send the licenses collected by deadline and
+// report the others as missing, rather than waiting for all of them.
+func WithDeadline(deadline time.Time) Option {
+	return func(o *options) {
+		o.deadline = deadline
+	}
 }
 
 // NewRlmlmCollector creates a new RlmlmCollector, replacing the old NewFlexlmCollector.
-func NewRlmlmCollector(cfg *config.Config, logger log.Logger, filters ...string) (*RlmlmCollector, error) {
+// Collectors are enabled according to their flags unless overridden by opts.
+func NewRlmlmCollector(cfg *config.Config, logger log.Logger, opts ...Option) (*RlmlmCollector, error) {
 	if logger == nil {
 		logger = defaultLogger
 	}
@@ -127,21 +236,47 @@ func NewRlmlmCollector(cfg *config.Config, logger log.Logger, filters ...string)
 		return nil, fmt.Errorf("no configuration loaded")
 	}
 
+	o := options{state: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for name := range o.state {
+		if _, exist := factories[name]; !exist {
+			return nil, fmt.Errorf("missing collector: %s", name)
+		}
+	}
+	if len(o.licenses) > 0 {
+		var err error
+		if cfg, err = licenseSubset(cfg, o.licenses); err != nil {
+			return nil, err
+		}
+	}
+
+	collectorStateMu.RLock()
+	state := make(map[string]bool, len(collectorState))
+	for name, enabled := range collectorState {
+		state[name] = *enabled
+	}
+	collectorStateMu.RUnlock()
+	for name, enabled := range o.state {
+		state[name] = enabled
+	}
+
 	f := make(map[string]bool)
-	for _, filter := range filters {
-		enabled, exist := collectorState[filter]
+	for _, filter := range o.filters {
+		enabled, exist := state[filter]
 		if !exist {
 			return nil, fmt.Errorf("missing collector: %s", filter)
 		}
-		if !*enabled {
+		if !enabled {
 			return nil, fmt.Errorf("disabled collector: %s", filter)
 		}
 		f[filter] = true
 	}
 
 	collectors := make(map[string]Collector)
-	for key, enabled := range collectorState {
-		if *enabled {
+	for key, enabled := range state {
+		if enabled {
 			// Pass config and logger to the factory function
 			collector, err := factories[key](cfg, logger)
 			if err != nil {
@@ -157,38 +292,226 @@ func NewRlmlmCollector(cfg *config.Config, logger log.Logger, filters ...string)
 		Config:     cfg,
 		Logger:     logger,
 		Collectors: collectors,
+		Deadline:   o.deadline,
 	}, nil
 }
 
+// licenseSubset returns a copy of cfg with only the named licenses.
+func licenseSubset(cfg *config.Config, names []string) (*config.Config, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	subset := *cfg
+	subset.Licenses = nil
+	for _, license := range cfg.Licenses {
+		if wanted[license.Name] {
+			subset.Licenses = append(subset.Licenses, license)
+			delete(wanted, license.Name)
+		}
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("missing license: %s", name)
+		}
+	}
+	return &subset, nil
+}
+
+// CheckDescriptors registers every collector, enabled or not, with a pedantic
+// registry, so that conflicting metric descriptors (the same name with
+// different labels or help) are reported at startup rather than on scrapes.
+func CheckDescriptors(cfg *config.Config, logger log.Logger) error {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	nc, err := NewRlmlmCollector(cfg, logger, WithEnabled(names...))
+	if err != nil {
+		return err
+	}
+	return prometheus.NewPedanticRegistry().Register(NewCache(nc, 0, 0, logger))
+}
+
 // Describe implements the prometheus.Collector interface.
 func (c RlmlmCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- scrapeDurationDesc
 	ch <- scrapeSuccessDesc
+	ch <- binaryAvailableDesc
+	ch <- configTargetInvalidDesc
+	ch <- configInvalidEntriesDesc
+	ch <- execInFlightDesc
+	ch <- execQueuedDesc
+	ch <- execWaitSecondsDesc
+	ch <- execTotalDesc
+	ch <- subprocessCPUDesc
+	ch <- subprocessMaxRSSDesc
+	ch <- subprocessRunsDesc
+	ch <- errorsDesc
+	ch <- scrapeIncompleteDesc
+	ch <- scrapeMissingLicenseDesc
+	ch <- licenseFileReloadDesc
+	ch <- licenseFileReadErrorsDesc
+	ch <- featureAppearedDesc
+	ch <- featureDisappearedDesc
+	ch <- featureFirstSeenDesc
+	ch <- featureLastSeenDesc
+	ch <- isvLastSuccessDesc
+	ch <- execSharedDesc
+	ch <- licenseMutedDesc
+	ch <- licenseTenantDesc
+	ch <- rlmstatStderrDesc
+	ch <- parseDurationDesc
+	ch <- commandPhaseDesc
+	ch <- billingSeatHoursDesc
+	ch <- exhaustedHookRunsDesc
+	for _, collector := range c.Collectors {
+		collector.Describe(ch)
+	}
 }
 
 // Collect implements the prometheus.Collector interface.
 func (c RlmlmCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, span := tracer.Start(withCommandCache(context.Background()), "scrape")
+	defer span.End()
+
+	if !c.Deadline.IsZero() {
+		ctx, cancel := context.WithDeadline(ctx, c.Deadline)
+		defer cancel()
+		c.collectUntilDeadline(ctx, ch)
+		return
+	}
+
+	c.collectExporter(ch)
+
 	wg := sync.WaitGroup{}
 	wg.Add(len(c.Collectors))
 	for name, collector := range c.Collectors {
 		go func(name string, collector Collector) {
-			c.execute(name, collector, ch)
+			c.execute(ctx, name, collector, ch)
 			wg.Done()
 		}(name, collector)
 	}
 	wg.Wait()
 }
 
+// collectExporter sends the metrics describing the exporter itself.
+func (c RlmlmCollector) collectExporter(ch chan<- prometheus.Metric) {
+	ch <- binaryAvailableMetric()
+	pool.collect(ch)
+	usage.collect(ch)
+	collectionErrors.collect(ch)
+	licenseFileReloads.collect(ch)
+	licenseFileReadErrors.collect(ch)
+	featureChurn.collect(ch)
+	featureSeen.collect(ch)
+	isvContacts.collect(ch)
+	execShared.collect(ch)
+	stderrLines.collect(ch)
+	parseDurations.collect(ch)
+	commandPhaseTimes.collect(ch)
+	billing.collect(ch)
+	exhaustion.collect(ch)
+	mutes.collect(ch, c.Config)
+	collectTenants(ch, c.Config)
+	// Entries without a name or defined twice can be rejected more than once.
+	seen := make(map[[2]string]bool, len(c.Config.Rejected))
+	for _, rejected := range c.Config.Rejected {
+		key := [2]string{rejected.License, rejected.Reason}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ch <- constMetric(configInvalidEntriesDesc, prometheus.GaugeValue, 1, rejected.License, rejected.Reason)
+		var te *config.TargetError
+		if errors.As(rejected.Err, &te) {
+			ch <- constMetric(configTargetInvalidDesc, prometheus.GaugeValue, 1, rejected.License, rejected.Reason)
+		}
+	}
+}
+
+// collectGlobal sends the exporter metrics and the metrics of all collectors
+// that aren't tied to a license.
+func (c RlmlmCollector) collectGlobal(ctx context.Context, ch chan<- prometheus.Metric) {
+	c.collectExporter(ch)
+	for name, collector := range c.Collectors {
+		g, ok := collector.(globalUpdater)
+		if !ok {
+			continue
+		}
+		if err := g.UpdateGlobal(ctx, ch); err != nil {
+			level.Error(c.Logger).Log("msg", "collector failed", "collector", name, "err", err)
+		}
+	}
+}
+
+// collectLicense runs every collector able to collect a single license for
+// license, returning the collected metrics and the errors joined. Muted
+// licenses yield no metrics.
+func (c RlmlmCollector) collectLicense(ctx context.Context, license config.License) ([]prometheus.Metric, error) {
+	metrics, errsByCollector := c.collectLicenseByCollector(ctx, license)
+	var errs []error
+	for name, err := range errsByCollector {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return metrics, errors.Join(errs...)
+}
+
+// collectLicenseByCollector is collectLicense with the error of every
+// collector of the license, nil if it succeeded.
+func (c RlmlmCollector) collectLicenseByCollector(ctx context.Context, license config.License) ([]prometheus.Metric, map[string]error) {
+	var (
+		metrics []prometheus.Metric
+		errs    = make(map[string]error)
+		ch      = make(chan prometheus.Metric)
+		done    = make(chan struct{})
+	)
+	if mutes.muted(license.Name) {
+		return nil, errs
+	}
+	// The collectors share the rlmstat runs of the license, unless the
+	// scrape already shares them among all licenses.
+	if _, ok := ctx.Value(commandCacheKey{}).(*commandCache); !ok {
+		ctx = withCommandCache(ctx)
+	}
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	for name, collector := range c.Collectors {
+		u, ok := collector.(licenseUpdater)
+		if !ok {
+			continue
+		}
+		errs[name] = u.UpdateLicense(ctx, ch, license)
+	}
+	close(ch)
+	<-done
+	return metrics, errs
+}
+
 // execute runs the collector and handles logging the result.
-func (c RlmlmCollector) execute(name string, collector Collector, ch chan<- prometheus.Metric) {
+func (c RlmlmCollector) execute(ctx context.Context, name string, collector Collector, ch chan<- prometheus.Metric) {
+	ctx, span := tracer.Start(ctx, "collector", trace.WithAttributes(attribute.String("collector", name)))
 	begin := time.Now()
-	err := collector.Update(ch)
+	err := collector.Update(ctx, ch)
 	duration := time.Since(begin)
+	endSpan(span, err)
 	var success float64
 
 	if err != nil {
 		// --- LOGGING MIGRATION: log.Errorf -> level.Error(c.Logger).Log() ---
-		level.Error(c.Logger).Log(
+		logger := level.Error(c.Logger)
+		if errors.Is(err, errRlmstatUnavailable) {
+			// A missing binary is logged once when it is looked up.
+			logger = level.Debug(c.Logger)
+		}
+		logger.Log(
 			"msg", "collector failed",
 			"collector", name,
 			"duration_seconds", duration.Seconds(),
@@ -205,8 +528,8 @@ func (c RlmlmCollector) execute(name string, collector Collector, ch chan<- prom
 		success = 1
 	}
 
-	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
-	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+	ch <- constMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- constMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
 }
 
 type typedDesc struct {
@@ -215,5 +538,5 @@ type typedDesc struct {
 }
 
 func (d *typedDesc) mustNewConstMetric(value float64, labels ...string) prometheus.Metric {
-	return prometheus.MustNewConstMetric(d.desc, d.valueType, value, labels...)
+	return constMetric(d.desc, d.valueType, value, labels...)
 }
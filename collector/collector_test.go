@@ -0,0 +1,154 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestSetCollectorEnabled(t *testing.T) {
+	old := *collectorState["lmstat_feature_exp"]
+	defer func() { *collectorState["lmstat_feature_exp"] = old }()
+
+	if err := SetCollectorEnabled("nonexistent", true); err == nil {
+		t.Fatal("Expected error for unknown collector")
+	}
+	if err := SetCollectorEnabled("lmstat_feature_exp", true); err != nil {
+		t.Fatal(err)
+	}
+	nc, err := NewRlmlmCollector(&config.Config{}, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nc.Collectors["lmstat_feature_exp"]; !ok {
+		t.Fatal("Enabled collector wasn't created")
+	}
+
+	if err := SetCollectorEnabled("lmstat_feature_exp", false); err != nil {
+		t.Fatal(err)
+	}
+	nc, err = NewRlmlmCollector(&config.Config{}, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nc.Collectors["lmstat_feature_exp"]; ok {
+		t.Fatal("Disabled collector was created")
+	}
+	if _, err := NewRlmlmCollector(&config.Config{}, log.NewNopLogger(), WithFilters("lmstat_feature_exp")); err == nil {
+		t.Fatal("Expected error filtering on a disabled collector")
+	}
+}
+
+func TestNewRlmlmCollectorOptions(t *testing.T) {
+	lmstat, featureExp := *collectorState["lmstat"], *collectorState["lmstat_feature_exp"]
+	nc, err := NewRlmlmCollector(&config.Config{}, log.NewNopLogger(), WithEnabled("lmstat_feature_exp"), WithDisabled("lmstat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nc.Collectors["lmstat_feature_exp"]; !ok {
+		t.Fatal("Enabled collector wasn't created")
+	}
+	if _, ok := nc.Collectors["lmstat"]; ok {
+		t.Fatal("Disabled collector was created")
+	}
+	if *collectorState["lmstat"] != lmstat || *collectorState["lmstat_feature_exp"] != featureExp {
+		t.Fatal("Options changed the collector flags")
+	}
+
+	nc, err = NewRlmlmCollector(&config.Config{}, log.NewNopLogger(), WithEnabled("lmstat_feature_exp"), WithFilters("lmstat_feature_exp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nc.Collectors) != 1 {
+		t.Fatalf("Expected 1 collector, got %d", len(nc.Collectors))
+	}
+
+	if _, err := NewRlmlmCollector(&config.Config{}, log.NewNopLogger(), WithEnabled("nonexistent")); err == nil {
+		t.Fatal("Expected error enabling an unknown collector")
+	}
+	if _, err := NewRlmlmCollector(&config.Config{}, log.NewNopLogger(), WithDisabled("lmstat"), WithFilters("lmstat")); err == nil {
+		t.Fatal("Expected error filtering on a disabled collector")
+	}
+}
+
+func TestNewRlmlmCollectorLicenses(t *testing.T) {
+	cfg := &config.Config{Licenses: []config.License{
+		{Name: "app1", LicenseServer: "27000@host1"},
+		{Name: "app2", LicenseServer: "27000@host2"},
+		{Name: "app3", LicenseServer: "27000@host3"},
+	}}
+	nc, err := NewRlmlmCollector(cfg, log.NewNopLogger(), WithLicenses("app3", "app1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := nc.Config.Licenses; len(l) != 2 || l[0].Name != "app1" || l[1].Name != "app3" {
+		t.Fatalf("Unexpected licenses %v", l)
+	}
+	if len(cfg.Licenses) != 3 {
+		t.Fatal("Filtering licenses changed the configuration")
+	}
+	if _, err := NewRlmlmCollector(cfg, log.NewNopLogger(), WithLicenses("app1", "app4")); err == nil {
+		t.Fatal("Expected error filtering on a missing license")
+	}
+}
+
+func TestCollectRejectedLicenses(t *testing.T) {
+	cfg := &config.Config{Rejected: []config.Rejection{
+		{License: "bad", Reason: config.ReasonRelativeFile, Err: &config.TargetError{Reason: config.ReasonRelativeFile, Err: errors.New("relative")}},
+		{License: "app1", Reason: config.ReasonDuplicateName, Err: errors.New("twice")},
+		{License: "app1", Reason: config.ReasonDuplicateName, Err: errors.New("twice")},
+	}}
+	ch := make(chan prometheus.Metric, 100)
+	RlmlmCollector{Config: cfg}.collectExporter(ch)
+	close(ch)
+
+	counts := make(map[*prometheus.Desc]int)
+	for m := range ch {
+		counts[m.Desc()]++
+	}
+	if counts[configInvalidEntriesDesc] != 2 || counts[configTargetInvalidDesc] != 1 {
+		t.Fatalf("Expected 2 invalid entries and 1 invalid target, got %d and %d",
+			counts[configInvalidEntriesDesc], counts[configTargetInvalidDesc])
+	}
+}
+
+func TestCheckDescriptors(t *testing.T) {
+	if err := CheckDescriptors(&config.Config{}, log.NewNopLogger()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDescribeComplete(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": "fixtures/lmstat_app1.txt"})
+
+	cfg := &config.Config{Licenses: []config.License{{Name: "app1", LicenseServer: "27000@host1", MonitorUsers: true, TopUsers: 2}}}
+	nc, err := NewRlmlmCollector(cfg, log.NewNopLogger(), WithEnabled("lmstat"), WithFilters("lmstat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pedantic registry rejects collected metrics that weren't described.
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(nc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("Unexpected error gathering: %v", err)
+	}
+}
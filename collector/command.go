@@ -0,0 +1,186 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// rlmstatEnv is added to the exporter's environment for every rlmstat run.
+var rlmstatEnv = []string{"LANG=C"}
+
+// Command describes one external command run by a collector.
+type Command struct {
+	Collector string
+	// License is empty for commands that aren't run per license.
+	License string
+	Path    string
+	Args    []string
+	// Env lists the variables set on top of the exporter's environment.
+	Env []string
+	// Condition explains when the command runs, empty if it always does.
+	Condition string
+}
+
+// String formats the command as a shell command line.
+func (c Command) String() string {
+	parts := make([]string, 0, len(c.Env)+len(c.Args)+1)
+	for _, env := range c.Env {
+		parts = append(parts, shellQuote(env))
+	}
+	parts = append(parts, shellQuote(c.Path))
+	for _, arg := range c.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// commandLister is implemented by collectors that run external commands.
+type commandLister interface {
+	commands() []Command
+}
+
+// DryRun returns the commands the enabled collectors would run on a scrape,
+// without executing anything.
+func (c RlmlmCollector) DryRun() []Command {
+	names := make([]string, 0, len(c.Collectors))
+	for name := range c.Collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cmds []Command
+	for _, name := range names {
+		lister, ok := c.Collectors[name].(commandLister)
+		if !ok {
+			continue
+		}
+		for _, cmd := range lister.commands() {
+			cmd.Collector = name
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// rlmstatCommand describes an rlmstat run for license with args.
+func rlmstatCommand(license string, args ...string) Command {
+	return Command{
+		License: license,
+		Path:    *rlmstatPath,
+		Args:    args,
+		Env:     rlmstatEnv,
+	}
+}
+
+// licenseRlmstatCommand describes an rlmstat run for license with args,
+// including the license's environment.
+func licenseRlmstatCommand(license config.License, args ...string) Command {
+	cmd := rlmstatCommand(license.Name, args...)
+	cmd.Env = append(append([]string{}, rlmstatEnv...), license.Environ()...)
+	return cmd
+}
+
+// runRlmstatCommand runs the configured rlmstat binary with args as soon as
+// the shared pool has a slot for prio. env is set on top of rlmstatEnv.
+// Identical commands of a scrape run once, see withCommandCache.
+func runRlmstatCommand(ctx context.Context, prio execPriority, env []string, args ...string) ([]byte, error) {
+	if err := rlmstatAvailable(); err != nil {
+		return nil, err
+	}
+	out, phases, err := runShared(ctx, *rlmstatPath, env, args, func() ([]byte, commandPhases, error) {
+		return execRlmstat(ctx, prio, env, args)
+	})
+	recordCommandPhases(ctx, phases)
+	return out, err
+}
+
+// execRlmstat runs the configured rlmstat binary with args once an exec slot
+// of priority prio is free, and returns how the time of the run was spent.
+func execRlmstat(ctx context.Context, prio execPriority, env, args []string) ([]byte, commandPhases, error) {
+	_, span := tracer.Start(ctx, "rlmstat", trace.WithAttributes(
+		attribute.String("priority", prio.String()),
+		attribute.StringSlice("args", args),
+	))
+	queued := time.Now()
+	release, err := pool.acquire(ctx, prio)
+	if err != nil {
+		endSpan(span, err)
+		return nil, commandPhases{queue: time.Since(queued)}, err
+	}
+	defer release()
+	span.AddEvent("acquired exec slot")
+	timer := &commandTimer{queue: time.Since(queued)}
+
+	// rlmstat is killed once the scrape is done waiting for it, like at its
+	// deadline, so that runs of hung license servers don't pile up.
+	cmd := exec.CommandContext(ctx, *rlmstatPath, args...)
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(append(os.Environ(), rlmstatEnv...), env...)
+	stderr := &cappedBuffer{max: maxStderrCapture}
+	cmd.Stderr = stderr
+
+	var out []byte
+	if *commandSandbox {
+		out, err = runSandboxed(cmd, flagSandboxLimits(), timer)
+	} else {
+		var stdout bytes.Buffer
+		cmd.Stdout = timer.writer(&stdout)
+		if err = timer.startCommand(cmd); err == nil {
+			err = timer.waitCommand(cmd)
+		}
+		out = stdout.Bytes()
+	}
+	phases := timer.phases()
+	usage.record(cmd.ProcessState)
+	countStderr(stderr.Bytes())
+	endSpan(span, err)
+	if err != nil {
+		// Error messages of rlmstat go to either stream, parsers and
+		// classifiers see both.
+		return append(out, stderr.Bytes()...), phases, withStderr(err, stderr.Bytes())
+	}
+	if stderr.Len() > 0 {
+		level.Debug(defaultLogger).Log("msg", "rlmstat printed on stderr", "args", strings.Join(args, " "), "stderr", quoteStderr(stderr.Bytes()))
+	}
+	return out, phases, nil
+}
+
+// shellQuote quotes s for a POSIX shell if it contains anything but
+// characters that are safe unquoted.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("@%+=:,./_-", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
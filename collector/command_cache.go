@@ -0,0 +1,108 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	execSharedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exec", "shared_total"),
+		"rlmlm_exporter: Total number of rlmstat runs saved by sharing the output of an identical command of the same scrape.",
+		nil, nil,
+	)
+
+	execShared = &sharedCounter{}
+)
+
+type commandCacheKey struct{}
+
+// commandCache shares the output of identical rlmstat commands, like those of
+// licenses configured with the same target, among the collectors of a
+// scrape.
+type commandCache struct {
+	mu      sync.Mutex
+	results map[string]*commandResult
+}
+
+// commandResult is the output of a command, ready once done is closed.
+type commandResult struct {
+	done   chan struct{}
+	out    []byte
+	phases commandPhases
+	err    error
+}
+
+// withCommandCache returns a context whose rlmstat commands run once, for
+// the duration of a scrape.
+func withCommandCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, commandCacheKey{}, &commandCache{results: make(map[string]*commandResult)})
+}
+
+// runShared runs the command identified by path, env and args with run, or
+// waits for the output of the identical command already running or done in
+// the scrape of ctx. Callers get their own copy of the output, and the phases
+// of the run.
+func runShared(ctx context.Context, path string, env, args []string, run func() ([]byte, commandPhases, error)) ([]byte, commandPhases, error) {
+	c, ok := ctx.Value(commandCacheKey{}).(*commandCache)
+	if !ok {
+		return run()
+	}
+	key := path + "\x00" + strings.Join(env, "\x00") + "\x00\x00" + strings.Join(args, "\x00")
+
+	c.mu.Lock()
+	r, found := c.results[key]
+	if !found {
+		r = &commandResult{done: make(chan struct{})}
+		c.results[key] = r
+	}
+	c.mu.Unlock()
+
+	if found {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return nil, commandPhases{}, ctx.Err()
+		}
+		execShared.inc()
+	} else {
+		r.out, r.phases, r.err = run()
+		close(r.done)
+	}
+	return bytes.Clone(r.out), r.phases, r.err
+}
+
+// sharedCounter counts the rlmstat runs saved by the command cache.
+type sharedCounter struct {
+	mu    sync.Mutex
+	count float64
+}
+
+func (s *sharedCounter) inc() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+}
+
+func (s *sharedCounter) collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch <- constMetric(execSharedDesc, prometheus.CounterValue, s.count)
+}
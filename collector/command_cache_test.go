@@ -0,0 +1,73 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunShared(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	run := func() ([]byte, commandPhases, error) {
+		runs.Add(1)
+		<-release
+		return []byte("output"), commandPhases{server: time.Second}, nil
+	}
+
+	ctx := withCommandCache(context.Background())
+	args := []string{"-a", "-c", "5053@host1"}
+	var wg sync.WaitGroup
+	outs := make([][]byte, 3)
+	phases := make([]commandPhases, 3)
+	for i := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outs[i], phases[i], _ = runShared(ctx, "rlmstat", nil, args, run)
+		}()
+	}
+	close(release)
+	wg.Wait()
+	if runs.Load() != 1 {
+		t.Fatalf("Expected a single run for identical commands, got %d", runs.Load())
+	}
+	outs[0][0] = 'O'
+	if string(outs[1]) != "output" || string(outs[2]) != "output" {
+		t.Fatalf("Expected every caller to get its own copy, got %q and %q", outs[1], outs[2])
+	}
+	for _, p := range phases {
+		if p.server != time.Second {
+			t.Fatalf("Expected every caller to get the phases of the run, got %+v", phases)
+		}
+	}
+
+	// Other arguments or environments are other commands.
+	runShared(ctx, "rlmstat", nil, []string{"-i", "-c", "5053@host1"}, run)
+	runShared(ctx, "rlmstat", []string{"RLM_DEBUG="}, args, run)
+	if runs.Load() != 3 {
+		t.Fatalf("Expected 3 runs, got %d", runs.Load())
+	}
+
+	// Without a cache, every command runs.
+	runShared(context.Background(), "rlmstat", nil, args, run)
+	runShared(context.Background(), "rlmstat", nil, args, run)
+	if runs.Load() != 5 {
+		t.Fatalf("Expected 5 runs, got %d", runs.Load())
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	commandPhaseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rlmstat", "phase_seconds"),
+		"rlmlm_exporter: Time the latest rlmstat run of a license spent waiting for an exec slot (queue), starting (spawn), until its first output (startup), between its first and last output, mostly waiting on the license server (server), and exiting (exit).",
+		[]string{"collector", "license_name", "phase"},
+		nil,
+	)
+
+	commandPhaseTimes = &phaseTimer{phases: make(map[[2]string]commandPhases)}
+)
+
+// commandPhases is how the time of an rlmstat run was spent. rlmstat prints
+// its banner before contacting the license server and the status as it
+// receives it, so startup is mostly the process and server the license
+// server. When rlmstat buffers its output until it exits, startup includes
+// the server.
+type commandPhases struct {
+	queue, spawn, startup, server, exit time.Duration
+}
+
+// commandPhasesKey holds the *commandPhases the rlmstat runs of a context
+// are recorded in.
+type commandPhasesKey struct{}
+
+// withCommandPhases returns a context recording the phases of its rlmstat
+// runs, shared ones included, in the returned commandPhases.
+func withCommandPhases(ctx context.Context) (context.Context, *commandPhases) {
+	phases := &commandPhases{}
+	return context.WithValue(ctx, commandPhasesKey{}, phases), phases
+}
+
+// recordCommandPhases stores phases in the commandPhases of ctx, if any.
+func recordCommandPhases(ctx context.Context, phases commandPhases) {
+	if p, ok := ctx.Value(commandPhasesKey{}).(*commandPhases); ok {
+		*p = phases
+	}
+}
+
+// commandTimer times a command from its start, exit and output.
+type commandTimer struct {
+	queue                               time.Duration
+	start, started, first, last, exited time.Time
+}
+
+// startCommand starts cmd.
+func (t *commandTimer) startCommand(cmd *exec.Cmd) error {
+	t.start = time.Now()
+	err := cmd.Start()
+	t.started = time.Now()
+	return err
+}
+
+// waitCommand waits for cmd to exit.
+func (t *commandTimer) waitCommand(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	t.exited = time.Now()
+	return err
+}
+
+// output records when data was read from or written to the output.
+func (t *commandTimer) output(n int) {
+	if n == 0 {
+		return
+	}
+	now := time.Now()
+	if t.first.IsZero() {
+		t.first = now
+	}
+	t.last = now
+}
+
+// writer returns w timing the output written to it.
+func (t *commandTimer) writer(w io.Writer) io.Writer {
+	return timedWriter{w, t}
+}
+
+// reader returns r timing the output read from it.
+func (t *commandTimer) reader(r io.Reader) io.Reader {
+	return timedReader{r, t}
+}
+
+// phases returns the phases of the command, once it exited. Commands without
+// output spend the time after spawning in startup.
+func (t *commandTimer) phases() commandPhases {
+	p := commandPhases{queue: t.queue, spawn: t.started.Sub(t.start)}
+	if t.exited.IsZero() {
+		return p
+	}
+	if t.first.IsZero() {
+		p.startup = t.exited.Sub(t.started)
+		return p
+	}
+	p.startup = t.first.Sub(t.started)
+	p.server = t.last.Sub(t.first)
+	p.exit = t.exited.Sub(t.last)
+	return p
+}
+
+type timedWriter struct {
+	w io.Writer
+	t *commandTimer
+}
+
+func (w timedWriter) Write(p []byte) (int, error) {
+	w.t.output(len(p))
+	return w.w.Write(p)
+}
+
+type timedReader struct {
+	r io.Reader
+	t *commandTimer
+}
+
+func (r timedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.t.output(n)
+	return n, err
+}
+
+// phaseTimer remembers the phases of the latest rlmstat run of every
+// license, by collector.
+type phaseTimer struct {
+	mu     sync.Mutex
+	phases map[[2]string]commandPhases
+}
+
+// observe records the phases of a run of license. Runs that couldn't start,
+// like without rlmstat binary, are skipped.
+func (t *phaseTimer) observe(collector, license string, phases commandPhases) {
+	if phases == (commandPhases{}) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phases[[2]string{collector, license}] = phases
+}
+
+func (t *phaseTimer) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, p := range t.phases {
+		for phase, d := range map[string]time.Duration{
+			"queue":   p.queue,
+			"spawn":   p.spawn,
+			"startup": p.startup,
+			"server":  p.server,
+			"exit":    p.exit,
+		} {
+			ch <- constMetric(commandPhaseDesc, prometheus.GaugeValue, d.Seconds(), key[0], key[1], phase)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCommandPhases(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+	// Like rlmstat, print the banner, wait for the server and print the
+	// status.
+	script := filepath.Join(t.TempDir(), "rlmstat")
+	body := "#!/bin/sh\necho 'rlmutil v14.2BL2'\nsleep 0.3\necho status\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Unexpected error writing script: %v", err)
+	}
+	oldPath := *rlmstatPath
+	defer func() { *rlmstatPath = oldPath }()
+	*rlmstatPath = script
+
+	ctx, phases := withCommandPhases(withCommandCache(context.Background()))
+	out, err := runRlmstatCommand(ctx, priorityStatus, nil, "-a")
+	if err != nil || string(out) != "rlmutil v14.2BL2\nstatus\n" {
+		t.Fatalf("Unexpected output %q, error %v", out, err)
+	}
+	if phases.server < 250*time.Millisecond || phases.startup >= phases.server || phases.spawn <= 0 {
+		t.Fatalf("Expected the wait between the outputs in the server phase, got %+v", *phases)
+	}
+
+	// A shared run reports the same phases.
+	shared, sharedPhases := withCommandPhases(ctx)
+	if _, err := runRlmstatCommand(shared, priorityStatus, nil, "-a"); err != nil {
+		t.Fatal(err)
+	}
+	if *sharedPhases != *phases {
+		t.Fatalf("Expected the phases of the shared run, got %+v and %+v", *sharedPhases, *phases)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// fakeRlmstat points --path.rlmstat to a shell script printing the fixture
+// mapped to its first argument, and nothing for other arguments.
+func fakeRlmstat(t *testing.T, fixtures map[string]string) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+
+	body := "#!/bin/sh\ncase \"$1\" in\n"
+	for arg, fixture := range fixtures {
+		path, err := filepath.Abs(fixture)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		body += arg + ") cat " + shellQuote(path) + " ;;\n"
+	}
+	body += "esac\n"
+
+	script := filepath.Join(t.TempDir(), "rlmstat")
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Unexpected error writing script: %v", err)
+	}
+	oldPath := *rlmstatPath
+	t.Cleanup(func() { *rlmstatPath = oldPath })
+	*rlmstatPath = script
+}
+
+func TestCommandString(t *testing.T) {
+	cmd := Command{
+		Path: "/opt/rlm/rlmstat",
+		Args: []string{"-a", "-c", "/licenses/my license.lic", "it's"},
+		Env:  []string{"LANG=C"},
+	}
+	expected := `LANG=C /opt/rlm/rlmstat -a -c '/licenses/my license.lic' 'it'\''s'`
+	if s := cmd.String(); s != expected {
+		t.Fatalf("Unexpected command line %s != %s", s, expected)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for in, expected := range map[string]string{
+		"":            "''",
+		"5053@host1":  "5053@host1",
+		"a;rm -rf /":  "'a;rm -rf /'",
+		"$(whoami)":   "'$(whoami)'",
+		"C:\\rlm.lic": "'C:\\rlm.lic'",
+	} {
+		if out := shellQuote(in); out != expected {
+			t.Fatalf("shellQuote(%q) = %s, expected %s", in, out, expected)
+		}
+	}
+}
+
+func TestRunRlmstatCommandEnv(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh in PATH")
+	}
+	oldPath := *rlmstatPath
+	t.Cleanup(func() { *rlmstatPath = oldPath })
+	*rlmstatPath = sh
+
+	license := config.License{Name: "app1", Env: map[string]string{"RLM_CONNECT_TIMEOUT": "5"}}
+	out, err := runRlmstatCommand(context.Background(), priorityStatus, license.Environ(), "-c", "echo $LANG $RLM_CONNECT_TIMEOUT")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s := strings.TrimSpace(string(out)); s != "C 5" {
+		t.Fatalf("Unexpected environment %q", s)
+	}
+
+	cmd := licenseRlmstatCommand(license, "-a")
+	if env := strings.Join(cmd.Env, " "); env != "LANG=C RLM_CONNECT_TIMEOUT=5" {
+		t.Fatalf("Unexpected dry run environment %q", env)
+	}
+}
+
+func TestRunRlmstatCommandCanceled(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh in PATH")
+	}
+	oldPath := *rlmstatPath
+	t.Cleanup(func() { *rlmstatPath = oldPath })
+	*rlmstatPath = sh
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := runRlmstatCommand(ctx, priorityStatus, nil, "-c", "exec sleep 30"); err == nil {
+		t.Fatal("Expected an error for a canceled run")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Expected rlmstat to be killed at the deadline, took %s", elapsed)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// customMetric is a compiled config.CustomMetric.
+type customMetric struct {
+	re     *regexp.Regexp
+	labels []string
+	desc   *prometheus.Desc
+	err    error
+}
+
+var (
+	// customMetrics caches the compiled custom metrics across collections
+	// and reloads.
+	customMetrics   = make(map[config.CustomMetric]*customMetric)
+	customMetricsMu sync.Mutex
+)
+
+// compileCustomMetric returns m compiled. The configuration is validated
+// when loaded, so errors are only expected from configurations built in code.
+func compileCustomMetric(m config.CustomMetric) *customMetric {
+	customMetricsMu.Lock()
+	defer customMetricsMu.Unlock()
+
+	if cm, ok := customMetrics[m]; ok {
+		return cm
+	}
+	cm := &customMetric{}
+	cm.re, cm.labels, cm.err = m.Compile()
+	if cm.err == nil {
+		help := m.Help
+		if help == "" {
+			help = "Custom metric " + m.Name + " extracted from the rlmstat output."
+		}
+		cm.desc = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "custom", m.Name),
+			help,
+			append([]string{"license_name"}, cm.labels...),
+			nil,
+		)
+	}
+	customMetrics[m] = cm
+	return cm
+}
+
+// describeCustomMetrics sends the descriptors of the custom metrics of all
+// licenses in cfg.
+func describeCustomMetrics(ch chan<- *prometheus.Desc, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, license := range cfg.Licenses {
+		for _, m := range license.CustomMetrics {
+			if cm := compileCustomMetric(m); cm.err == nil && !seen[m.Name] {
+				seen[m.Name] = true
+				ch <- cm.desc
+			}
+		}
+	}
+}
+
+// exportCustomMetrics sends the custom metrics of license found in the
+// rlmstat output. Every matching line becomes a sample, the first one wins if
+// several lines yield the same labels.
+func exportCustomMetrics(ch chan<- prometheus.Metric, logger log.Logger, license config.License, output []byte) {
+	if len(license.CustomMetrics) == 0 {
+		return
+	}
+	lines := strings.Split(string(bytes.ReplaceAll(output, []byte("\r\n"), []byte("\n"))), "\n")
+
+	for _, m := range license.CustomMetrics {
+		cm := compileCustomMetric(m)
+		if cm.err != nil {
+			level.Error(logger).Log("msg", "invalid custom metric", "license", license.Name, "err", cm.err)
+			continue
+		}
+		valueIndex := cm.re.SubexpIndex(m.ValueGroup)
+		seen := make(map[string]bool)
+		for _, line := range lines {
+			match := cm.re.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			value, err := strconv.ParseFloat(match[valueIndex], 64)
+			if err != nil {
+				level.Debug(logger).Log("msg", "custom metric value isn't a number", "license", license.Name,
+					"metric", m.Name, "value", match[valueIndex])
+				continue
+			}
+			labelValues := []string{license.Name}
+			for _, label := range cm.labels {
+				labelValues = append(labelValues, match[cm.re.SubexpIndex(label)])
+			}
+			key := strings.Join(labelValues, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ch <- constMetric(cm.desc, prometheus.GaugeValue, value, labelValues...)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestExportCustomMetrics(t *testing.T) {
+	out, err := os.ReadFile("fixtures/lmstat_rlm_v14_custom.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	license := config.License{Name: "app1", CustomMetrics: []config.CustomMetric{{
+		Name:       "tokens_remaining",
+		Regex:      `(?P<vendor>\w+) tokens remaining: (?P<tokens>\S+) \(pool (?P<pool>\w+)\)`,
+		ValueGroup: "tokens",
+	}}}
+
+	ch := make(chan prometheus.Metric, 10)
+	exportCustomMetrics(ch, log.NewNopLogger(), license, out)
+	close(ch)
+
+	got := make(map[string]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		// Labels are sorted by name: license_name, pool, vendor.
+		l := pb.GetLabel()
+		got[l[0].GetValue()+"/"+l[1].GetValue()+"/"+l[2].GetValue()] = pb.GetGauge().GetValue()
+	}
+	// The second onprem line repeats the labels and vendor3 has no number.
+	want := map[string]float64{
+		"app1/cloud/vendor2":  420,
+		"app1/onprem/vendor2": 75,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("Expected %s to be %v, got %v", k, v, got[k])
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	featureDailyPeakUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "daily_peak_used"),
+		"Highest number of licenses of a feature in use at a collection since midnight in the license's timezone.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+
+	dailyPeaks = &peakTracker{now: time.Now, peaks: make(map[featureKey]dailyPeak)}
+)
+
+// dailyPeak is the peak usage of a feature on day, formatted as 2006-01-02.
+type dailyPeak struct {
+	day  string
+	used float64
+}
+
+// peakTracker keeps the peak usage of every feature for the current day.
+type peakTracker struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	peaks map[featureKey]dailyPeak
+}
+
+// observe records the usage of feature and returns its peak since the last
+// midnight in loc. Days are calendar days of loc, so DST changes make them
+// 23 or 25 hours long.
+func (t *peakTracker) observe(license, feature string, used float64, loc *time.Location) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := featureKey{license, feature}
+	day := t.now().In(loc).Format("2006-01-02")
+	peak, ok := t.peaks[key]
+	if !ok || peak.day != day || used > peak.used {
+		peak = dailyPeak{day: day, used: used}
+		t.peaks[key] = peak
+	}
+	return peak.used
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeakTracker(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	var now time.Time
+	tr := &peakTracker{now: func() time.Time { return now }, peaks: make(map[featureKey]dailyPeak)}
+
+	for i, step := range []struct {
+		now      string
+		used     float64
+		expected float64
+	}{
+		{"2025-03-29T10:00:00Z", 5, 5},
+		{"2025-03-29T15:00:00Z", 8, 8},
+		{"2025-03-29T20:00:00Z", 3, 8},
+		// 23:30 UTC is already the next day in Berlin.
+		{"2025-03-29T23:30:00Z", 2, 2},
+		// The DST change on March 30 doesn't reset the day.
+		{"2025-03-30T12:00:00Z", 4, 4},
+		{"2025-03-30T21:30:00Z", 1, 4},
+		{"2025-03-30T22:30:00Z", 1, 1},
+	} {
+		now, err = time.Parse(time.RFC3339, step.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if peak := tr.observe("app1", "feature1", step.used, loc); peak != step.expected {
+			t.Fatalf("Step %d: expected peak %v, got %v", i, step.expected, peak)
+		}
+	}
+}
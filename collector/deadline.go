@@ -0,0 +1,161 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	scrapeIncompleteDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "incomplete"),
+		"rlmlm_exporter: Whether the scrape deadline was reached before every license was collected.",
+		nil,
+		nil,
+	)
+	scrapeMissingLicenseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "missing_license_info"),
+		"rlmlm_exporter: Licenses left out of the scrape because they weren't collected before the deadline.",
+		[]string{"license_name"},
+		nil,
+	)
+)
+
+// partialResult holds the metrics collected for a license, with the error of
+// every collector of the license, or by a collector that doesn't collect
+// licenses one at a time.
+type partialResult struct {
+	license string
+	metrics []prometheus.Metric
+	errs    map[string]error
+}
+
+// collectUntilDeadline collects every license concurrently and sends the
+// metrics of those collected before ctx is done. Licenses still being
+// collected are left out instead of being reported down, and listed by
+// rlmlm_scrape_missing_license_info. The collectors of licenses fail if they
+// failed on any license collected, and last until the last one.
+func (c RlmlmCollector) collectUntilDeadline(ctx context.Context, ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	c.collectGlobal(ctx, ch)
+
+	var pending int
+	missing := make(map[string]bool)
+	if c.Config != nil {
+		for _, license := range c.Config.Licenses {
+			missing[license.Name] = true
+		}
+	}
+	// Buffered so that collections finishing after the deadline don't block.
+	results := make(chan partialResult, len(missing)+len(c.Collectors))
+
+	// failed tells whether each collector of licenses failed on one so far.
+	failed := make(map[string]bool)
+	for name, collector := range c.Collectors {
+		if _, ok := collector.(licenseUpdater); ok {
+			failed[name] = false
+			continue
+		}
+		pending++
+		go func(name string, collector Collector) {
+			results <- partialResult{metrics: bufferMetrics(func(buf chan<- prometheus.Metric) {
+				c.execute(ctx, name, collector, buf)
+			})}
+		}(name, collector)
+	}
+	if c.Config != nil {
+		for _, license := range c.Config.Licenses {
+			pending++
+			go func() {
+				metrics, errs := c.collectLicenseByCollector(ctx, license)
+				results <- partialResult{license: license.Name, metrics: metrics, errs: errs}
+			}()
+		}
+	}
+
+	var incomplete bool
+collect:
+	for ; pending > 0; pending-- {
+		select {
+		case r := <-results:
+			for _, m := range r.metrics {
+				ch <- m
+			}
+			for name, err := range r.errs {
+				if err != nil {
+					failed[name] = true
+					c.logLicenseFailure(name, r.license, err)
+				}
+			}
+			delete(missing, r.license)
+		case <-ctx.Done():
+			incomplete = true
+			break collect
+		}
+	}
+
+	duration := time.Since(begin).Seconds()
+	for name, failed := range failed {
+		ch <- constMetric(scrapeDurationDesc, prometheus.GaugeValue, duration, name)
+		ch <- constMetric(scrapeSuccessDesc, prometheus.GaugeValue, boolToFloat64(!failed), name)
+	}
+	ch <- constMetric(scrapeIncompleteDesc, prometheus.GaugeValue, boolToFloat64(incomplete))
+	if !incomplete {
+		return
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ch <- constMetric(scrapeMissingLicenseDesc, prometheus.GaugeValue, 1, name)
+	}
+	level.Warn(c.Logger).Log("msg", "scrape deadline reached, sending partial metrics", "missing_licenses", len(names))
+}
+
+// logLicenseFailure logs that collector failed on license, like execute.
+func (c RlmlmCollector) logLicenseFailure(collector, license string, err error) {
+	logger := level.Error(c.Logger)
+	if errors.Is(err, errRlmstatUnavailable) {
+		// A missing binary is logged once when it is looked up.
+		logger = level.Debug(c.Logger)
+	}
+	logger.Log("msg", "collector failed", "collector", collector, "license", license, "err", err)
+}
+
+// bufferMetrics returns the metrics collect sends.
+func bufferMetrics(collect func(chan<- prometheus.Metric)) []prometheus.Metric {
+	var (
+		metrics []prometheus.Metric
+		ch      = make(chan prometheus.Metric)
+		done    = make(chan struct{})
+	)
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+	collect(ch)
+	close(ch)
+	<-done
+	return metrics
+}
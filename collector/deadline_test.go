@@ -0,0 +1,105 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// slowLicenseCollector never finishes collecting the license named slow.
+type slowLicenseCollector struct {
+	fakeLicenseCollector
+}
+
+func (s *slowLicenseCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error {
+	if license.Name == "slow" {
+		<-ctx.Done()
+		ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, "N/A")
+		return ctx.Err()
+	}
+	return s.fakeLicenseCollector.UpdateLicense(ctx, ch, license)
+}
+
+func TestCollectUntilDeadline(t *testing.T) {
+	cfg := &config.Config{Licenses: []config.License{{Name: "fast"}, {Name: "slow"}}}
+	c := RlmlmCollector{
+		Config:     cfg,
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"lmstat": &slowLicenseCollector{}},
+		Deadline:   time.Now().Add(100 * time.Millisecond),
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error gathering: %v", err)
+	}
+
+	byName := make(map[string][]*dto.Metric)
+	for _, family := range families {
+		byName[family.GetName()] = family.GetMetric()
+	}
+	// The slow license is left out rather than reported down.
+	if up := byName["rlmlm_lmstat_up"]; len(up) != 1 || up[0].GetLabel()[0].GetValue() != "fast" || up[0].GetGauge().GetValue() != 1 {
+		t.Fatalf("Unexpected rlmlm_lmstat_up: %v", up)
+	}
+	if incomplete := byName["rlmlm_scrape_incomplete"]; len(incomplete) != 1 || incomplete[0].GetGauge().GetValue() != 1 {
+		t.Fatalf("Unexpected rlmlm_scrape_incomplete: %v", incomplete)
+	}
+	if missing := byName["rlmlm_scrape_missing_license_info"]; len(missing) != 1 || missing[0].GetLabel()[0].GetValue() != "slow" {
+		t.Fatalf("Unexpected rlmlm_scrape_missing_license_info: %v", missing)
+	}
+}
+
+func TestCollectUntilDeadlineFailure(t *testing.T) {
+	cfg := &config.Config{Licenses: []config.License{{Name: "broken"}}}
+	c := RlmlmCollector{
+		Config:     cfg,
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"lmstat": &fakeLicenseCollector{fail: true}},
+		Deadline:   time.Now().Add(time.Minute),
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error gathering: %v", err)
+	}
+
+	byName := make(map[string][]*dto.Metric)
+	for _, family := range families {
+		byName[family.GetName()] = family.GetMetric()
+	}
+	if success := byName["rlmlm_scrape_collector_success"]; len(success) != 1 || success[0].GetLabel()[0].GetValue() != "lmstat" || success[0].GetGauge().GetValue() != 0 {
+		t.Fatalf("Unexpected rlmlm_scrape_collector_success: %v", success)
+	}
+	if duration := byName["rlmlm_scrape_collector_duration_seconds"]; len(duration) != 1 {
+		t.Fatalf("Unexpected rlmlm_scrape_collector_duration_seconds: %v", duration)
+	}
+	if incomplete := byName["rlmlm_scrape_incomplete"]; len(incomplete) != 1 || incomplete[0].GetGauge().GetValue() != 0 {
+		t.Fatalf("Unexpected rlmlm_scrape_incomplete: %v", incomplete)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"path"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+	"github.com/iambengiey/rlmlm_exporter/expr"
+)
+
+// derivedMetric is a compiled config.DerivedMetric.
+type derivedMetric struct {
+	program *expr.Program
+	desc    *prometheus.Desc
+	err     error
+}
+
+var (
+	// derivedMetrics caches the compiled derived metrics across collections
+	// and reloads.
+	derivedMetrics   = make(map[config.DerivedMetric]*derivedMetric)
+	derivedMetricsMu sync.Mutex
+)
+
+// compileDerivedMetric returns m compiled. The configuration is validated
+// when loaded, so errors are only expected from configurations built in code.
+func compileDerivedMetric(m config.DerivedMetric) *derivedMetric {
+	derivedMetricsMu.Lock()
+	defer derivedMetricsMu.Unlock()
+
+	if dm, ok := derivedMetrics[m]; ok {
+		return dm
+	}
+	dm := &derivedMetric{}
+	dm.program, dm.err = m.Compile()
+	if dm.err == nil {
+		help := m.Help
+		if help == "" {
+			help = "Derived metric " + m.Name + " computed from the features of the license."
+		}
+		dm.desc = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "derived", m.Name),
+			help,
+			[]string{"license_name"},
+			nil,
+		)
+	}
+	derivedMetrics[m] = dm
+	return dm
+}
+
+// describeDerivedMetrics sends the descriptors of the derived metrics of all
+// licenses in cfg.
+func describeDerivedMetrics(ch chan<- *prometheus.Desc, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, license := range cfg.Licenses {
+		for _, m := range license.DerivedMetrics {
+			if dm := compileDerivedMetric(m); dm.err == nil && !seen[m.Name] {
+				seen[m.Name] = true
+				ch <- dm.desc
+			}
+		}
+	}
+}
+
+// exportDerivedMetrics evaluates the derived metrics of license over the
+// features in data, after aliases but before features_to_include and
+// features_to_exclude.
+func exportDerivedMetrics(ch chan<- prometheus.Metric, logger log.Logger, license config.License, data *lmstatData) {
+	if len(license.DerivedMetrics) == 0 {
+		return
+	}
+	functions := derivedMetricFunctions(data)
+	for _, m := range license.DerivedMetrics {
+		dm := compileDerivedMetric(m)
+		if dm.err != nil {
+			level.Error(logger).Log("msg", "invalid derived metric", "license", license.Name, "err", dm.err)
+			continue
+		}
+		value, err := dm.program.Eval(functions)
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to compute derived metric", "license", license.Name, "metric", m.Name, "err", err)
+			continue
+		}
+		ch <- constMetric(dm.desc, prometheus.GaugeValue, value, license.Name)
+	}
+}
+
+// derivedMetricFunctions returns the implementations of
+// config.DerivedMetricFunctions over data. Their feature name argument may
+// contain * wildcards, the matching features add up.
+func derivedMetricFunctions(data *lmstatData) map[string]expr.Function {
+	sum := func(value func(f *feature) float64) expr.Function {
+		return func(args []any) (float64, error) {
+			pattern, err := featurePattern(args)
+			if err != nil {
+				return 0, err
+			}
+			var total float64
+			for name, f := range data.features {
+				if ok, _ := path.Match(pattern, name); ok {
+					total += value(f)
+				}
+			}
+			return total, nil
+		}
+	}
+	return map[string]expr.Function{
+		"issued":   sum(func(f *feature) float64 { return f.issued }),
+		"used":     sum(func(f *feature) float64 { return f.used }),
+		"queued":   sum(func(f *feature) float64 { return f.queued }),
+		"features": sum(func(*feature) float64 { return 1 }),
+		// Users holding several of the features count once.
+		"users": func(args []any) (float64, error) {
+			pattern, err := featurePattern(args)
+			if err != nil {
+				return 0, err
+			}
+			users := make(map[string]bool)
+			for name, byUser := range data.usersByFeature {
+				if ok, _ := path.Match(pattern, name); ok {
+					for user := range byUser {
+						users[user] = true
+					}
+				}
+			}
+			return float64(len(users)), nil
+		},
+	}
+}
+
+// featurePattern returns the single feature name argument of a function.
+func featurePattern(args []any) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("takes a single feature name")
+	}
+	pattern, ok := args[0].(string)
+	if !ok {
+		return "", errors.New("takes a feature name in quotes")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestExportDerivedMetrics(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseLmstatParseable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parseLmstatParseable(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	license := config.License{Name: "app1", DerivedMetrics: []config.DerivedMetric{
+		{Name: "free_ratio", Expr: `(issued("feature*") - used("feature*")) / issued("feature*")`},
+		{Name: "feature_count", Expr: `features("*")`},
+		{Name: "users", Expr: `users("feature1")`},
+		{Name: "broken", Expr: `used(1)`},
+	}}
+	ch := make(chan prometheus.Metric, 10)
+	exportDerivedMetrics(ch, log.NewNopLogger(), license, data)
+	close(ch)
+
+	// Metrics are sent in the configured order.
+	var got []float64
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, pb.GetGauge().GetValue())
+	}
+	f1, f2 := data.features["feature1"], data.features["feature2"]
+	want := []float64{
+		(f1.issued + f2.issued - f1.used - f2.used) / (f1.issued + f2.issued),
+		float64(len(data.features)),
+		float64(len(data.usersByFeature["feature1"])),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v without the broken metric, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
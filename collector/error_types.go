@@ -0,0 +1,124 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Error types exported as the type label of rlmlm_errors_total.
+const (
+	errorTypeExec    = "exec_error"
+	errorTypeTimeout = "timeout"
+	errorTypeParse   = "parse_error"
+	errorTypeConfig  = "config_error"
+	errorTypeNetwork = "network_error"
+)
+
+var (
+	errorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "errors_total"),
+		"rlmlm_exporter: Failed license collections by error type and collector.",
+		[]string{"type", "license_name", "collector"},
+		nil,
+	)
+
+	collectionErrors = &errorCounter{counts: make(map[errorKey]float64)}
+
+	// networkExitCodes are the rlmstat exit codes of errorDescriptionString
+	// caused by failing to reach or talk to the license server.
+	networkExitCodes = map[int]bool{
+		161: true, 160: true, 199: true, 194: true, 242: true, 241: true,
+		240: true, 239: true, 249: true,
+	}
+)
+
+// typedError classifies an error for rlmlm_errors_total.
+type typedError struct {
+	typ string
+	err error
+}
+
+func (e *typedError) Error() string { return e.err.Error() }
+
+func (e *typedError) Unwrap() error { return e.err }
+
+// parseError marks err as rlmstat output that couldn't be parsed.
+func parseError(err error) error { return &typedError{errorTypeParse, err} }
+
+// configError marks err as caused by the configuration.
+func configError(err error) error { return &typedError{errorTypeConfig, err} }
+
+// networkError marks err as rlmstat failing to reach the license server.
+func networkError(err error) error { return &typedError{errorTypeNetwork, err} }
+
+// errorType returns the type of err. Errors that weren't classified are
+// failures to run rlmstat.
+func errorType(err error) string {
+	var te *typedError
+	if errors.As(err, &te) {
+		return te.typ
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return errorTypeTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return errorTypeTimeout
+		}
+		return errorTypeNetwork
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && networkExitCodes[exitErr.ExitCode()] {
+		return errorTypeNetwork
+	}
+	return errorTypeExec
+}
+
+type errorKey struct {
+	typ, license, collector string
+}
+
+// errorCounter counts collection errors since the exporter started.
+type errorCounter struct {
+	mu     sync.Mutex
+	counts map[errorKey]float64
+}
+
+// record counts err, if any, for license and collector.
+func (c *errorCounter) record(collector, license string, err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[errorKey{errorType(err), license, collector}]++
+}
+
+// collect sends the error counts to ch.
+func (c *errorCounter) collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, count := range c.counts {
+		ch <- constMetric(errorsDesc, prometheus.CounterValue, count, key.typ, key.license, key.collector)
+	}
+}
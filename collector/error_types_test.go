@@ -0,0 +1,61 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestErrorType(t *testing.T) {
+	for err, expected := range map[error]string{
+		errors.New("boom"): errorTypeExec,
+		fmt.Errorf("rlmstat failed for app1: %w", parseError(errUnparseableOutput)): errorTypeParse,
+		configError(errors.New("missing license_file")):                             errorTypeConfig,
+		networkError(errors.New("license server error")):                            errorTypeNetwork,
+		fmt.Errorf("rlmstat failed for app1: %w", context.DeadlineExceeded):         errorTypeTimeout,
+		fmt.Errorf("rlmstat failed for app1: %w", errRlmstatUnavailable):            errorTypeExec,
+	} {
+		if typ := errorType(err); typ != expected {
+			t.Fatalf("Expected %s for %q, got %s", expected, err, typ)
+		}
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+	// 241 is "Cannot connect to license server".
+	err := exec.Command("sh", "-c", "exit 241").Run()
+	if typ := errorType(fmt.Errorf("rlmstat failed for app1: %w", err)); typ != errorTypeNetwork {
+		t.Fatalf("Expected %s for exit status 241, got %s", errorTypeNetwork, typ)
+	}
+}
+
+func TestErrorCounter(t *testing.T) {
+	c := &errorCounter{counts: make(map[errorKey]float64)}
+	c.record("lmstat", "app1", nil)
+	c.record("lmstat", "app1", parseError(errUnparseableOutput))
+	c.record("lmstat", "app1", parseError(errUnparseableOutput))
+	c.record("lmstat_feature_exp", "app1", configError(errors.New("invalid timezone")))
+
+	if n := c.counts[errorKey{errorTypeParse, "app1", "lmstat"}]; n != 2 {
+		t.Fatalf("Expected 2 parse errors, got %v", n)
+	}
+	if len(c.counts) != 2 {
+		t.Fatalf("Unexpected error counts %v", c.counts)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	eventCheckout = "checkout"
+	eventCheckin  = "checkin"
+)
+
+var (
+	eventsPath = kingpin.Flag("events.path",
+		"Append the checkouts and checkins inferred from the users of consecutive collections to this JSON lines file, empty disables it.").Default("").String()
+	eventsMaxSize = kingpin.Flag("events.max-size",
+		"Rotate the event log once it reaches this size.").Default("100MB").Bytes()
+	eventsMaxFiles = kingpin.Flag("events.max-files",
+		"Number of rotated event logs kept, older ones are removed.").Default("5").Int()
+
+	checkoutLog     *eventLog
+	checkoutLogOnce sync.Once
+)
+
+// checkoutEvent is a line of the event log.
+type checkoutEvent struct {
+	Time     string  `json:"time"`
+	Event    string  `json:"event"`
+	License  string  `json:"license_name"`
+	Feature  string  `json:"feature"`
+	User     string  `json:"user"`
+	Licenses float64 `json:"licenses"`
+}
+
+// userEvents returns the checkouts and checkins turning the users prev into
+// users, ordered by user.
+func userEvents(license, feature string, prev, users map[string]float64) []checkoutEvent {
+	var events []checkoutEvent
+	for user, n := range users {
+		if n > prev[user] {
+			events = append(events, checkoutEvent{Event: eventCheckout, License: license, Feature: feature, User: user, Licenses: n - prev[user]})
+		}
+	}
+	for user, n := range prev {
+		if n > users[user] {
+			events = append(events, checkoutEvent{Event: eventCheckin, License: license, Feature: feature, User: user, Licenses: n - users[user]})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].User != events[j].User {
+			return events[i].User < events[j].User
+		}
+		return events[i].Event < events[j].Event
+	})
+	return events
+}
+
+// eventLog is an append-only JSON lines file rotated by size: path.1 is the
+// newest rotated file, path.<maxFiles> the oldest.
+type eventLog struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	now      func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// write appends events, stamped with the current time.
+func (l *eventLog) write(events []checkoutEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		if err := l.open(); err != nil {
+			return err
+		}
+	}
+	now := l.now().UTC().Format(time.RFC3339)
+	for _, event := range events {
+		event.Time = now
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+			if err := l.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := l.file.Write(line)
+		l.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *eventLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files, dropping the oldest, and starts a new file.
+func (l *eventLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if err := removeIfExists(fmt.Sprintf("%s.%d", l.path, l.maxFiles)); err != nil {
+		return err
+	}
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if l.maxFiles > 0 {
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeCheckoutEvents appends events to --events.path, if set.
+func writeCheckoutEvents(events []checkoutEvent) {
+	checkoutLogOnce.Do(func() {
+		if *eventsPath != "" {
+			checkoutLog = &eventLog{path: *eventsPath, maxSize: int64(*eventsMaxSize), maxFiles: *eventsMaxFiles, now: time.Now}
+		}
+	})
+	if checkoutLog == nil {
+		return
+	}
+	if err := checkoutLog.write(events); err != nil {
+		level.Warn(defaultLogger).Log("msg", "couldn't write checkout events", "path", checkoutLog.path, "err", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckoutTrackerEvents(t *testing.T) {
+	var events []checkoutEvent
+	tr := newCheckoutTracker(func(e []checkoutEvent) { events = append(events, e...) })
+
+	tr.observe("app1", "feature1", 5, map[string]float64{"user1": 5})
+	tr.observe("app1", "feature1", 4, map[string]float64{"user1": 2, "user2": 2})
+	// Users missing from the output while licenses are used are unknown.
+	tr.observe("app1", "feature1", 4, nil)
+	tr.observe("app1", "feature1", 0, nil)
+
+	expected := []checkoutEvent{
+		{Event: eventCheckin, License: "app1", Feature: "feature1", User: "user1", Licenses: 3},
+		{Event: eventCheckout, License: "app1", Feature: "feature1", User: "user2", Licenses: 2},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Unexpected events: %+v", events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatalf("Unexpected event %d: %+v", i, events[i])
+		}
+	}
+}
+
+func TestEventLogRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l := &eventLog{path: path, maxSize: 200, maxFiles: 2, now: func() time.Time { return time.Unix(0, 0) }}
+	event := checkoutEvent{Event: eventCheckout, License: "app1", Feature: "feature1", User: "user1", Licenses: 1}
+	for i := 0; i < 10; i++ {
+		if err := l.write([]checkoutEvent{event}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	var got checkoutEvent
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Time != "1970-01-01T00:00:00Z" || got.User != "user1" {
+		t.Fatalf("Unexpected event: %+v", got)
+	}
+	for _, name := range []string{path + ".1", path + ".2"} {
+		if info, err := os.Stat(name); err != nil || info.Size() > l.maxSize {
+			t.Fatalf("Unexpected rotated file %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("Expected only 2 rotated files, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// exhaustedHookTimeout bounds an on_exhausted run, the command is killed
+// past it.
+const exhaustedHookTimeout = time.Minute
+
+var (
+	exhaustedHookRunsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exhausted_hook", "runs_total"),
+		"rlmlm_exporter: Runs of the on_exhausted command of a license, by result (success or failure).",
+		[]string{"license_name", "result"},
+		nil,
+	)
+
+	exhaustion = &exhaustionTracker{
+		since: make(map[[2]string]time.Time),
+		fired: make(map[[2]string]bool),
+		runs:  make(map[[2]string]float64),
+	}
+)
+
+// exhaustionTracker runs the on_exhausted command of a license once per
+// feature staying exhausted, all its licenses in use, for its exhausted_for.
+// It runs again once the feature was available in between.
+type exhaustionTracker struct {
+	mu sync.Mutex
+	// since is when each feature of a license was first seen exhausted,
+	// fired whether the command ran since.
+	since map[[2]string]time.Time
+	fired map[[2]string]bool
+	// runs counts the runs by license and result.
+	runs map[[2]string]float64
+	// wg tracks the commands running.
+	wg sync.WaitGroup
+}
+
+// observe updates the exhausted features of license and starts its command
+// for those exhausted long enough.
+func (t *exhaustionTracker) observe(logger log.Logger, license config.License, data *lmstatData, exported map[string]bool, now time.Time) {
+	if license.OnExhausted == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.since {
+		if key[0] == license.Name && !exported[key[1]] {
+			delete(t.since, key)
+			delete(t.fired, key)
+		}
+	}
+	for name := range exported {
+		key := [2]string{license.Name, name}
+		f := data.features[name]
+		if f == nil || f.issued <= 0 || f.used < f.issued {
+			delete(t.since, key)
+			delete(t.fired, key)
+			continue
+		}
+		since, ok := t.since[key]
+		if !ok {
+			since = now
+			t.since[key] = now
+		}
+		if t.fired[key] || now.Sub(since) < license.ExhaustedAfter() {
+			continue
+		}
+		t.fired[key] = true
+		event := config.ExhaustedEvent{
+			License: license.Name,
+			Feature: name,
+			Issued:  f.issued,
+			Used:    f.used,
+			Queued:  f.queued,
+			Since:   since,
+		}
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.run(logger, license, event)
+		}()
+	}
+}
+
+// run runs the command of license for event. Besides the placeholders, the
+// event is passed in RLMLM_* environment variables.
+func (t *exhaustionTracker) run(logger log.Logger, license config.License, event config.ExhaustedEvent) {
+	result := "failure"
+	defer func() {
+		t.mu.Lock()
+		t.runs[[2]string{license.Name, result}]++
+		t.mu.Unlock()
+	}()
+
+	args, err := license.ExhaustedCommand(event)
+	if err != nil {
+		level.Error(logger).Log("msg", "couldn't render on_exhausted", "license", license.Name, "feature", event.Feature, "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exhaustedHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"RLMLM_LICENSE="+event.License,
+		"RLMLM_FEATURE="+event.Feature,
+		"RLMLM_ISSUED="+strconv.FormatFloat(event.Issued, 'f', -1, 64),
+		"RLMLM_USED="+strconv.FormatFloat(event.Used, 'f', -1, 64),
+		"RLMLM_QUEUED="+strconv.FormatFloat(event.Queued, 'f', -1, 64),
+		"RLMLM_EXHAUSTED_SINCE="+event.Since.UTC().Format(time.RFC3339),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		level.Error(logger).Log("msg", "on_exhausted failed", "license", license.Name, "feature", event.Feature,
+			"command", args[0], "output", quoteStderr(out), "err", err)
+		return
+	}
+	result = "success"
+	level.Info(logger).Log("msg", "ran on_exhausted", "license", license.Name, "feature", event.Feature,
+		"command", args[0], "output", quoteStderr(out))
+}
+
+func (t *exhaustionTracker) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, runs := range t.runs {
+		ch <- constMetric(exhaustedHookRunsDesc, prometheus.CounterValue, runs, key[0], key[1])
+	}
+}
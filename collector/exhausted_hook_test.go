@@ -0,0 +1,97 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestExhaustionTracker(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "reclaim.sh")
+	body := "#!/bin/sh\necho \"$1 $2 $RLMLM_LICENSE $RLMLM_USED\" >> " + shellQuote(calls) + "\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Unexpected error writing script: %v", err)
+	}
+
+	tracker := &exhaustionTracker{
+		since: make(map[[2]string]time.Time),
+		fired: make(map[[2]string]bool),
+		runs:  make(map[[2]string]float64),
+	}
+	license := config.License{
+		Name:         "app1",
+		OnExhausted:  script + " {{.Feature}} {{.Issued}}",
+		ExhaustedFor: 10 * time.Minute,
+	}
+	exhausted := &lmstatData{features: map[string]*feature{
+		"feature1": {issued: 4, used: 4},
+		"feature2": {issued: 4, used: 3},
+	}}
+	available := &lmstatData{features: map[string]*feature{
+		"feature1": {issued: 4, used: 2},
+		"feature2": {issued: 4, used: 3},
+	}}
+	exported := map[string]bool{"feature1": true, "feature2": true}
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for _, step := range []struct {
+		data    *lmstatData
+		minutes int
+	}{
+		{exhausted, 0},
+		{exhausted, 5},
+		// Exhausted for 10 minutes, the command runs once.
+		{exhausted, 10},
+		{exhausted, 15},
+		// Available in between, it runs again 10 minutes later.
+		{available, 20},
+		{exhausted, 25},
+		{exhausted, 30},
+		{exhausted, 35},
+	} {
+		tracker.observe(log.NewNopLogger(), license, step.data, exported, start.Add(time.Duration(step.minutes)*time.Minute))
+		tracker.wg.Wait()
+	}
+
+	out, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "feature1 4 app1 4\nfeature1 4 app1 4\n"; string(out) != expected {
+		t.Fatalf("Unexpected runs %q, expected %q", out, expected)
+	}
+	if tracker.runs[[2]string{"app1", "success"}] != 2 {
+		t.Fatalf("Expected 2 successful runs, got %v", tracker.runs)
+	}
+
+	license.OnExhausted = filepath.Join(dir, "missing.sh")
+	tracker.fired = make(map[[2]string]bool)
+	tracker.observe(log.NewNopLogger(), license, exhausted, exported, start.Add(time.Hour))
+	tracker.wg.Wait()
+	if tracker.runs[[2]string{"app1", "failure"}] != 1 {
+		t.Fatalf("Expected a failed run, got %v", tracker.runs)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	serverFailoverActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "failover_active"),
+		"Whether a server of a license served by several servers is the one answering, by fqdn.",
+		[]string{"license_name", "fqdn"},
+		nil,
+	)
+	serverFailoverTransitionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "failover_transitions_total"),
+		"Number of times the answering server of a license changed since the exporter started.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	failovers = newFailoverTracker()
+)
+
+// failoverTracker remembers the answering server of every license between
+// collections.
+type failoverTracker struct {
+	mu          sync.Mutex
+	active      map[string]string
+	transitions map[string]float64
+}
+
+func newFailoverTracker() *failoverTracker {
+	return &failoverTracker{
+		active:      make(map[string]string),
+		transitions: make(map[string]float64),
+	}
+}
+
+// observe records the answering server of license and returns the number of
+// transitions. An empty active, when no server answers, keeps the last one.
+func (t *failoverTracker) observe(license, active string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if active == "" {
+		return t.transitions[license]
+	}
+	if prev, ok := t.active[license]; ok && prev != active {
+		t.transitions[license]++
+	}
+	t.active[license] = active
+	return t.transitions[license]
+}
+
+// activeServer returns the fqdn of the server answering for the license: the
+// master if it is up, else the only server up. It is empty if none or
+// several servers are up without a master.
+func activeServer(servers map[string]*server) string {
+	var up []string
+	for _, s := range servers {
+		if !s.status {
+			continue
+		}
+		if s.master {
+			return s.fqdn
+		}
+		up = append(up, s.fqdn)
+	}
+	if len(up) != 1 {
+		return ""
+	}
+	return up[0]
+}
+
+// exportFailover sends the failover metrics of licenses served by several
+// servers.
+func exportFailover(ch chan<- prometheus.Metric, license string, servers map[string]*server) {
+	if len(servers) < 2 {
+		return
+	}
+	active := activeServer(servers)
+	fqdns := make([]string, 0, len(servers))
+	for _, s := range servers {
+		fqdns = append(fqdns, s.fqdn)
+	}
+	sort.Strings(fqdns)
+	for _, fqdn := range fqdns {
+		ch <- constMetric(serverFailoverActiveDesc, prometheus.GaugeValue, boolToFloat64(fqdn == active), license, fqdn)
+	}
+	ch <- constMetric(serverFailoverTransitionsDesc, prometheus.CounterValue, failovers.observe(license, active), license)
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestFailoverTracker(t *testing.T) {
+	tr := newFailoverTracker()
+	for i, step := range []struct {
+		active   string
+		expected float64
+	}{
+		{"host1", 0},
+		{"host1", 0},
+		// No server answering doesn't count as a transition.
+		{"", 0},
+		{"host2", 1},
+		{"host1", 2},
+	} {
+		if got := tr.observe("app1", step.active); got != step.expected {
+			t.Fatalf("Step %d: expected %v transitions, got %v", i, step.expected, got)
+		}
+	}
+}
+
+func TestActiveServer(t *testing.T) {
+	for expected, servers := range map[string]map[string]*server{
+		"host1": {
+			"host1": {fqdn: "host1", status: true, master: true},
+			"host2": {fqdn: "host2", status: true},
+		},
+		// The failover server answers while the primary is down.
+		"host2": {
+			"host1": {fqdn: "host1"},
+			"host2": {fqdn: "host2", status: true},
+		},
+		"": {
+			"host1": {fqdn: "host1", status: true},
+			"host2": {fqdn: "host2", status: true},
+		},
+	} {
+		if got := activeServer(servers); got != expected {
+			t.Fatalf("Expected active server %q, got %q", expected, got)
+		}
+	}
+}
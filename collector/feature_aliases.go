@@ -0,0 +1,78 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// applyFeatureAliases renames the features of data to the feature_aliases of
+// license. Features aliased to the same name are merged.
+func applyFeatureAliases(data *lmstatData, license config.License) {
+	if len(license.FeatureAliases) == 0 {
+		return
+	}
+
+	features := make(map[string]*feature, len(data.features))
+	for name, f := range data.features {
+		name = license.FeatureName(name)
+		merged, ok := features[name]
+		if !ok {
+			features[name] = f
+			continue
+		}
+		merged.issued += f.issued
+		merged.used += f.used
+		merged.queued += f.queued
+		merged.softLimit += f.softLimit
+		merged.overdraft += f.overdraft
+		merged.hasSoftLimit = merged.hasSoftLimit || f.hasSoftLimit
+		merged.hasOverdraft = merged.hasOverdraft || f.hasOverdraft
+	}
+	data.features = features
+	data.usersByFeature = aliasNested(data.usersByFeature, license)
+	data.hostsByFeature = aliasNested(data.hostsByFeature, license)
+	data.reservationsByFeature = aliasNested(data.reservationsByFeature, license)
+	if data.checkoutsByFeature != nil {
+		checkouts := make(map[string][]checkout, len(data.checkoutsByFeature))
+		for name, list := range data.checkoutsByFeature {
+			name = license.FeatureName(name)
+			checkouts[name] = append(checkouts[name], list...)
+		}
+		data.checkoutsByFeature = checkouts
+	}
+}
+
+// aliasNested renames the features of m, adding up the values of features
+// aliased to the same name.
+func aliasNested(m map[string]map[string]float64, license config.License) map[string]map[string]float64 {
+	if m == nil {
+		return nil
+	}
+	aliased := make(map[string]map[string]float64, len(m))
+	for name, values := range m {
+		for key, value := range values {
+			addToNested(aliased, license.FeatureName(name), key, value)
+		}
+	}
+	return aliased
+}
+
+// applyFeatureExpAliases renames the license lines of featuresExp to the
+// feature_aliases of license.
+func applyFeatureExpAliases(featuresExp map[int]*featureExp, license config.License) {
+	for _, f := range featuresExp {
+		f.name = license.FeatureName(f.name)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestApplyFeatureAliases(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseLmstatParseable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parseLmstatParseable(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	license := config.License{Name: "app1", FeatureAliases: map[string]string{"feature1": "HPC"}}
+	applyFeatureAliases(data, license)
+	if data.features["feature1"] != nil || data.usersByFeature["feature1"] != nil || data.reservationsByFeature["feature1"] != nil {
+		t.Fatalf("Expected feature1 to be renamed, got %v", data.features)
+	}
+	if f := data.features["HPC"]; f == nil || f.issued != 144 || f.used != 3 {
+		t.Fatalf("Unexpected values for HPC: %+v", f)
+	}
+	if used := data.usersByFeature["HPC"]["user1"]; used != 2 {
+		t.Fatalf("Unexpected values for HPC[user1]: %v!=2", used)
+	}
+	if len(data.hostsByFeature["HPC"]) != 2 || data.reservationsByFeature["HPC"]["GROUP1"] != 8 {
+		t.Fatalf("Unexpected hosts %v or reservations %v for HPC", data.hostsByFeature["HPC"], data.reservationsByFeature["HPC"])
+	}
+
+	// Features aliased to the same name are merged.
+	license.FeatureAliases["feature2"] = "HPC"
+	applyFeatureAliases(data, license)
+	if f := data.features["HPC"]; len(data.features) != 1 || f.issued != 169 || !f.hasSoftLimit || f.softLimit != 20 {
+		t.Fatalf("Unexpected merged values for HPC: %+v", f)
+	}
+}
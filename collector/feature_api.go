@@ -0,0 +1,167 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// ErrFeatureNotFound is returned by LookupFeature if no license serves the feature.
+var ErrFeatureNotFound = errors.New("feature not found")
+
+// FeatureStatus is the current state of a feature across all licenses.
+type FeatureStatus struct {
+	Feature  string           `json:"feature"`
+	Licenses []LicenseFeature `json:"licenses"`
+	// Errors holds the licenses that couldn't be queried, by name.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// LicenseFeature is the state of a feature on a single license.
+type LicenseFeature struct {
+	License string  `json:"license_name"`
+	Issued  float64 `json:"issued"`
+	Used    float64 `json:"used"`
+	Queued  float64 `json:"queued"`
+	// Users maps the users holding the feature to their number of licenses.
+	// It is only set if monitor_users is enabled for the license.
+	Users map[string]float64 `json:"users,omitempty"`
+	// Holders lists who holds the feature while all its licenses are in use,
+	// most seats first, so that the first responder knows whom to ask to
+	// release some. It is only set if monitor_users is enabled.
+	Holders     []Holder            `json:"holders,omitempty"`
+	Expirations []FeatureExpiration `json:"expirations,omitempty"`
+}
+
+// Holder is a user holding seats of a feature.
+type Holder struct {
+	User  string  `json:"user"`
+	Host  string  `json:"host,omitempty"`
+	Seats float64 `json:"seats"`
+	// CheckoutAgeSeconds counts from the first collection that saw the user
+	// holding the feature, it is left out until one did.
+	CheckoutAgeSeconds float64 `json:"checkout_age_seconds,omitempty"`
+}
+
+// FeatureExpiration is one license line of the feature.
+type FeatureExpiration struct {
+	Version  string `json:"version"`
+	Vendor   string `json:"vendor"`
+	Licenses string `json:"licenses"`
+	// Expires is empty for permanent licenses and unparseable dates.
+	Expires    string `json:"expires,omitempty"`
+	ExpiresRaw string `json:"expires_raw"`
+}
+
+// LookupFeature queries every license of cfg that exports the named feature
+// and returns its current users, queue and expiration dates.
+func LookupFeature(ctx context.Context, cfg *config.Config, logger log.Logger, name string) (*FeatureStatus, error) {
+	if cfg == nil {
+		return nil, ErrFeatureNotFound
+	}
+
+	ctx = withCommandCache(ctx)
+	status := &FeatureStatus{Feature: name, Licenses: []LicenseFeature{}}
+	lmstat := &LmstatCollector{config: cfg, logger: logger}
+	for _, license := range cfg.Licenses {
+		target := licenseTarget(license)
+		if target == "" {
+			continue
+		}
+		if !featureSelected(name, splitCSVList(license.FeaturesToInclude), splitCSVList(license.FeaturesToExclude)) {
+			continue
+		}
+
+		data, _, err := lmstat.queryLicense(ctx, license, target)
+		if err != nil {
+			if status.Errors == nil {
+				status.Errors = make(map[string]string)
+			}
+			status.Errors[license.Name] = err.Error()
+			continue
+		}
+		f, ok := data.features[name]
+		if !ok {
+			continue
+		}
+
+		lf := LicenseFeature{License: license.Name, Issued: f.issued, Used: f.used, Queued: f.queued}
+		if license.MonitorUsers {
+			lf.Users = data.usersByFeature[name]
+			if f.issued > 0 && f.used >= f.issued {
+				lf.Holders = featureHolders(data.checkoutsByFeature[name], checkoutStarts.checkouts(license.Name)[name], time.Now())
+			}
+		}
+		// Expiration dates are best effort, the usage is still worth reporting.
+		featuresExp, _ := queryFeatureExpirations(ctx, cfg, logger, license, target)
+		indexes := make([]int, 0, len(featuresExp))
+		for index := range featuresExp {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		for _, index := range indexes {
+			exp := featuresExp[index]
+			if exp.name != name {
+				continue
+			}
+			e := FeatureExpiration{Version: exp.version, Vendor: exp.vendor, Licenses: exp.licenses, ExpiresRaw: exp.rawExpires}
+			if exp.parsed && !math.IsInf(exp.expires, 1) {
+				e.Expires = time.Unix(int64(exp.expires), 0).UTC().Format(time.RFC3339)
+			}
+			lf.Expirations = append(lf.Expirations, e)
+		}
+		status.Licenses = append(status.Licenses, lf)
+	}
+
+	if len(status.Licenses) == 0 && len(status.Errors) == 0 {
+		return nil, ErrFeatureNotFound
+	}
+	return status, nil
+}
+
+// featureHolders returns the holders of checkouts, most seats first and then
+// the longest held, given since when each user has been seen holding the
+// feature.
+func featureHolders(checkouts []checkout, since map[string]time.Time, now time.Time) []Holder {
+	holders := make([]Holder, 0, len(checkouts))
+	for _, c := range checkouts {
+		h := Holder{User: c.user, Host: c.host, Seats: c.seats}
+		if start, ok := since[c.user]; ok {
+			h.CheckoutAgeSeconds = math.Max(now.Sub(start).Seconds(), 0)
+		}
+		holders = append(holders, h)
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		a, b := holders[i], holders[j]
+		if a.Seats != b.Seats {
+			return a.Seats > b.Seats
+		}
+		if a.CheckoutAgeSeconds != b.CheckoutAgeSeconds {
+			return a.CheckoutAgeSeconds > b.CheckoutAgeSeconds
+		}
+		if a.User != b.User {
+			return a.User < b.User
+		}
+		return a.Host < b.Host
+	})
+	return holders
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const testParseLmstatQueued = "fixtures/lmstat_queued.txt"
+
+func TestParseLmstatQueued(t *testing.T) {
+	raw, err := os.ReadFile(testParseLmstatQueued)
+	if err != nil {
+		t.Fatalf("Unexpected error reading fixture: %v", err)
+	}
+	data, err := legacyQuirks.parseHuman(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing fixture: %v", err)
+	}
+
+	f, ok := data.features["feature1"]
+	if !ok {
+		t.Fatalf("feature1 not parsed")
+	}
+	if f.used != 2 || f.queued != 3 {
+		t.Fatalf("Unexpected feature1 usage: used=%v queued=%v", f.used, f.queued)
+	}
+	// Queued users don't hold a seat.
+	if _, ok := data.usersByFeature["feature1"]["user3"]; ok {
+		t.Fatalf("Queued user3 counted as using feature1: %v", data.usersByFeature["feature1"])
+	}
+	if len(data.usersByFeature["feature1"]) != 2 {
+		t.Fatalf("Unexpected feature1 users: %v", data.usersByFeature["feature1"])
+	}
+}
+
+func TestLookupFeature(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": testParseLmstatQueued})
+	defer func(prev *checkoutStartTracker) { checkoutStarts = prev }(checkoutStarts)
+	checkoutStarts = newCheckoutStartTracker()
+	checkoutStarts.observe("app1", map[string]map[string]float64{"feature1": {"user2": 1}}, map[string]bool{"feature1": true}, time.Now().Add(-time.Hour))
+
+	cfg := &config.Config{Licenses: []config.License{
+		{Name: "app1", LicenseServer: "27002@host2.domain.net", MonitorUsers: true},
+		{Name: "app2", LicenseServer: "27002@host2.domain.net", FeaturesToExclude: "feature1"},
+	}}
+	status, err := LookupFeature(context.Background(), cfg, log.NewNopLogger(), "feature1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(status.Licenses) != 1 || status.Licenses[0].License != "app1" {
+		t.Fatalf("Unexpected licenses: %+v", status.Licenses)
+	}
+	if got := status.Licenses[0]; got.Queued != 3 || len(got.Users) != 2 {
+		t.Fatalf("Unexpected feature1 status: %+v", got)
+	}
+	// All seats are in use, user2 was seen holding one for longer.
+	holders := status.Licenses[0].Holders
+	if len(holders) != 2 || holders[0].User != "user2" || holders[0].Host != "host2" || holders[0].CheckoutAgeSeconds < 3600 || holders[1].User != "user1" {
+		t.Fatalf("Unexpected feature1 holders: %+v", holders)
+	}
+
+	if _, err := LookupFeature(context.Background(), cfg, log.NewNopLogger(), "nofeature"); !errors.Is(err, ErrFeatureNotFound) {
+		t.Fatalf("Unexpected error for unknown feature: %v", err)
+	}
+}
+
+func TestListLicenses(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": testParseLmstatQueued})
+
+	cfg := &config.Config{Licenses: []config.License{
+		{Name: "app1", LicenseServer: "27002@host2.domain.net", MonitorUsers: true},
+		{Name: "app2", LicenseServer: "27002@host2.domain.net", FeaturesToExclude: "feature1"},
+		{Name: "app3", LicenseServer: "27002@host2.domain.net"},
+	}}
+	list := ListLicenses(context.Background(), cfg, log.NewNopLogger(), LicenseListOptions{Limit: 2, Features: true, Users: true})
+	if list.Total != 3 || list.NextOffset != 2 || len(list.Licenses) != 2 {
+		t.Fatalf("Unexpected first page: %+v", list)
+	}
+	app1, app2 := list.Licenses[0], list.Licenses[1]
+	if app1.License != "app1" || len(app1.Features) == 0 || len(app1.Users["feature1"]) != 2 {
+		t.Fatalf("Unexpected app1 status: %+v", app1)
+	}
+	// Users are only reported with monitor_users and excluded features are skipped.
+	if app2.Users != nil {
+		t.Fatalf("Unexpected app2 users: %v", app2.Users)
+	}
+	for _, f := range app2.Features {
+		if f.Feature == "feature1" {
+			t.Fatalf("Excluded feature1 listed for app2: %+v", app2.Features)
+		}
+	}
+
+	list = ListLicenses(context.Background(), cfg, log.NewNopLogger(), LicenseListOptions{Offset: 2, Limit: 2, Users: true})
+	if list.NextOffset != 0 || len(list.Licenses) != 1 || list.Licenses[0].Features != nil {
+		t.Fatalf("Unexpected last page: %+v", list)
+	}
+
+	list = ListLicenses(context.Background(), cfg, log.NewNopLogger(), LicenseListOptions{Offset: 5})
+	if list.Total != 3 || len(list.Licenses) != 0 {
+		t.Fatalf("Unexpected page past the end: %+v", list)
+	}
+}
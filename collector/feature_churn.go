@@ -0,0 +1,91 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	featureAppearedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "appeared_total"),
+		"Number of features that appeared on a license since the previous successful collection, summed since the exporter started.",
+		[]string{"license_name"},
+		nil,
+	)
+	featureDisappearedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "disappeared_total"),
+		"Number of features that disappeared from a license since the previous successful collection, summed since the exporter started.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	featureChurn = newChurnTracker()
+)
+
+// churnTracker counts the features added to and removed from licenses
+// between successful collections, like after an ISV license swap.
+type churnTracker struct {
+	mu          sync.Mutex
+	prev        map[string]map[string]bool
+	appeared    map[string]float64
+	disappeared map[string]float64
+}
+
+func newChurnTracker() *churnTracker {
+	return &churnTracker{
+		prev:        make(map[string]map[string]bool),
+		appeared:    make(map[string]float64),
+		disappeared: make(map[string]float64),
+	}
+}
+
+// observe records the features exported for license. The first observation
+// of a license counts nothing.
+func (t *churnTracker) observe(license string, features map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, seen := t.prev[license]
+	t.prev[license] = features
+	if !seen {
+		// Exported as zero from the first collection on.
+		t.appeared[license] = 0
+		t.disappeared[license] = 0
+		return
+	}
+	for feature := range features {
+		if !prev[feature] {
+			t.appeared[license]++
+		}
+	}
+	for feature := range prev {
+		if !features[feature] {
+			t.disappeared[license]++
+		}
+	}
+}
+
+func (t *churnTracker) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for license, n := range t.appeared {
+		ch <- constMetric(featureAppearedDesc, prometheus.CounterValue, n, license)
+	}
+	for license, n := range t.disappeared {
+		ch <- constMetric(featureDisappearedDesc, prometheus.CounterValue, n, license)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestChurnTracker(t *testing.T) {
+	tr := newChurnTracker()
+
+	tr.observe("app1", map[string]bool{"feature1": true, "feature2": true})
+	if tr.appeared["app1"] != 0 || tr.disappeared["app1"] != 0 {
+		t.Fatalf("Expected nothing counted on the first collection, got %v and %v", tr.appeared, tr.disappeared)
+	}
+
+	// A license swap replacing feature2 by feature3 and feature4.
+	tr.observe("app1", map[string]bool{"feature1": true, "feature3": true, "feature4": true})
+	tr.observe("app1", map[string]bool{"feature1": true, "feature3": true, "feature4": true})
+	if tr.appeared["app1"] != 2 || tr.disappeared["app1"] != 1 {
+		t.Fatalf("Expected 2 appeared and 1 disappeared, got %v and %v", tr.appeared["app1"], tr.disappeared["app1"])
+	}
+
+	tr.observe("app1", nil)
+	if tr.disappeared["app1"] != 4 {
+		t.Fatalf("Expected 4 disappeared, got %v", tr.disappeared["app1"])
+	}
+}
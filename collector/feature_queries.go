@@ -0,0 +1,123 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// runLicenseStatus runs the `rlmstat -a` of args for license, or with
+// query_features an `rlmstat -f <feature>` per included feature, merging
+// their output.
+func (c *LmstatCollector) runLicenseStatus(ctx context.Context, license config.License, parse func([]byte) (*lmstatData, error), args ...string) (*lmstatData, error) {
+	if !license.QueryFeatures {
+		return c.runLmstat(ctx, license, parse, args...)
+	}
+	var merged *lmstatData
+	for _, feature := range license.QueriedFeatures() {
+		data, err := c.runLmstat(ctx, license, parse, featureQueryArgs(feature, args)...)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = data
+			continue
+		}
+		mergeLmstatData(merged, data)
+	}
+	return merged, nil
+}
+
+// featureQueryArgs returns args querying feature instead of all features.
+func featureQueryArgs(feature string, args []string) []string {
+	query := make([]string, 0, len(args)+1)
+	for _, arg := range args {
+		if arg == "-a" {
+			query = append(query, "-f", feature)
+			continue
+		}
+		query = append(query, arg)
+	}
+	return query
+}
+
+// mergeLmstatData adds the features of src to dst. The servers and ISVs both
+// print are kept from dst.
+func mergeLmstatData(dst, src *lmstatData) {
+	if dst.servers == nil {
+		dst.servers = make(map[string]*server)
+	}
+	for name, s := range src.servers {
+		if _, ok := dst.servers[name]; !ok {
+			dst.servers[name] = s
+		}
+	}
+	if dst.vendors == nil {
+		dst.vendors = make(map[string]*vendor)
+	}
+	for name, v := range src.vendors {
+		if _, ok := dst.vendors[name]; !ok {
+			dst.vendors[name] = v
+		}
+	}
+	if dst.features == nil {
+		dst.features = make(map[string]*feature)
+	}
+	for name, f := range src.features {
+		dst.features[name] = f
+	}
+	dst.usersByFeature = mergeByFeature(dst.usersByFeature, src.usersByFeature)
+	dst.hostsByFeature = mergeByFeature(dst.hostsByFeature, src.hostsByFeature)
+	dst.reservationsByFeature = mergeByFeature(dst.reservationsByFeature, src.reservationsByFeature)
+	if dst.checkoutsByFeature == nil {
+		dst.checkoutsByFeature = make(map[string][]checkout)
+	}
+	for name, checkouts := range src.checkoutsByFeature {
+		dst.checkoutsByFeature[name] = checkouts
+	}
+	if dst.isvByFeature == nil {
+		dst.isvByFeature = make(map[string]string)
+	}
+	for name, isv := range src.isvByFeature {
+		dst.isvByFeature[name] = isv
+	}
+	for _, b := range src.banners {
+		if !hasBanner(dst.banners, b.server) {
+			dst.banners = append(dst.banners, b)
+		}
+	}
+	dst.output = append(dst.output, src.output...)
+}
+
+// mergeByFeature adds the per feature values of src to dst and returns dst.
+func mergeByFeature(dst, src map[string]map[string]float64) map[string]map[string]float64 {
+	if dst == nil {
+		dst = make(map[string]map[string]float64)
+	}
+	for name, values := range src {
+		dst[name] = values
+	}
+	return dst
+}
+
+func hasBanner(banners []serverBanner, server string) bool {
+	for _, b := range banners {
+		if b.server == server {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestRunLicenseStatusQueryFeatures(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	body := "#!/bin/sh\necho \"$@\" >> " + shellQuote(calls) + "\ncase \"$2\" in\n"
+	for _, feature := range []string{"feature8", "feature100"} {
+		path, err := filepath.Abs("fixtures/lmstat_f_" + feature + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body += feature + ") cat " + shellQuote(path) + " ;;\n"
+	}
+	body += "esac\n"
+	script := filepath.Join(dir, "rlmstat")
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Unexpected error writing script: %v", err)
+	}
+	oldPath := *rlmstatPath
+	defer func() { *rlmstatPath = oldPath }()
+	*rlmstatPath = script
+
+	license := config.License{
+		Name:              "app1",
+		LicenseServer:     "27002@host-1.domain.net",
+		FeaturesToInclude: "feature8,licenses100",
+		FeatureAliases:    map[string]string{"feature100": "licenses100"},
+		QueryFeatures:     true,
+	}
+	c := &LmstatCollector{logger: log.NewNopLogger()}
+	data, err := c.runLicenseStatus(withCommandCache(context.Background()), license,
+		quirksForVersion("v11.7").parseHuman, "-a", "-c", license.LicenseServer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-f feature8 -c 27002@host-1.domain.net\n-f feature100 -c 27002@host-1.domain.net\n"
+	if string(out) != expected {
+		t.Fatalf("Unexpected rlmstat runs %q, expected %q", out, expected)
+	}
+	if len(data.features) != 2 || data.features["feature8"].used != 1 || data.features["feature100"].issued != 10 {
+		t.Fatalf("Expected feature8 and feature100, got %v", data.features)
+	}
+	if len(data.servers) != 3 || len(data.vendors) != 1 {
+		t.Fatalf("Expected the servers and ISV printed by both runs once, got %v and %v", data.servers, data.vendors)
+	}
+	if data.usersByFeature["feature8"]["user17"] != 1 || len(data.usersByFeature["feature100"]) == 0 {
+		t.Fatalf("Expected the users of both features, got %v", data.usersByFeature)
+	}
+	if !strings.Contains(string(data.output), "Users of feature8") || !strings.Contains(string(data.output), "Users of feature100") {
+		t.Fatalf("Expected the output of both runs, got %s", data.output)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// featureSeenWriteInterval is how often the last-seen times are written to
+// the file, which bounds how much they lag after a crash. Features seen for
+// the first time are written right away.
+const featureSeenWriteInterval = time.Minute
+
+var (
+	featureFirstSeenDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "first_seen_timestamp_seconds"),
+		"Time the feature was first served by the license, as recorded in --path.feature-seen-file, in seconds since the epoch.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureLastSeenDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "last_seen_timestamp_seconds"),
+		"Time the feature was last served by the license, as recorded in --path.feature-seen-file, in seconds since the epoch.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+
+	featureSeenCSVHeader = []string{"license_name", "feature", "first_seen", "last_seen"}
+
+	featureSeen = &seenTracker{}
+)
+
+// seenTimes is when a feature was first and last served.
+type seenTimes struct {
+	first, last time.Time
+}
+
+// seenTracker keeps when every feature ever exported was first and last
+// served in a CSV file, so that restarts don't lose them. Features removed
+// from a license are kept, their last-seen time tells when.
+type seenTracker struct {
+	mu      sync.Mutex
+	path    string
+	seen    map[featureKey]seenTimes
+	written time.Time
+}
+
+// LoadFeatureSeen records the first-seen and last-seen times of the features
+// in the CSV file at path, starting from those it already holds. Empty
+// disables them.
+func LoadFeatureSeen(path string) error {
+	return featureSeen.load(path)
+}
+
+// FlushFeatureSeen writes the last-seen times observed since the previous
+// write, like when stopping.
+func FlushFeatureSeen() error {
+	featureSeen.mu.Lock()
+	defer featureSeen.mu.Unlock()
+	return featureSeen.write(time.Now())
+}
+
+func (t *seenTracker) load(path string) error {
+	seen := make(map[featureKey]seenTimes)
+	if path != "" {
+		var err error
+		if seen, err = readFeatureSeenFile(path); err != nil {
+			return err
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.path, t.seen, t.written = path, seen, time.Time{}
+	return nil
+}
+
+// observe records that the exported features of license are served at now.
+func (t *seenTracker) observe(license string, exported map[string]bool, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.path == "" {
+		return nil
+	}
+
+	added := false
+	for feature := range exported {
+		key := featureKey{license, feature}
+		times, ok := t.seen[key]
+		if !ok {
+			times.first = now
+			added = true
+		}
+		times.last = now
+		t.seen[key] = times
+	}
+	if !added && now.Sub(t.written) < featureSeenWriteInterval {
+		return nil
+	}
+	return t.write(now)
+}
+
+// write replaces the file with the times of every feature, sorted by license
+// and feature. t.mu must be held.
+func (t *seenTracker) write(now time.Time) error {
+	if t.path == "" {
+		return nil
+	}
+	keys := make([]featureKey, 0, len(t.seen))
+	for key := range t.seen {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.license != b.license {
+			return a.license < b.license
+		}
+		return a.feature < b.feature
+	})
+
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := csv.NewWriter(tmp)
+	w.Write(featureSeenCSVHeader)
+	for _, key := range keys {
+		times := t.seen[key]
+		w.Write([]string{
+			key.license,
+			key.feature,
+			strconv.FormatInt(times.first.Unix(), 10),
+			strconv.FormatInt(times.last.Unix(), 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		return err
+	}
+	t.written = now
+	return nil
+}
+
+// collect sends the first-seen and last-seen times of every feature.
+func (t *seenTracker) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, times := range t.seen {
+		ch <- constMetric(featureFirstSeenDesc, prometheus.GaugeValue, float64(times.first.Unix()), key.license, key.feature)
+		ch <- constMetric(featureLastSeenDesc, prometheus.GaugeValue, float64(times.last.Unix()), key.license, key.feature)
+	}
+}
+
+// readFeatureSeenFile returns the times held by the file at path, none if
+// it doesn't exist.
+func readFeatureSeenFile(path string) (map[featureKey]seenTimes, error) {
+	seen := make(map[featureKey]seenTimes)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+	for i, record := range records {
+		if i == 0 || len(record) != len(featureSeenCSVHeader) {
+			continue
+		}
+		var times [2]time.Time
+		for j, field := range record[2:] {
+			seconds, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s on line %d of %s: %w", featureSeenCSVHeader[2+j], i+1, path, err)
+			}
+			times[j] = time.Unix(seconds, 0)
+		}
+		seen[featureKey{record[0], record[1]}] = seenTimes{first: times[0], last: times[1]}
+	}
+	return seen, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeenTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.csv")
+	tracker := &seenTracker{}
+	if err := tracker.load(path); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1735689600, 0)
+	observations := []map[string]bool{
+		{"solver": true},
+		{"solver": true, "mesher": true},
+		{"mesher": true},
+	}
+	for i, exported := range observations {
+		if err := tracker.observe("app1", exported, start.Add(time.Duration(i)*30*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The last observation is within a minute of the previous write.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "license_name,feature,first_seen,last_seen\napp1,mesher,1735689630,1735689630\napp1,solver,1735689600,1735689630\n"
+	if string(data) != want {
+		t.Fatalf("Unexpected file:\n%s", data)
+	}
+
+	// A restart resumes from the file and keeps the first-seen times.
+	restarted := &seenTracker{}
+	if err := restarted.load(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.observe("app1", map[string]bool{"mesher": true}, start.Add(5*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	mesher := restarted.seen[featureKey{"app1", "mesher"}]
+	if !mesher.first.Equal(start.Add(30*time.Second)) || !mesher.last.Equal(start.Add(5*time.Hour)) {
+		t.Fatalf("Unexpected times of mesher after a restart: %+v", mesher)
+	}
+	if solver := restarted.seen[featureKey{"app1", "solver"}]; !solver.last.Equal(start.Add(30 * time.Second)) {
+		t.Fatalf("Expected the removed solver to keep its last-seen time, got %+v", solver)
+	}
+
+	if err := os.WriteFile(path, []byte("license_name,feature,first_seen,last_seen\napp1,solver,soon,1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.load(path); err == nil || !strings.Contains(err.Error(), "invalid first_seen on line 2") {
+		t.Fatalf("Expected an invalid first_seen, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const (
+	// maxIdleConnsPerHost bounds the idle connections kept to each server.
+	maxIdleConnsPerHost = 4
+	// sslCertFileEnv names the CA certificates file trusted next to the
+	// system roots, like the bundle copied into a scratch image.
+	sslCertFileEnv = "SSL_CERT_FILE"
+)
+
+var (
+	// httpClients are shared by every server with the same settings, across
+	// scrapes, so that their connections are reused rather than reopened.
+	// They are keyed by the settings printed with %#v, which have a slice.
+	httpClients   = make(map[string]*http.Client)
+	httpClientsMu sync.Mutex
+
+	systemRoots = sync.OnceValues(func() (*x509.CertPool, error) {
+		return loadSystemRoots(os.Getenv(sslCertFileEnv))
+	})
+)
+
+// SystemRoots returns the roots servers are verified with without ca_file:
+// the system roots and the certificates of $SSL_CERT_FILE, nil for the
+// system roots alone. Go itself only reads $SSL_CERT_FILE on Unix and
+// ignores it when it can't be read, failing every TLS connection of static
+// images later.
+func SystemRoots() (*x509.CertPool, error) {
+	return systemRoots()
+}
+
+// loadSystemRoots returns the system roots with the certificates of file,
+// nil if file is empty.
+func loadSystemRoots(file string) (*x509.CertPool, error) {
+	if file == "" {
+		return nil, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", sslCertFileEnv, err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s %s", sslCertFileEnv, file)
+	}
+	return pool, nil
+}
+
+// httpClient returns the shared client for settings, with defaultTimeout if
+// settings has none. CA files are read when the client is created.
+func httpClient(settings config.HTTPClient, defaultTimeout time.Duration) (*http.Client, error) {
+	if settings.Timeout == 0 {
+		settings.Timeout = defaultTimeout
+	}
+
+	key := fmt.Sprintf("%#v", settings)
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+	if client, ok := httpClients[key]; ok {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+	if len(settings.PinnedSPKI) > 0 {
+		pins, err := settings.PinnedKeys()
+		if err != nil {
+			return nil, err
+		}
+		// The pins replace the verification of the certificate chain.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPinnedKey(cs.PeerCertificates, pins)
+		}
+	}
+	if settings.CAFile == "" {
+		roots, err := systemRoots()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = roots
+	} else {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA file %s", settings.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if settings.CertFile != "" {
+		certs := &clientCertificate{certFile: settings.CertFile, keyFile: settings.KeyFile}
+		if _, err := certs.get(nil); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = certs.get
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	// The default transport honors the proxy environment.
+	switch {
+	case settings.NoProxy:
+		transport.Proxy = nil
+	case settings.ProxyURL != "":
+		proxy, err := url.Parse(settings.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	var rt http.RoundTripper = transport
+	if settings.BearerTokenFile != "" {
+		rt = &bearerTokenTransport{next: transport, file: settings.BearerTokenFile}
+	}
+	client := &http.Client{Transport: rt, Timeout: settings.Timeout}
+	httpClients[key] = client
+	return client, nil
+}
+
+// clientCertificate loads a client certificate again whenever its files
+// change, so that rotated certificates are used without a restart.
+type clientCertificate struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified [2]time.Time
+}
+
+// get implements tls.Config.GetClientCertificate. A certificate that can't be
+// loaded again, like while its files are being replaced, is kept.
+func (c *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var modified [2]time.Time
+	for i, path := range []string{c.certFile, c.keyFile} {
+		if fi, err := os.Stat(path); err == nil {
+			modified[i] = fi.ModTime()
+		}
+	}
+	if c.cert != nil && modified == c.modified {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("couldn't load client certificate: %w", err)
+	}
+	c.cert, c.modified = &cert, modified
+	return c.cert, nil
+}
+
+// bearerTokenTransport sends the token in file with every request.
+type bearerTokenTransport struct {
+	next http.RoundTripper
+	file string
+}
+
+// RoundTrip implements http.RoundTripper. The file is read on every request
+// so that rotated tokens are picked up.
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(t.file)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read bearer token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	return t.next.RoundTrip(req)
+}
+
+// verifyPinnedKey checks that the public key of the leaf certificate has one
+// of the SHA-256 hashes pins.
+func verifyPinnedKey(certs []*x509.Certificate, pins [][sha256.Size]byte) error {
+	if len(certs) == 0 {
+		return errors.New("no server certificate to check the pinned keys against")
+	}
+	hash := sha256.Sum256(certs[0].RawSubjectPublicKeyInfo)
+	for _, pin := range pins {
+		if hash == pin {
+			return nil
+		}
+	}
+	return fmt.Errorf("public key of %s with hash sha256//%s isn't pinned",
+		certs[0].Subject, base64.StdEncoding.EncodeToString(hash[:]))
+}
@@ -0,0 +1,285 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	idleTimeout = kingpin.Flag("collector.idle.timeout",
+		"Timeout of fetching the heartbeat report of the idle collector.").Default("10s").Duration()
+
+	heartbeatUpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "heartbeat", "up"),
+		"Whether the heartbeat report of the license clients could be read and parsed.",
+		nil,
+		nil,
+	)
+	checkoutIdleSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "checkout", "idle_seconds"),
+		"Seconds since the user holding a checkout was last active, according to the heartbeat report, or since the checkout if later.",
+		[]string{"license_name", "feature", "user"},
+		nil,
+	)
+
+	checkoutStarts = newCheckoutStartTracker()
+)
+
+// checkoutStartTracker remembers since when the users of each feature have
+// been seen holding it.
+type checkoutStartTracker struct {
+	mu sync.Mutex
+	// since is keyed by license, feature and user.
+	since map[string]map[string]map[string]time.Time
+}
+
+func newCheckoutStartTracker() *checkoutStartTracker {
+	return &checkoutStartTracker{since: make(map[string]map[string]map[string]time.Time)}
+}
+
+// observe replaces the checkouts of license with the users of the features
+// in exported, keeping when the checkouts already seen started.
+func (t *checkoutStartTracker) observe(license string, usersByFeature map[string]map[string]float64, exported map[string]bool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev := t.since[license]
+	current := make(map[string]map[string]time.Time)
+	for feature, users := range usersByFeature {
+		if !exported[feature] || len(users) == 0 {
+			continue
+		}
+		current[feature] = make(map[string]time.Time, len(users))
+		for user := range users {
+			since, ok := prev[feature][user]
+			if !ok {
+				since = now
+			}
+			current[feature][user] = since
+		}
+	}
+	t.since[license] = current
+}
+
+// checkouts returns the checkouts of license by feature and user.
+func (t *checkoutStartTracker) checkouts(license string) map[string]map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.since[license]
+}
+
+// heartbeats holds the last activity of users from a heartbeat report.
+type heartbeats struct {
+	// byUser is the last activity of a user on any feature.
+	byUser map[string]time.Time
+	// byFeature is the last activity of a user on a feature, keyed by user
+	// and feature.
+	byFeature map[[2]string]time.Time
+}
+
+// last returns the last activity of user on feature and whether there was
+// any.
+func (h heartbeats) last(user, feature string) (time.Time, bool) {
+	user = strings.ToLower(user)
+	last, ok := h.byUser[user]
+	if t, found := h.byFeature[[2]string{user, feature}]; found && (!ok || t.After(last)) {
+		last, ok = t, true
+	}
+	return last, ok
+}
+
+type idleCollector struct {
+	config *config.Config
+	logger log.Logger
+	now    func() time.Time
+}
+
+func init() {
+	registerCollector("idle", false, NewIdleCollector)
+}
+
+// NewIdleCollector returns a collector correlating the checkouts found by the
+// lmstat collector with the last activity of their users from the heartbeat
+// report, so that idle sessions can be reclaimed.
+func NewIdleCollector(cfg *config.Config, logger log.Logger) (Collector, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &idleCollector{
+		config: cfg,
+		logger: logger,
+		now:    time.Now,
+	}, nil
+}
+
+// Describe implements the Collector interface.
+func (c *idleCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- heartbeatUpDesc
+	ch <- checkoutIdleSecondsDesc
+}
+
+// Update implements the Collector interface. Checkouts of users missing from
+// the heartbeat report aren't exported, nothing tells whether they are idle.
+func (c *idleCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) (err error) {
+	if c.config == nil || c.config.Heartbeat == nil {
+		return nil
+	}
+	defer func() { collectionErrors.record("idle", "heartbeat", err) }()
+
+	hb, err := c.fetchHeartbeats(ctx, *c.config.Heartbeat)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to read heartbeat report", "err", err)
+		ch <- constMetric(heartbeatUpDesc, prometheus.GaugeValue, 0)
+		return fmt.Errorf("heartbeat: %w", err)
+	}
+	ch <- constMetric(heartbeatUpDesc, prometheus.GaugeValue, 1)
+
+	now := c.now()
+	for _, license := range c.config.Licenses {
+		for feature, users := range checkoutStarts.checkouts(license.Name) {
+			for user, since := range users {
+				last, ok := hb.last(user, feature)
+				if !ok {
+					continue
+				}
+				if since.After(last) {
+					last = since
+				}
+				idle := math.Max(now.Sub(last).Seconds(), 0)
+				ch <- constMetric(checkoutIdleSecondsDesc, prometheus.GaugeValue, idle, license.Name, feature, user)
+			}
+		}
+	}
+	return nil
+}
+
+// fetchHeartbeats reads the heartbeat report from the file or URL of source.
+func (c *idleCollector) fetchHeartbeats(ctx context.Context, source config.HeartbeatSource) (heartbeats, error) {
+	if source.File != "" {
+		f, err := os.Open(source.File)
+		if err != nil {
+			return heartbeats{}, err
+		}
+		defer f.Close()
+		hb, err := parseHeartbeats(f)
+		if err != nil {
+			return heartbeats{}, parseError(err)
+		}
+		return hb, nil
+	}
+
+	client, err := httpClient(source.HTTPClient, *idleTimeout)
+	if err != nil {
+		return heartbeats{}, configError(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return heartbeats{}, configError(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return heartbeats{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return heartbeats{}, networkError(fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	hb, err := parseHeartbeats(resp.Body)
+	if err != nil {
+		return heartbeats{}, parseError(err)
+	}
+	return hb, nil
+}
+
+// parseHeartbeats parses a heartbeat report, a CSV file with a header naming
+// at least the user and last_activity columns. last_activity is an RFC 3339
+// time or Unix seconds. The optional feature column restricts the activity to
+// a feature; rows without one apply to every feature of the user.
+func parseHeartbeats(r io.Reader) (heartbeats, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return heartbeats{}, err
+	}
+	if len(records) == 0 {
+		return heartbeats{}, errors.New("empty heartbeat report")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"user", "last_activity"} {
+		if _, ok := columns[name]; !ok {
+			return heartbeats{}, fmt.Errorf("missing %s column in heartbeat report", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	hb := heartbeats{
+		byUser:    make(map[string]time.Time),
+		byFeature: make(map[[2]string]time.Time),
+	}
+	for _, record := range records[1:] {
+		user := strings.ToLower(field(record, "user"))
+		if user == "" {
+			continue
+		}
+		last, err := parseActivityTime(field(record, "last_activity"))
+		if err != nil {
+			return heartbeats{}, fmt.Errorf("invalid last_activity of user %s: %w", user, err)
+		}
+		if feature := field(record, "feature"); feature != "" {
+			if key := [2]string{user, feature}; last.After(hb.byFeature[key]) {
+				hb.byFeature[key] = last
+			}
+		} else if last.After(hb.byUser[user]) {
+			hb.byUser[user] = last
+		}
+	}
+	return hb, nil
+}
+
+// parseActivityTime parses an RFC 3339 time or Unix seconds.
+func parseActivityTime(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(seconds)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const testHeartbeats = "fixtures/heartbeats.csv"
+
+func TestParseHeartbeats(t *testing.T) {
+	hb, err := parseHeartbeats(strings.NewReader("User,Last_Activity\nAlice,2025-03-01T09:50:00Z\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, ok := hb.last("alice", "solver"); !ok || !last.Equal(time.Date(2025, 3, 1, 9, 50, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected last activity %v", last)
+	}
+	if _, ok := hb.last("bob", "solver"); ok {
+		t.Fatal("Expected no activity for bob")
+	}
+
+	for _, report := range []string{"user\nalice\n", "user,last_activity\nalice,yesterday\n", ""} {
+		if _, err := parseHeartbeats(strings.NewReader(report)); err == nil {
+			t.Fatalf("Expected an error parsing %q", report)
+		}
+	}
+}
+
+func TestCheckoutStartTracker(t *testing.T) {
+	tracker := newCheckoutStartTracker()
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	users := map[string]map[string]float64{"solver": {"alice": 1}, "mesher": {"bob": 1}}
+	tracker.observe("app1", users, map[string]bool{"solver": true}, start)
+	users["solver"]["carol"] = 1
+	tracker.observe("app1", users, map[string]bool{"solver": true}, start.Add(time.Minute))
+
+	checkouts := tracker.checkouts("app1")
+	if len(checkouts) != 1 {
+		t.Fatalf("Expected only the exported feature, got %v", checkouts)
+	}
+	if !checkouts["solver"]["alice"].Equal(start) || !checkouts["solver"]["carol"].Equal(start.Add(time.Minute)) {
+		t.Fatalf("Unexpected checkout starts %v", checkouts["solver"])
+	}
+}
+
+func TestIdleCollector(t *testing.T) {
+	defer func(prev *checkoutStartTracker) { checkoutStarts = prev }(checkoutStarts)
+	checkoutStarts = newCheckoutStartTracker()
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	checkoutStarts.observe("app1", map[string]map[string]float64{
+		"solver": {"alice": 1, "bob": 1, "carol": 1},
+		"mesher": {"bob": 1},
+	}, map[string]bool{"solver": true, "mesher": true}, now.Add(-4*time.Hour))
+	checkoutStarts.observe("app2", map[string]map[string]float64{"solver": {"alice": 1}}, map[string]bool{"solver": true}, now.Add(-5*time.Minute))
+
+	cfg := &config.Config{
+		Licenses:  []config.License{{Name: "app1"}, {Name: "app2"}},
+		Heartbeat: &config.HeartbeatSource{File: testHeartbeats},
+	}
+	c, err := NewIdleCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*idleCollector).now = func() time.Time { return now }
+
+	ch := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Update(context.Background(), ch)
+		close(ch)
+	}()
+	idle := make(map[string]float64)
+	var up float64
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		switch m.Desc() {
+		case heartbeatUpDesc:
+			up = pb.GetGauge().GetValue()
+		case checkoutIdleSecondsDesc:
+			labels := make(map[string]string)
+			for _, l := range pb.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			idle[labels["license_name"]+"/"+labels["feature"]+"/"+labels["user"]] = pb.GetGauge().GetValue()
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if up != 1 {
+		t.Fatalf("Expected the heartbeat report to be up, got %v", up)
+	}
+	expected := map[string]float64{
+		"app1/solver/alice": 600,
+		"app1/solver/bob":   1200,
+		"app1/mesher/bob":   7200,
+		// The checkout is more recent than the activity.
+		"app2/solver/alice": 300,
+	}
+	if len(idle) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, idle)
+	}
+	for key, value := range expected {
+		if idle[key] != value {
+			t.Fatalf("Expected %s to be idle for %v seconds, got %v", key, value, idle[key])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	isvLastSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "isv", "last_success_timestamp_seconds"),
+		"Time an ISV daemon was last reported up by a successfully parsed rlmstat run, in seconds since the epoch.",
+		[]string{"isv"},
+		nil,
+	)
+
+	isvContacts = &contactTracker{last: make(map[string]time.Time)}
+)
+
+// contactTracker remembers when every ISV daemon was last seen up. It is
+// kept across collections, so the time stays exported while the daemon or
+// its license server is down.
+type contactTracker struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// observe records the ISV daemons of vendors reported up at now.
+func (t *contactTracker) observe(vendors map[string]*vendor, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, v := range vendors {
+		if v.status && now.After(t.last[name]) {
+			t.last[name] = now
+		}
+	}
+}
+
+func (t *contactTracker) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, last := range t.last {
+		ch <- constMetric(isvLastSuccessDesc, prometheus.GaugeValue, float64(last.UnixNano())/1e9, name)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContactTracker(t *testing.T) {
+	tr := &contactTracker{last: make(map[string]time.Time)}
+	first := time.Unix(1000, 0)
+	later := first.Add(time.Minute)
+
+	tr.observe(map[string]*vendor{
+		"vendor1": {status: true},
+		"vendor2": {status: false},
+	}, first)
+	// Down doesn't count, and a collection started earlier and finishing
+	// later doesn't move the time back.
+	tr.observe(map[string]*vendor{"vendor1": {status: false}}, later)
+	tr.observe(map[string]*vendor{"vendor1": {status: true}}, first)
+
+	if last := tr.last["vendor1"]; !last.Equal(first) {
+		t.Fatalf("Unexpected last success of vendor1 %v", last)
+	}
+	if _, ok := tr.last["vendor2"]; ok {
+		t.Fatal("Expected no last success for vendor2, it was never up")
+	}
+
+	tr.observe(map[string]*vendor{"vendor1": {status: true}}, later)
+	if last := tr.last["vendor1"]; !last.Equal(later) {
+		t.Fatalf("Unexpected last success of vendor1 %v", last)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// applyISVFilter drops the ISVs of data that license doesn't select with
+// isvs, and their features with their users, hosts and reservations.
+// Features whose ISV the output doesn't tell are kept.
+func applyISVFilter(data *lmstatData, license config.License) {
+	if len(license.ISVs) == 0 {
+		return
+	}
+
+	for name := range data.vendors {
+		if !license.SelectsISV(name) {
+			delete(data.vendors, name)
+		}
+	}
+	for name := range data.features {
+		if license.SelectsISV(data.isvByFeature[name]) {
+			continue
+		}
+		delete(data.features, name)
+		delete(data.usersByFeature, name)
+		delete(data.hostsByFeature, name)
+		delete(data.reservationsByFeature, name)
+		delete(data.checkoutsByFeature, name)
+	}
+}
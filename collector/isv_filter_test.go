@@ -0,0 +1,81 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const testParseRlmV14ISVs = "fixtures/lmstat_rlm_v14_isvs.txt"
+
+func TestApplyISVFilter(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseRlmV14ISVs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := quirksForVersion("v14.2").parseHuman(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.isvByFeature["feature1"] != "vendor1" || data.isvByFeature["feature7"] != "vendor3" {
+		t.Fatalf("Unexpected ISVs of the features %v", data.isvByFeature)
+	}
+
+	applyISVFilter(data, config.License{Name: "rlm", ISVs: []string{"VENDOR2", "vendor3"}})
+	if len(data.features) != 2 || data.features["feature4"] == nil || data.features["feature7"] == nil {
+		t.Fatalf("Expected the features of vendor2 and vendor3, got %v", data.features)
+	}
+	if len(data.vendors) != 2 || data.vendors["vendor1"] != nil {
+		t.Fatalf("Expected vendor1 to be dropped, got %v", data.vendors)
+	}
+}
+
+func TestApplyISVFilterLmutil(t *testing.T) {
+	dataByte, err := os.ReadFile("fixtures/lmstat_app1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := quirksForVersion("v11.7").parseHuman(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.isvByFeature["feature1"] != "VENDOR1" {
+		t.Fatalf("Unexpected ISVs of the features %v", data.isvByFeature)
+	}
+
+	applyISVFilter(data, config.License{Name: "app1", ISVs: []string{"vendor2"}})
+	if data.features["feature1"] != nil || data.usersByFeature["feature1"] != nil || data.reservationsByFeature["feature1"] != nil {
+		t.Fatalf("Expected feature1 of VENDOR1 to be dropped, got %v", data.features)
+	}
+}
+
+func TestApplyISVFilterParseable(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseLmstatParseable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parseLmstatParseable(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// feature2 has no isv key, features of unknown ISVs are kept.
+	applyISVFilter(data, config.License{Name: "app1", ISVs: []string{"vendor2"}})
+	if data.features["feature1"] != nil || data.usersByFeature["feature1"] != nil || data.features["feature2"] == nil {
+		t.Fatalf("Expected only feature1 to be dropped, got %v", data.features)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var maxLabelLength = kingpin.Flag("metrics.max-label-length",
+	"Label values longer than this many bytes, like distinguished names used as user names, are cut and get a hash suffix. Zero disables the limit.").Default("256").Int()
+
+// labelHashLength is the length of the suffix of cut label values: a tilde
+// and the start of the SHA256 of the whole value, which keeps cut values
+// apart.
+const labelHashLength = 9
+
+// constMetric is prometheus.MustNewConstMetric with the label values made
+// safe by sanitizeLabel. Every metric of the collectors is built with it, as
+// label values often come straight from rlmstat output.
+func constMetric(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, labelValues ...string) prometheus.Metric {
+	max := *maxLabelLength
+	for i, v := range labelValues {
+		if labelSafe(v, max) {
+			continue
+		}
+		// Leave the caller's slice alone.
+		labelValues = append([]string(nil), labelValues...)
+		for j := i; j < len(labelValues); j++ {
+			labelValues[j] = sanitizeLabel(labelValues[j], max)
+		}
+		break
+	}
+	return prometheus.MustNewConstMetric(desc, valueType, value, labelValues...)
+}
+
+// sanitizeLabel returns v with invalid UTF-8 and control characters replaced
+// by U+FFFD, cut to at most max bytes including a hash suffix if it is
+// longer. Zero max doesn't limit the length.
+func sanitizeLabel(v string, max int) string {
+	if labelSafe(v, max) {
+		return v
+	}
+	orig := v
+	v = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return utf8.RuneError
+		}
+		return r
+	}, strings.ToValidUTF8(v, string(utf8.RuneError)))
+	if max <= 0 || len(v) <= max {
+		return v
+	}
+
+	sum := sha256.Sum256([]byte(orig))
+	suffix := "~" + hex.EncodeToString(sum[:])[:labelHashLength-1]
+	cut := max - len(suffix)
+	if cut < 0 {
+		return suffix[:max]
+	}
+	// Don't cut a character in half.
+	for cut > 0 && !utf8.RuneStart(v[cut]) {
+		cut--
+	}
+	return v[:cut] + suffix
+}
+
+// labelSafe reports whether v needs no sanitizing, without allocating.
+func labelSafe(v string, max int) bool {
+	if max > 0 && len(v) > max {
+		return false
+	}
+	for _, r := range v {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
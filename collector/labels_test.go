@@ -0,0 +1,68 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSanitizeLabel(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"user1", "user1"},
+		{"Jürgen", "Jürgen"},
+		{"user\x1b[31m1", "user�[31m1"},
+		{"host\xff1", "host�1"},
+	} {
+		if got := sanitizeLabel(tc.in, 32); got != tc.want {
+			t.Fatalf("Expected %q for %q, got %q", tc.want, tc.in, got)
+		}
+	}
+
+	dn := "CN=" + strings.Repeat("ä", 40) + ",OU=Engineering,DC=example,DC=com"
+	other := dn + "x"
+	got := sanitizeLabel(dn, 32)
+	if len(got) > 32 || !utf8.ValidString(got) || !strings.HasPrefix(got, "CN=ää") {
+		t.Fatalf("Unexpected cut value %q", got)
+	}
+	if got == sanitizeLabel(other, 32) {
+		t.Fatalf("Expected different values cut apart, got %q for both", got)
+	}
+	if got := sanitizeLabel(dn, 0); got != dn {
+		t.Fatalf("Expected no limit with 0, got %q", got)
+	}
+}
+
+func TestConstMetricSanitizes(t *testing.T) {
+	desc := prometheus.NewDesc("test", "Test.", []string{"user"}, nil)
+	labels := []string{"user\xff"}
+	m := constMetric(desc, prometheus.GaugeValue, 1, labels...)
+
+	var pb dto.Metric
+	if err := m.Write(&pb); err != nil {
+		t.Fatal(err)
+	}
+	if v := pb.GetLabel()[0].GetValue(); v != "user�" {
+		t.Fatalf("Unexpected label value %q", v)
+	}
+	if labels[0] != "user\xff" {
+		t.Fatal("Expected the label values passed in to be left alone")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	licenseFileMtimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "license_file", "mtime_seconds"),
+		"Last modification time of the license file of a license, in seconds since the epoch.",
+		[]string{"license_name"},
+		nil,
+	)
+	licenseFileInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "license_file", "info"),
+		"Path and SHA256 checksum of the license file of a license.",
+		[]string{"license_name", "path", "sha256"},
+		nil,
+	)
+)
+
+type licenseFileCollector struct {
+	config *config.Config
+	logger log.Logger
+}
+
+func init() {
+	registerCollector("license_file", false, NewLicenseFileCollector)
+}
+
+// NewLicenseFileCollector returns a collector exporting the modification
+// time and checksum of the configured license files.
+func NewLicenseFileCollector(cfg *config.Config, logger log.Logger) (Collector, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &licenseFileCollector{config: cfg, logger: logger}, nil
+}
+
+// Describe implements the Collector interface.
+func (c *licenseFileCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- licenseFileMtimeDesc
+	ch <- licenseFileInfoDesc
+}
+
+// Update implements the Collector interface. Files that can't be read are
+// left out, the lint collector reports them.
+func (c *licenseFileCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if c.config == nil {
+		return nil
+	}
+
+	for _, license := range c.config.Licenses {
+		if license.LicenseFile == "" {
+			continue
+		}
+		mtime, sum, err := readLicenseFileState(ctx, license.LicenseFile, license.Name)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "couldn't read license file", "license", license.Name, "path", license.LicenseFile, "err", err)
+			continue
+		}
+		ch <- constMetric(licenseFileMtimeDesc, prometheus.GaugeValue, mtime, license.Name)
+		ch <- constMetric(licenseFileInfoDesc, prometheus.GaugeValue, 1, license.Name, license.LicenseFile, sum)
+	}
+	return nil
+}
+
+// licenseFileState returns the modification time of path, in seconds since
+// the epoch, and the hex encoded SHA256 of its content.
+func licenseFileState(path string) (float64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, "", err
+	}
+	return float64(info.ModTime().UnixNano()) / 1e9, hex.EncodeToString(h.Sum(nil)), nil
+}
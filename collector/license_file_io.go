@@ -0,0 +1,108 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// licenseFileReadAttempts bounds the reads of a license file failing
+	// with a transient error.
+	licenseFileReadAttempts = 4
+	// licenseFileRetryDelay is the delay before the first retry, doubled
+	// before every further one.
+	licenseFileRetryDelay = 100 * time.Millisecond
+)
+
+var (
+	licenseFileReadErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "license_file", "read_errors_total"),
+		"rlmlm_exporter: Number of failed reads of the license file of a license, including reads retried after a transient error.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	licenseFileReadErrors = newLicenseCounter(licenseFileReadErrorsDesc)
+
+	// transientIOErrors are returned by network file systems like NFS while
+	// a mount recovers, and are worth retrying.
+	transientIOErrors = []error{syscall.ESTALE, syscall.EIO, syscall.EINTR, syscall.EAGAIN, syscall.ETIMEDOUT}
+)
+
+// readLicenseFileState is licenseFileState retrying transient errors with
+// backoff. Every failed read is counted for the licenses names.
+func readLicenseFileState(ctx context.Context, path string, names ...string) (mtime float64, sum string, err error) {
+	err = retryLicenseFileRead(ctx, names, func() error {
+		var err error
+		mtime, sum, err = licenseFileState(path)
+		return err
+	})
+	return mtime, sum, err
+}
+
+// openLicenseFile opens and closes the license file of license before
+// rlmstat reads it, retrying transient errors, which lets NFS clients
+// revalidate a stale file handle that would fail rlmstat.
+func openLicenseFile(ctx context.Context, license string, path string) error {
+	return retryLicenseFileRead(ctx, []string{license}, func() error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// retryLicenseFileRead calls read until it succeeds, fails with an error that
+// isn't transient, licenseFileReadAttempts are made or ctx is done.
+func retryLicenseFileRead(ctx context.Context, names []string, read func() error) error {
+	delay := licenseFileRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := read()
+		if err == nil {
+			return nil
+		}
+		for _, name := range names {
+			licenseFileReadErrors.inc(name)
+		}
+		if !isTransientIOError(err) || attempt == licenseFileReadAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+func isTransientIOError(err error) bool {
+	for _, transient := range transientIOErrors {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestRetryLicenseFileRead(t *testing.T) {
+	readErrors := func(license string) float64 {
+		licenseFileReadErrors.mu.Lock()
+		defer licenseFileReadErrors.mu.Unlock()
+		return licenseFileReadErrors.counts[license]
+	}
+
+	var reads int
+	err := retryLicenseFileRead(context.Background(), []string{"nfs1", "nfs2"}, func() error {
+		reads++
+		if reads < 3 {
+			return &fs.PathError{Op: "open", Path: "/mnt/licenses/app.lic", Err: syscall.ESTALE}
+		}
+		return nil
+	})
+	if err != nil || reads != 3 {
+		t.Fatalf("Expected success at the third read, got %d reads: %v", reads, err)
+	}
+	if readErrors("nfs1") != 2 || readErrors("nfs2") != 2 {
+		t.Fatalf("Expected 2 read errors per license, got %v and %v", readErrors("nfs1"), readErrors("nfs2"))
+	}
+
+	reads = 0
+	err = retryLicenseFileRead(context.Background(), []string{"missing"}, func() error {
+		reads++
+		return &fs.PathError{Op: "open", Path: "/mnt/licenses/missing.lic", Err: os.ErrNotExist}
+	})
+	if err == nil || reads != 1 {
+		t.Fatalf("Expected a single read of a missing file, got %d reads: %v", reads, err)
+	}
+	if readErrors("missing") != 1 {
+		t.Fatalf("Expected 1 read error, got %v", readErrors("missing"))
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestLicenseFileCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app1.lic")
+	if err := os.WriteFile(path, []byte("HOST host1 any 5053\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Licenses: []config.License{
+		{Name: "app1", LicenseFile: path},
+		{Name: "app2", LicenseFile: filepath.Join(t.TempDir(), "missing.lic")},
+		{Name: "app3", LicenseServer: "5053@host1"},
+	}}
+	c, err := NewLicenseFileCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err := c.Update(context.Background(), ch); err != nil {
+			t.Error(err)
+		}
+		close(ch)
+	}()
+
+	var metrics []*dto.Metric
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		switch m.Desc() {
+		case licenseFileMtimeDesc:
+			if v := pb.GetGauge().GetValue(); v != float64(mtime.Unix()) {
+				t.Fatalf("Unexpected modification time %v", v)
+			}
+		case licenseFileInfoDesc:
+			// Labels are sorted by name: license_name, path, sha256.
+			if sum := pb.GetLabel()[2].GetValue(); sum != "ad027df6ecdd9ffeef957d013009aa76b41ae1cdeed932b214ab8f9c35c4587b" {
+				t.Fatalf("Unexpected checksum %s", sum)
+			}
+		}
+		metrics = append(metrics, &pb)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("Expected the metrics of app1 only, got %d metrics", len(metrics))
+	}
+}
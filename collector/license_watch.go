@@ -0,0 +1,189 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+const (
+	// licenseWatchSettle lets an editor finish writing a file before it is
+	// read, so that a save triggers a single refresh.
+	licenseWatchSettle = 200 * time.Millisecond
+	// licenseWatchPollInterval is used where file system events aren't
+	// available.
+	licenseWatchPollInterval = 5 * time.Second
+)
+
+var (
+	cacheWatchLicenseFiles = kingpin.Flag("cache.watch-license-files",
+		"With --cache.interval, collect a license again as soon as its license_file changes on disk instead of at the next interval.").Default("true").Bool()
+
+	licenseFileReloadDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "license_file", "reload_total"),
+		"rlmlm_exporter: Number of times a license was collected again because its license file changed on disk.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	licenseFileReloads = newLicenseCounter(licenseFileReloadDesc)
+)
+
+// licenseCounter counts events by license, exported as desc.
+type licenseCounter struct {
+	desc   *prometheus.Desc
+	mu     sync.Mutex
+	counts map[string]float64
+}
+
+func newLicenseCounter(desc *prometheus.Desc) *licenseCounter {
+	return &licenseCounter{desc: desc, counts: make(map[string]float64)}
+}
+
+func (r *licenseCounter) inc(license string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[license]++
+}
+
+func (r *licenseCounter) collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for license, count := range r.counts {
+		ch <- constMetric(r.desc, prometheus.CounterValue, count, license)
+	}
+}
+
+// stampFile returns the checksum of path, the license file of names, which
+// tells apart files saved within the resolution of modification times, empty
+// if it can't be read.
+func stampFile(ctx context.Context, path string, names []string) string {
+	_, sum, err := readLicenseFileState(ctx, path, names...)
+	if err != nil {
+		return ""
+	}
+	return sum
+}
+
+// watchLicenseFiles calls changed with the name of every license of cfg
+// whose license file changes, until ctx is done.
+func watchLicenseFiles(ctx context.Context, cfg *config.Config, logger log.Logger, changed func(license string)) {
+	if cfg == nil || !*cacheWatchLicenseFiles {
+		return
+	}
+	licenses := make(map[string][]string)
+	for _, license := range cfg.Licenses {
+		if license.LicenseFile != "" {
+			licenses[license.LicenseFile] = append(licenses[license.LicenseFile], license.Name)
+		}
+	}
+	if len(licenses) == 0 {
+		return
+	}
+
+	stamps := make(map[string]string, len(licenses))
+	dirSet := make(map[string]bool)
+	for path, names := range licenses {
+		stamps[path] = stampFile(ctx, path, names)
+		// Directories are watched as editors replace files by renaming.
+		dirSet[filepath.Dir(path)] = true
+	}
+	dirs := make([]string, 0, len(dirSet))
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	events, err := watchDirs(ctx, dirs)
+	if err != nil {
+		level.Warn(logger).Log("msg", "couldn't watch license files, polling them", "interval", licenseWatchPollInterval, "err", err)
+		events = pollDirs(ctx, licenseWatchPollInterval)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(licenseWatchSettle):
+			}
+			// Drop the events of the same save.
+			for drained := false; !drained; {
+				select {
+				case _, ok := <-events:
+					if !ok {
+						return
+					}
+				default:
+					drained = true
+				}
+			}
+
+			for path, names := range licenses {
+				stamp := stampFile(ctx, path, names)
+				if stamp == stamps[path] {
+					continue
+				}
+				stamps[path] = stamp
+				for _, name := range names {
+					level.Info(logger).Log("msg", "license file changed, collecting license again", "license", name, "path", path)
+					licenseFileReloads.inc(name)
+					changed(name)
+				}
+			}
+		}
+	}()
+}
+
+// pollDirs signals every interval until ctx is done, for the changes to be
+// looked for.
+func pollDirs(ctx context.Context, interval time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				close(ch)
+				return
+			case <-ticker.C:
+				select {
+				case ch <- struct{}{}:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	return ch
+}
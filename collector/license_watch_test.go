@@ -0,0 +1,96 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// countingLicenseCollector signals every collection of a license.
+type countingLicenseCollector struct {
+	collected chan string
+}
+
+func (c *countingLicenseCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+func (c *countingLicenseCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c *countingLicenseCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error {
+	c.collected <- license.Name
+	return nil
+}
+
+func TestCacheWatchLicenseFiles(t *testing.T) {
+	old := *cacheWatchLicenseFiles
+	t.Cleanup(func() { *cacheWatchLicenseFiles = old })
+	*cacheWatchLicenseFiles = true
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "watched.lic")
+	if err := os.WriteFile(path, []byte("LICENSE vendor1 feature1 2018.12 permanent 10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloads := func() float64 {
+		licenseFileReloads.mu.Lock()
+		defer licenseFileReloads.mu.Unlock()
+		return licenseFileReloads.counts["watched"]
+	}
+	before := reloads()
+
+	counting := &countingLicenseCollector{collected: make(chan string, 10)}
+	nc := &RlmlmCollector{
+		Config:     &config.Config{Licenses: []config.License{{Name: "watched", LicenseFile: path}}},
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"counting": counting},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	NewCache(nc, time.Hour, 0, log.NewNopLogger()).Start(ctx)
+
+	expectCollection := func(step string) {
+		t.Helper()
+		select {
+		case <-counting.collected:
+		case <-time.After(2 * licenseWatchPollInterval):
+			t.Fatalf("%s: license not collected", step)
+		}
+	}
+	expectCollection("start")
+
+	// Replaced the way editors save files.
+	tmp := filepath.Join(dir, ".watched.lic.swp")
+	if err := os.WriteFile(tmp, []byte("LICENSE vendor1 feature1 2018.12 permanent 20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	expectCollection("rename")
+
+	if n := reloads() - before; n != 1 {
+		t.Fatalf("Expected 1 reload, got %v", n)
+	}
+}
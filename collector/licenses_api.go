@@ -0,0 +1,124 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// LicenseListOptions selects the page and the fields returned by ListLicenses.
+type LicenseListOptions struct {
+	Offset int
+	// Limit is the maximum number of licenses returned, 0 returns them all.
+	Limit int
+	// Features and Users select the fields to return. Licenses are only
+	// queried if either is set.
+	Features bool
+	Users    bool
+}
+
+// LicenseList is a page of licenses.
+type LicenseList struct {
+	// Total is the number of licenses across all pages.
+	Total    int             `json:"total"`
+	Offset   int             `json:"offset"`
+	Licenses []LicenseStatus `json:"licenses"`
+	// NextOffset is the offset of the next page, 0 on the last page.
+	NextOffset int `json:"next_offset,omitempty"`
+}
+
+// LicenseStatus is the current state of a license.
+type LicenseStatus struct {
+	License  string         `json:"license_name"`
+	Features []FeatureUsage `json:"features,omitempty"`
+	// Users maps the features to the users holding them and their number of
+	// licenses. It is only set if monitor_users is enabled for the license.
+	Users map[string]map[string]float64 `json:"users,omitempty"`
+	Error string                        `json:"error,omitempty"`
+}
+
+// FeatureUsage is the usage of a feature on a single license.
+type FeatureUsage struct {
+	Feature string  `json:"feature"`
+	Issued  float64 `json:"issued"`
+	Used    float64 `json:"used"`
+	Queued  float64 `json:"queued"`
+}
+
+// ListLicenses returns a page of the licenses of cfg, querying only the
+// licenses on the page.
+func ListLicenses(ctx context.Context, cfg *config.Config, logger log.Logger, opts LicenseListOptions) *LicenseList {
+	var licenses []config.License
+	if cfg != nil {
+		for _, license := range cfg.Licenses {
+			if licenseTarget(license) != "" {
+				licenses = append(licenses, license)
+			}
+		}
+	}
+
+	list := &LicenseList{Total: len(licenses), Offset: opts.Offset, Licenses: []LicenseStatus{}}
+	if opts.Offset >= len(licenses) {
+		return list
+	}
+	page := licenses[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(page) {
+		page = page[:opts.Limit]
+		list.NextOffset = opts.Offset + opts.Limit
+	}
+
+	ctx = withCommandCache(ctx)
+	lmstat := &LmstatCollector{config: cfg, logger: logger}
+	for _, license := range page {
+		status := LicenseStatus{License: license.Name}
+		if !opts.Features && !opts.Users {
+			list.Licenses = append(list.Licenses, status)
+			continue
+		}
+
+		data, _, err := lmstat.queryLicense(ctx, license, licenseTarget(license))
+		if err != nil {
+			status.Error = err.Error()
+			list.Licenses = append(list.Licenses, status)
+			continue
+		}
+		include, exclude := splitCSVList(license.FeaturesToInclude), splitCSVList(license.FeaturesToExclude)
+		names := make([]string, 0, len(data.features))
+		for name := range data.features {
+			if featureSelected(name, include, exclude) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if opts.Features {
+				f := data.features[name]
+				status.Features = append(status.Features, FeatureUsage{Feature: name, Issued: f.issued, Used: f.used, Queued: f.queued})
+			}
+			if opts.Users && license.MonitorUsers && len(data.usersByFeature[name]) > 0 {
+				if status.Users == nil {
+					status.Users = make(map[string]map[string]float64)
+				}
+				status.Users[name] = data.usersByFeature[name]
+			}
+		}
+		list.Licenses = append(list.Licenses, status)
+	}
+	return list
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+	"github.com/iambengiey/rlmlm_exporter/lint"
+)
+
+var lintIssuesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "lint", "issues_total"),
+	"Number of issues found in the license file of a license and the options files it references, by check.",
+	[]string{"license_name", "check"},
+	nil,
+)
+
+// lintChecks are always exported, so fixed issues drop back to zero.
+var lintChecks = []string{
+	lint.CheckUnreadable, lint.CheckSyntax, lint.CheckExpired,
+	lint.CheckHostIDMismatch, lint.CheckIncludeExclude, lint.CheckUnknownKeyword,
+}
+
+type lintCollector struct {
+	config *config.Config
+	logger log.Logger
+}
+
+func init() {
+	registerCollector("lint", false, NewLintCollector)
+}
+
+// NewLintCollector returns a collector linting the configured license files.
+func NewLintCollector(cfg *config.Config, logger log.Logger) (Collector, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &lintCollector{config: cfg, logger: logger}, nil
+}
+
+// Describe implements the Collector interface.
+func (c *lintCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lintIssuesDesc
+}
+
+// Update implements the Collector interface.
+func (c *lintCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if c.config == nil {
+		return nil
+	}
+
+	for name, issues := range LintLicenses(c.config, c.logger) {
+		counts := make(map[string]float64)
+		for _, issue := range issues {
+			counts[issue.Check]++
+		}
+		for _, check := range lintChecks {
+			ch <- constMetric(lintIssuesDesc, prometheus.GaugeValue, counts[check], name, check)
+		}
+	}
+	return nil
+}
+
+// LintLicenses lints the license file of every license that has one, by
+// license name.
+func LintLicenses(cfg *config.Config, logger log.Logger) map[string][]lint.Issue {
+	linter := lint.Linter{Now: time.Now()}
+	host, err := lint.LocalHost()
+	if err != nil {
+		level.Warn(logger).Log("msg", "couldn't identify the local host, skipping hostid checks", "err", err)
+	} else {
+		linter.Host = host
+	}
+
+	results := make(map[string][]lint.Issue)
+	for _, license := range cfg.Licenses {
+		if license.LicenseFile == "" {
+			continue
+		}
+		results[license.Name] = linter.LicenseFile(license.LicenseFile)
+	}
+	return results
+}
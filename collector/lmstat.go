@@ -1,12 +1,32 @@
-//go:build !linux && !windows
-// +build !linux,!windows
+// Copyright 2017 Mario Trangoni
+// Copyright 2015 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
 
 package collector
 
 import (
-	"io"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -15,24 +35,136 @@ import (
 	"github.com/iambengiey/rlmlm_exporter/config"
 )
 
+const (
+	notFound = "not found"
+
+	// Names of the parser paths reported by rlmlm_lmstat_parser_info.
+	parserHuman     = "human"
+	parserParseable = "parseable"
+)
+
 // The lmstat collector's metrics.
 var (
 	lmstatupDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, "lmstat", "up"),
-		"Is the lmstat output parseable.",
+		"Is the lmstat output parseable. Deprecated, use rlmlm_target_up.",
 		[]string{"license_name", "license_server"},
 		nil,
 	)
+	lmstatInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "lmstat", "info"),
+		"rlmstat version information labeled by arch, build and version.",
+		[]string{"arch", "build", "version"},
+		nil,
+	)
+	rlmUtilityVersionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rlm", "utility_version_info"),
+		"Detected rlmstat utility version labeled by version, build and the parser quirks in use.",
+		[]string{"version", "build", "quirks"},
+		nil,
+	)
+	lmstatParserDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "lmstat", "parser_info"),
+		"Which rlmstat output parser was used for a license, labeled by parser.",
+		[]string{"license_name", "parser"},
+		nil,
+	)
+	serverStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "status"),
+		"License server status labeled by license_name, fqdn, port, master and version.",
+		[]string{"license_name", "fqdn", "port", "master", "version"},
+		nil,
+	)
+	serverInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "info"),
+		"RLM version, build and platform of a license server, as it reports them.",
+		[]string{"license_name", "license_server", "rlm_version", "build", "platform"},
+		nil,
+	)
+	isvStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "isv", "status"),
+		"ISV daemon status labeled by license_name, isv and version.",
+		[]string{"license_name", "isv", "version"},
+		nil,
+	)
+	featureIssuedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "issued"),
+		"Number of issued licenses of a feature.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "used"),
+		"Number of licenses of a feature currently in use.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureUsedUsersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "used_users"),
+		"Number of licenses of a feature checked out by a user.",
+		[]string{"license_name", "feature", "user"},
+		nil,
+	)
+	featureTopUserSeatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "top_user_seats"),
+		"Number of licenses of a feature checked out by the user ranked rank by seats held.",
+		[]string{"license_name", "feature", "rank", "user"},
+		nil,
+	)
+	featureUsageInconsistentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "usage_inconsistent"),
+		"Whether the licenses of a feature in use differ from the sum of the checkouts listed per user, which hints at stuck checkouts.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureUniqueUsersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "unique_users"),
+		"Number of distinct users holding checkouts of a feature.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureUniqueHostsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "unique_hosts"),
+		"Number of distinct hosts holding checkouts of a feature.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureSoftLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "soft_limit"),
+		"Number of licenses of a feature that can be checked out before the soft limit is exceeded, for ISVs reporting it.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureOverdraftUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "overdraft_used"),
+		"Number of overdraft licenses of a feature in use, for ISVs reporting it.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+	featureReservedGroupsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "reserved_groups"),
+		"Number of licenses of a feature reserved for a group.",
+		[]string{"license_name", "feature", "group"},
+		nil,
+	)
+)
+
+var (
+	errUnparseableOutput = errors.New("no known records in rlmstat output")
+
+	// lmstatInfo caches the `rlmstat -v` result for lmstatInfoPath.
+	lmstatInfo     lmstatInformation
+	lmstatInfoPath string
+	lmstatInfoMu   sync.Mutex
 )
 
 // LmstatCollector implements the Collector interface.
 type LmstatCollector struct {
-	config *config.Config // Fixed: Changed from config.Configuration to *config.Config
-	logger log.Logger     // Added: Logger for go-kit/log
+	config *config.Config
+	logger log.Logger
 }
 
 // NewLmstatCollector creates a new LmstatCollector.
-// NOTE: This constructor now accepts config and logger, matching the updated factory signature in collector.go.
 func NewLmstatCollector(cfg *config.Config, logger log.Logger) (Collector, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -44,104 +176,698 @@ func NewLmstatCollector(cfg *config.Config, logger log.Logger) (Collector, error
 	}, nil
 }
 
+// Describe implements the Collector interface.
+func (c *LmstatCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lmstatupDesc
+	ch <- targetUpDesc
+	ch <- targetFailureReasonDesc
+	ch <- lmstatInfoDesc
+	ch <- rlmUtilityVersionDesc
+	ch <- lmstatParserDesc
+	ch <- serverStatusDesc
+	ch <- serverInfoDesc
+	ch <- serverDiscoveredPortDesc
+	ch <- serverFailoverActiveDesc
+	ch <- serverFailoverTransitionsDesc
+	ch <- isvStatusDesc
+	ch <- featureIssuedDesc
+	ch <- featureUsedDesc
+	ch <- featureUsedUsersDesc
+	ch <- featureTopUserSeatsDesc
+	ch <- featureUsageInconsistentDesc
+	ch <- featureUniqueUsersDesc
+	ch <- featureUniqueHostsDesc
+	ch <- featureSoftLimitDesc
+	ch <- featureOverdraftUsedDesc
+	ch <- featureReservedGroupsDesc
+	ch <- featureCheckoutEventsDesc
+	ch <- featureDailyPeakUsedDesc
+	ch <- productUsedDesc
+	ch <- productIssuedDesc
+	describeCustomMetrics(ch, c.config)
+	describeDerivedMetrics(ch, c.config)
+}
+
 // Update implements the Collector interface.
-func (c *LmstatCollector) Update(ch chan<- prometheus.Metric) error {
+func (c *LmstatCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if c.config == nil {
+		return nil
+	}
+
+	if err := c.UpdateGlobal(ctx, ch); err != nil {
+		return err
+	}
 	for _, license := range c.config.Licenses {
-		c.lmstatUpdate(ch, license)
+		if mutes.muted(license.Name) {
+			continue
+		}
+		// Failures are exported as rlmlm_lmstat_up and logged per license.
+		_ = c.UpdateLicense(ctx, ch, license)
 	}
 
 	return nil
 }
 
-// lmstatUpdate executes the rlmstat command and updates metrics for a single license.
-func (c *LmstatCollector) lmstatUpdate(ch chan<- prometheus.Metric, license config.License) {
-	level.Debug(c.logger).Log("msg", "Running rlmstat for license", "name", license.Name)
+// UpdateGlobal exports the rlmstat version information.
+func (c *LmstatCollector) UpdateGlobal(ctx context.Context, ch chan<- prometheus.Metric) error {
+	info := rlmstatVersion(ctx, c.logger)
+	ch <- constMetric(lmstatInfoDesc, prometheus.GaugeValue, 1,
+		info.arch, info.build, info.version)
+	ch <- constMetric(rlmUtilityVersionDesc, prometheus.GaugeValue, 1,
+		info.version, info.build, quirksForVersion(info.version).name)
+	return nil
+}
+
+// UpdateLicense executes the rlmstat command and updates metrics for a single license.
+func (c *LmstatCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) (err error) {
+	ctx, span := startLicenseSpan(ctx, "lmstat", license.Name)
+	defer func() {
+		endSpan(span, err)
+		collectionErrors.record("lmstat", license.Name, err)
+	}()
 
+	level.Debug(c.logger).Log("msg", "running rlmstat", "license", license.Name)
+
+	target := licenseTarget(license)
+	if target == "" {
+		level.Error(c.logger).Log("msg", "missing license_file or license_server in config", "license", license.Name)
+		ch <- constMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, "N/A")
+		ch <- constMetric(targetUpDesc, prometheus.GaugeValue, 0, license.Name, "N/A")
+		exportTargetFailure(ch, license.Name, targetFailureConfig)
+		return configError(fmt.Errorf("missing license_file or license_server for %s", license.Name))
+	}
+
+	if license.LicenseFile != "" {
+		if err := openLicenseFile(ctx, license.Name, license.LicenseFile); err != nil {
+			// rlmstat reports it too, in its own words.
+			level.Warn(c.logger).Log("msg", "couldn't read license file", "license", license.Name, "path", license.LicenseFile, "err", err)
+		}
+	}
+
+	data, parser, err := c.queryLicense(ctx, license, target)
+	if err != nil {
+		logger := level.Error(c.logger)
+		if errors.Is(err, errRlmstatUnavailable) {
+			// Already reported when the binary was looked up.
+			logger = level.Debug(c.logger)
+		}
+		logger.Log("msg", "rlmstat failed", "license", license.Name, "target", target, "err", err)
+		ch <- constMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, target)
+		ch <- constMetric(targetUpDesc, prometheus.GaugeValue, 0, license.Name, target)
+		reason := targetFailureUnknown
+		var tf *targetFailure
+		if errors.As(err, &tf) {
+			reason = tf.reason
+		}
+		exportTargetFailure(ch, license.Name, reason)
+		// The ISV may have moved the server to another port.
+		serverPorts.forget(license.LicenseServer)
+		return fmt.Errorf("rlmstat failed for %s: %w", license.Name, err)
+	}
+
+	ch <- constMetric(lmstatupDesc, prometheus.GaugeValue, 1, license.Name, target)
+	ch <- constMetric(targetUpDesc, prometheus.GaugeValue, 1, license.Name, target)
+	exportTargetFailure(ch, license.Name, "")
+	ch <- constMetric(lmstatParserDesc, prometheus.GaugeValue, 1, license.Name, parser)
+	exportDiscoveredPorts(ch, license)
+	c.exportLmstat(ch, license, data)
+	exportCustomMetrics(ch, c.logger, license, data.output)
+	exportDerivedMetrics(ch, c.logger, license, data)
+	return nil
+}
+
+// queryLicense runs rlmstat against target and returns the parsed data and
+// the name of the parser that produced it.
+func (c *LmstatCollector) queryLicense(ctx context.Context, license config.License, target string) (*lmstatData, string, error) {
 	var (
-		server string
-		args   = []string{"-a"} // Default args to show all features
+		data   *lmstatData
+		err    error
+		parser = parserHuman
+		quirks = quirksForVersion(rlmstatVersion(ctx, c.logger).version)
 	)
+	if license.Parser != "" {
+		parse, err := vendorParse(license.Parser)
+		if err != nil {
+			return nil, license.Parser, err
+		}
+		if data, err = c.runLmstat(ctx, license, parse, "-a", "-c", target); err != nil {
+			return nil, license.Parser, err
+		}
+		applyISVFilter(data, license)
+		applyFeatureAliases(data, license)
+		return data, license.Parser, nil
+	}
+	if quirks.parseable && !snapshotLicense(quirks, license) {
+		data, err = c.runLicenseStatus(ctx, license, parseLmstatParseable, "-a", "-c", target, "-dq")
+		if err == nil {
+			parser = parserParseable
+		} else {
+			level.Debug(c.logger).Log("msg", "parseable rlmstat output unavailable, falling back", "license", license.Name, "err", err)
+		}
+	}
+	if parser == parserHuman {
+		data, err = c.runLicenseStatus(ctx, license, quirks.parseHuman, "-a", "-c", target)
+	}
+	if err != nil {
+		return nil, parser, err
+	}
+	applyISVFilter(data, license)
+	applyFeatureAliases(data, license)
+	return data, parser, nil
+}
+
+// runLmstat runs rlmstat with args and hands its output to parse. Failures
+// are returned as a targetFailure.
+func (c *LmstatCollector) runLmstat(ctx context.Context, license config.License, parse func([]byte) (*lmstatData, error), args ...string) (*lmstatData, error) {
+	ctx, phases := withCommandPhases(ctx)
+	out, runErr := runRlmstatCommand(ctx, priorityStatus, license.Environ(), args...)
+	commandPhaseTimes.observe("lmstat", license.Name, *phases)
+	if runErr != nil {
+		// rlmstat often exits with a non-zero code on success (e.g. if no
+		// licenses are in use), so only give up when there is no output.
+		if len(out) == 0 {
+			var exitErr *exec.ExitError
+			if errors.As(runErr, &exitErr) {
+				if desc, ok := errorDescriptionString[exitErr.Error()]; ok {
+					level.Debug(c.logger).Log("msg", "rlmstat exit status", "license", license.Name, "description", desc)
+				}
+			}
+			return nil, &targetFailure{classifyTargetFailure(runErr, out), runErr}
+		}
+		level.Debug(c.logger).Log("msg", "rlmstat exited with error, parsing output anyway", "license", license.Name, "err", runErr)
+	}
+	start := time.Now()
+	data, err := parse(out)
+	parseDurations.observe(c.logger, "lmstat", license.Name, len(out), time.Since(start))
+	if err != nil {
+		captureParseFailure(c.logger, license.Name, args, out, err)
+		err = parseError(err)
+		// The exit code tells more than the output not being parseable.
+		reason := classifyTargetFailure(err, out)
+		if reason == targetFailureParse && runErr != nil {
+			reason = classifyTargetFailure(runErr, out)
+		}
+		return nil, &targetFailure{reason, err}
+	}
+	data.output = out
+	return data, nil
+}
+
+// commands implements commandLister.
+func (c *LmstatCollector) commands() []Command {
+	var cmds []Command
+	for i, args := range versionProbes {
+		cmd := rlmstatCommand("", args...)
+		cmd.Condition = "once per rlmstat path"
+		if i > 0 {
+			cmd.Condition = "once per rlmstat path, if the previous version probe failed"
+		}
+		cmds = append(cmds, cmd)
+	}
+	if c.config == nil {
+		return cmds
+	}
+
+	for _, license := range c.config.Licenses {
+		target := commandTarget(license)
+		if target == "" {
+			continue
+		}
+		if license.Parser != "" {
+			cmd := licenseRlmstatCommand(license, "-a", "-c", target)
+			cmd.Condition = fmt.Sprintf("parsed by the %s parser", license.Parser)
+			cmds = append(cmds, cmd)
+			continue
+		}
+		if license.QueryFeatures {
+			for _, feature := range license.QueriedFeatures() {
+				parseable := licenseRlmstatCommand(license, "-f", feature, "-c", target, "-dq")
+				parseable.Condition = "if rlmstat reports v12 or newer"
+				human := licenseRlmstatCommand(license, "-f", feature, "-c", target)
+				human.Condition = "if rlmstat is older than v12 or the -dq output is unusable"
+				cmds = append(cmds, parseable, human)
+			}
+			continue
+		}
+		if *rlmstatSnapshot {
+			cmds = append(cmds, licenseRlmstatCommand(license, "-a", "-c", target))
+			continue
+		}
+		parseable := licenseRlmstatCommand(license, "-a", "-c", target, "-dq")
+		parseable.Condition = "if rlmstat reports v12 or newer"
+		human := licenseRlmstatCommand(license, "-a", "-c", target)
+		human.Condition = "if rlmstat is older than v12 or the -dq output is unusable"
+		cmds = append(cmds, parseable, human)
+	}
+	return cmds
+}
+
+// exportLmstat sends the parsed rlmstat data of a license to ch.
+func (c *LmstatCollector) exportLmstat(ch chan<- prometheus.Metric, license config.License, data *lmstatData) {
+	for _, s := range data.servers {
+		ch <- constMetric(serverStatusDesc, prometheus.GaugeValue, boolToFloat64(s.status),
+			license.Name, s.fqdn, s.port, strconv.FormatBool(s.master), s.version)
+	}
+	exportFailover(ch, license.Name, data.servers)
+	for _, b := range data.banners {
+		if b.version != "" {
+			ch <- constMetric(serverInfoDesc, prometheus.GaugeValue, 1, license.Name, b.server, b.version, b.build, b.platform)
+		}
+	}
+	isvContacts.observe(data.vendors, time.Now())
+	for name, v := range data.vendors {
+		ch <- constMetric(isvStatusDesc, prometheus.GaugeValue, boolToFloat64(v.status),
+			license.Name, name, v.version)
+	}
+
+	include := splitCSVList(license.FeaturesToInclude)
+	exclude := splitCSVList(license.FeaturesToExclude)
+	// Outputs without any checkout listed can't be compared.
+	listsUsers := len(data.usersByFeature) > 0
+	loc, err := license.Location()
+	if err != nil {
+		// Reported by the expiration check.
+		loc = time.UTC
+	}
+	exported := make(map[string]bool, len(data.features))
+	totals := newProductTotals()
+	for name, f := range data.features {
+		if !featureSelected(name, include, exclude) {
+			continue
+		}
+		exported[name] = true
+		totals.add(name, f)
+		ch <- constMetric(featureIssuedDesc, prometheus.GaugeValue, f.issued, license.Name, name)
+		ch <- constMetric(featureUsedDesc, prometheus.GaugeValue, f.used, license.Name, name)
+		if f.hasSoftLimit {
+			ch <- constMetric(featureSoftLimitDesc, prometheus.GaugeValue, f.softLimit, license.Name, name)
+		}
+		if f.hasOverdraft {
+			ch <- constMetric(featureOverdraftUsedDesc, prometheus.GaugeValue, f.overdraft, license.Name, name)
+		}
+		ch <- constMetric(featureDailyPeakUsedDesc, prometheus.GaugeValue,
+			dailyPeaks.observe(license.Name, name, f.used, loc), license.Name, name)
+		usageHistory.observe(license.Name, name, f.used, f.issued)
+		if c.config != nil && c.config.Report != nil {
+			reportPeaks.observe(license.Name, name, f.used)
+		}
+		ch <- constMetric(featureCheckoutEventsDesc, prometheus.CounterValue,
+			checkouts.observe(license.Name, name, f.used, data.usersByFeature[name]), license.Name, name)
+		if license.MonitorUsers {
+			for user, used := range data.usersByFeature[name] {
+				ch <- constMetric(featureUsedUsersDesc, prometheus.GaugeValue, used, license.Name, name, user)
+			}
+		}
+		if listsUsers {
+			ch <- constMetric(featureUsageInconsistentDesc, prometheus.GaugeValue,
+				boolToFloat64(usageInconsistent(f.used, data.usersByFeature[name])), license.Name, name)
+			ch <- constMetric(featureUniqueUsersDesc, prometheus.GaugeValue,
+				float64(len(data.usersByFeature[name])), license.Name, name)
+			ch <- constMetric(featureUniqueHostsDesc, prometheus.GaugeValue,
+				float64(len(data.hostsByFeature[name])), license.Name, name)
+		}
+		for i, user := range topUsers(data.usersByFeature[name], license.TopUsers) {
+			ch <- constMetric(featureTopUserSeatsDesc, prometheus.GaugeValue,
+				data.usersByFeature[name][user], license.Name, name, strconv.Itoa(i+1), user)
+		}
+		if license.MonitorReservations {
+			for group, reserved := range data.reservationsByFeature[name] {
+				ch <- constMetric(featureReservedGroupsDesc, prometheus.GaugeValue, reserved, license.Name, name, group)
+			}
+		}
+	}
+	totals.export(ch, license.Name)
+	featureChurn.observe(license.Name, exported)
+	if err := featureSeen.observe(license.Name, exported, time.Now()); err != nil {
+		level.Warn(c.logger).Log("msg", "couldn't record the features seen", "license", license.Name, "err", err)
+	}
+	checkoutStarts.observe(license.Name, data.usersByFeature, exported, time.Now())
+	exhaustion.observe(c.logger, license, data, exported, time.Now())
+	if c.config != nil {
+		if err := billing.observe(c.config.Billing, license.Name, data, exported, time.Now()); err != nil {
+			level.Warn(c.logger).Log("msg", "couldn't bill seat-hours", "license", license.Name, "err", err)
+		}
+	}
+}
+
+// licenseTarget returns the value passed to `rlmstat -c` for a license, or an
+// empty string if neither license_file nor license_server is configured.
+// license_server entries without a port get the port discovered for them.
+func licenseTarget(license config.License) string {
+	if license.LicenseFile != "" {
+		return license.LicenseFile
+	}
+	return serverPorts.target(license.LicenseServer)
+}
 
-	// Determine the target server/file based on configuration
+// commandTarget is licenseTarget without probing the ports of the
+// license_server entries without one, for listing the commands.
+func commandTarget(license config.License) string {
 	if license.LicenseFile != "" {
-		server = license.LicenseFile
-		args = append(args, "-c", server)
-	} else if license.LicenseServer != "" {
-		server = license.LicenseServer
-		args = append(args, "-c", server)
-	} else {
-		// Log error using go-kit/log format
-		level.Error(c.logger).Log(
-			"msg", "Missing license_file or license_server in config",
-			"license", license.Name,
-		)
-		ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, "N/A")
-		return
-	}
-
-	cmd := exec.Command("rlmstat", args...)
-	stdout, err := cmd.StdoutPipe()
+		return license.LicenseFile
+	}
+	return serverPorts.cachedTarget(license.LicenseServer)
+}
+
+// rlmstatVersion returns the version of the configured rlmstat binary, trying
+// each of versionProbes in turn. The result is cached per binary path; failed
+// probes are retried next time.
+func rlmstatVersion(ctx context.Context, logger log.Logger) lmstatInformation {
+	lmstatInfoMu.Lock()
+	defer lmstatInfoMu.Unlock()
+
+	if lmstatInfoPath == *rlmstatPath {
+		return lmstatInfo
+	}
+
+	for _, args := range versionProbes {
+		out, err := runRlmstatCommand(ctx, priorityStatus, nil, args...)
+		if err != nil && len(out) == 0 {
+			level.Debug(logger).Log("msg", "rlmstat version probe failed", "path", *rlmstatPath,
+				"args", strings.Join(args, " "), "err", err)
+			continue
+		}
+		dataStr, err := splitOutput(out)
+		if err != nil {
+			continue
+		}
+		info := parseLmstatVersion(dataStr)
+		if info.version == notFound {
+			continue
+		}
+
+		lmstatInfo = info
+		lmstatInfoPath = *rlmstatPath
+		level.Debug(logger).Log("msg", "detected rlmstat version", "version", info.version,
+			"quirks", quirksForVersion(info.version).name)
+		return lmstatInfo
+	}
+	return lmstatInformation{arch: notFound, build: notFound, version: notFound}
+}
+
+// versionMajor returns the major number of a version like "v12.3.1", or -1.
+func versionMajor(version string) int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.Index(version, "."); i >= 0 {
+		version = version[:i]
+	}
+	major, err := strconv.Atoi(version)
 	if err != nil {
-		// Log error using go-kit/log format
-		level.Error(c.logger).Log(
-			"msg", "Failed to create stdout pipe for rlmstat",
-			"license", license.Name,
-			"err", err,
-		)
-		ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, server)
-		return
-	}
-
-	if err := cmd.Start(); err != nil {
-		// Log error using go-kit/log format
-		level.Error(c.logger).Log(
-			"msg", "Failed to start rlmstat command",
-			"license", license.Name,
-			"cmd", "rlmstat "+strings.Join(args, " "),
-			"err", err,
-		)
-		ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, server)
-		return
-	}
-
-	// Read and process the output
-	rlmstatOutput, err := io.ReadAll(stdout)
+		return -1
+	}
+	return major
+}
+
+// splitOutput splits rlmstat output into one single-field record per line,
+// numbering duplicated lines so they stay distinguishable.
+func splitOutput(raw []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.Comma = 'Ž'
+	r.LazyQuotes = true
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
 	if err != nil {
-		level.Error(c.logger).Log("msg", "Failed to read rlmstat output", "license", license.Name, "err", err)
-		cmd.Wait() // Ensure the command is waited on even if reading failed
-		ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, server)
-		return
+		return nil, err
 	}
 
-	if err := cmd.Wait(); err != nil {
-		// rlmstat often exits with a non-zero code on success (e.g., if no licenses are in use),
-		// but we still want to parse the output if we got any.
-		if len(rlmstatOutput) == 0 {
-			level.Error(c.logger).Log(
-				"msg", "rlmstat command failed with no output",
-				"license", license.Name,
-				"err", err,
-			)
-			ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 0, license.Name, server)
-			return
+	filtered := make([][]string, 0, len(records))
+	seen := make(map[string]int)
+	for _, row := range records {
+		if len(row) == 0 {
+			continue
+		}
+		key := row[0]
+		if count, ok := seen[key]; ok {
+			seen[key] = count + 1
+			row[0] = strings.TrimSpace(row[0]) + strconv.Itoa(seen[key])
+		} else {
+			seen[key] = 1
 		}
+		filtered = append(filtered, row)
 	}
+	return filtered, nil
+}
 
-	// Processing logic goes here...
-	// For simplicity, we assume successful parsing if we got output.
-	// A more robust implementation would check for specific error messages in the output.
+func parseLmstatVersion(outStr [][]string) lmstatInformation {
+	info := lmstatInformation{arch: notFound, build: notFound, version: notFound}
+	for _, line := range outStr {
+		lineJoined := strings.Join(line, "")
+		if matches := lmutilVersionRegex.FindStringSubmatch(lineJoined); matches != nil {
+			info.version = matches[1]
+			info.build = matches[2]
+			info.arch = matches[3]
+		} else if matches := rlmVersionRegex.FindStringSubmatch(lineJoined); matches != nil {
+			info.version = matches[1]
+			info.build = matches[2]
+		}
+	}
+	return info
+}
 
-	ch <- prometheus.MustNewConstMetric(lmstatupDesc, prometheus.GaugeValue, 1, license.Name, server)
+func parseLmstatLicenseInfoServer(outStr [][]string) map[string]*server {
+	servers := make(map[string]*server)
+	for _, line := range outStr {
+		lineJoined := strings.Join(line, "")
+		if matches := lmutilLicenseServersRegex.FindStringSubmatch(lineJoined); matches != nil {
+			for _, s := range strings.Split(matches[1], ",") {
+				port, host, ok := strings.Cut(s, "@")
+				if !ok {
+					continue
+				}
+				fqdn := config.ServerHost(host)
+				servers[fqdn] = &server{fqdn: fqdn, port: port}
+			}
+		} else if matches := lmutilLicenseServerStatusRegex.FindStringSubmatch(lineJoined); matches != nil {
+			fqdn := config.ServerHost(matches[1])
+			s, ok := servers[fqdn]
+			if !ok {
+				s = &server{fqdn: fqdn}
+				servers[fqdn] = s
+			}
+			s.status = matches[2] == upString
+			s.master = matches[3] != ""
+			s.version = matches[4]
+		}
+	}
+	return servers
+}
 
-	// Here you would continue with the parsing logic, converting rlmstatOutput to metrics...
+// parseServerBanners returns the banners of the RLM servers in the human
+// readable output, with the version and platform lines following their status
+// line.
+func parseServerBanners(outStr [][]string) []serverBanner {
+	var banners []serverBanner
+	for _, line := range outStr {
+		lineJoined := strings.Join(line, "")
+		if matches := rlmServerStatusRegex.FindStringSubmatch(lineJoined); matches != nil {
+			banners = append(banners, serverBanner{server: matches[2] + "@" + config.ServerHost(matches[1])})
+			continue
+		}
+		if len(banners) == 0 {
+			continue
+		}
+		b := &banners[len(banners)-1]
+		if matches := rlmServerVersionRegex.FindStringSubmatch(lineJoined); matches != nil {
+			b.version, b.build = matches[1], matches[2]
+		} else if matches := rlmServerPlatformRegex.FindStringSubmatch(lineJoined); matches != nil {
+			b.platform = matches[1]
+		}
+	}
+	return banners
+}
+
+// parseFeatureISVs returns the ISV of the features of the "Users of" sections
+// naming their vendor and of the RLM "license pool status" blocks.
+func parseFeatureISVs(outStr [][]string) map[string]string {
+	var (
+		isvs        = make(map[string]string)
+		featureName string
+		pool        string
+	)
+	for _, line := range outStr {
+		lineJoined := strings.Join(line, "")
+		if matches := lmutilLicenseFeatureUsageRegex.FindStringSubmatch(lineJoined); matches != nil {
+			featureName, pool = matches[1], ""
+			continue
+		}
+		if matches := rlmPoolStatusRegex.FindStringSubmatch(lineJoined); matches != nil {
+			featureName, pool = "", matches[1]
+			continue
+		}
+		if featureName != "" {
+			if matches := lmutilLicenseFeatureVendorRegex.FindStringSubmatch(lineJoined); matches != nil {
+				if _, ok := isvs[featureName]; !ok {
+					isvs[featureName] = matches[2]
+				}
+			}
+			continue
+		}
+		if pool != "" {
+			if matches := rlmPoolFeatureRegex.FindStringSubmatch(lineJoined); matches != nil {
+				isvs[matches[1]] = pool
+			}
+		}
+	}
+	return isvs
+}
 
-	// Example parsing placeholder (replace with actual parsing):
-	c.parseLmstatOutput(ch, license, server, string(rlmstatOutput))
+func parseLmstatLicenseInfoVendor(outStr [][]string) map[string]*vendor {
+	vendors := make(map[string]*vendor)
+	for _, line := range outStr {
+		matches := lmutilLicenseVendorStatusRegex.FindStringSubmatch(strings.Join(line, ""))
+		if matches == nil {
+			continue
+		}
+		vendors[matches[1]] = &vendor{status: matches[2] == upString, version: matches[3]}
+	}
+	return vendors
 }
 
-// Placeholder for the actual parsing logic
-func (c *LmstatCollector) parseLmstatOutput(ch chan<- prometheus.Metric, license config.License, server, output string) {
-	level.Debug(c.logger).Log("msg", "Placeholder for rlmstat output parsing", "license", license.Name, "output_length", len(output))
+func parseLmstatLicenseInfoFeature(outStr [][]string) (map[string]*feature,
+	map[string]map[string]float64, map[string]map[string]float64, map[string]map[string]float64, map[string][]checkout) {
+	var (
+		featureName       string
+		features          = make(map[string]*feature)
+		licUsersByFeature = make(map[string]map[string]float64)
+		licHostsByFeature = make(map[string]map[string]float64)
+		reservGroupByFeat = make(map[string]map[string]float64)
+		checkoutsByFeat   = make(map[string][]checkout)
+		userRegexes       = []*regexp.Regexp{lmutilLicenseFeatureUsageUserRegex, lmutilLicenseFeatureUsageUser2Regex}
+	)
+
+	for _, line := range outStr {
+		lineJoined := strings.Join(line, "")
+		if matches := lmutilLicenseFeatureUsageRegex.FindStringSubmatch(lineJoined); matches != nil {
+			featureName = matches[1]
+			issued, err := strconv.ParseFloat(matches[2], 64)
+			if err != nil {
+				continue
+			}
+			used, err := strconv.ParseFloat(matches[3], 64)
+			if err != nil {
+				continue
+			}
+			features[featureName] = &feature{issued: issued, used: used}
+			continue
+		}
+		if featureName == "" {
+			continue
+		}
+		if matches := lmutilLicenseFeatureGroupReservRegex.FindStringSubmatch(lineJoined); matches != nil {
+			reserved, err := strconv.ParseFloat(matches[lmutilLicenseFeatureGroupReservRegex.SubexpIndex("reservation")], 64)
+			if err != nil {
+				continue
+			}
+			group := matches[lmutilLicenseFeatureGroupReservRegex.SubexpIndex("group")]
+			if reservGroupByFeat[featureName] == nil {
+				reservGroupByFeat[featureName] = make(map[string]float64)
+			}
+			reservGroupByFeat[featureName][group] += reserved
+			continue
+		}
+		if matches := lmutilLicenseFeatureQueuedRegex.FindStringSubmatch(lineJoined); matches != nil {
+			if queued, err := strconv.ParseFloat(matches[lmutilLicenseFeatureQueuedRegex.SubexpIndex("queued")], 64); err == nil {
+				if f, ok := features[featureName]; ok {
+					f.queued += queued
+				}
+			}
+			continue
+		}
+		for _, re := range userRegexes {
+			matches := re.FindStringSubmatch(lineJoined)
+			if matches == nil {
+				continue
+			}
+			// The first regex also matches lines without a display
+			// column by capturing indentation as the user, skip those.
+			user := strings.TrimSpace(matches[re.SubexpIndex("user")])
+			if user == "" {
+				continue
+			}
+			used := 1.0
+			if licenses := matches[re.SubexpIndex("licenses")]; licenses != "" {
+				if n, err := strconv.ParseFloat(licenses, 64); err == nil {
+					used = n
+				}
+			}
+			addToNested(licUsersByFeature, featureName, user, used)
+			addToNested(licHostsByFeature, featureName, matches[re.SubexpIndex("host")], used)
+			checkoutsByFeat[featureName] = append(checkoutsByFeat[featureName], checkout{user, matches[re.SubexpIndex("host")], used})
+			break
+		}
+	}
+	return features, licUsersByFeature, licHostsByFeature, reservGroupByFeat, checkoutsByFeat
+}
+
+// usageInconsistent reports whether used differs from the sum of the
+// checkouts of users.
+func usageInconsistent(used float64, users map[string]float64) bool {
+	var sum float64
+	for _, n := range users {
+		sum += n
+	}
+	return sum != used
+}
+
+// topUsers returns the n users holding the most seats, by name on ties.
+func topUsers(users map[string]float64, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	names := make([]string, 0, len(users))
+	for user := range users {
+		names = append(names, user)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if users[names[i]] != users[names[j]] {
+			return users[names[i]] > users[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+// featureSelected applies the features_to_include/features_to_exclude lists.
+func featureSelected(name string, include, exclude []string) bool {
+	if len(include) > 0 {
+		return contains(include, name)
+	}
+	return !contains(exclude, name)
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func splitCSVList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		trimmed := strings.TrimSpace(p)
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+func contains(slice []string, item string) bool {
+	for _, v := range slice {
+		if v == item {
+			return true
+		}
+	}
+	return false
 }
 
 // init registers the collector.
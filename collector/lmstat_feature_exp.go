@@ -17,7 +17,9 @@
 package collector
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -25,6 +27,46 @@ import (
 	"github.com/iambengiey/rlmlm_exporter/config"
 )
 
+var (
+	featureExpUnparseableDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "expiration_unparseable"),
+		"License lines whose expiration date couldn't be parsed, labeled by the raw date.",
+		[]string{"license_name", "feature", "index", "expires"},
+		nil,
+	)
+
+	featureLineExpirationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "line_expiration_seconds"),
+		"Expiration date of a single license line of a feature, labeled by its position and seat count.",
+		[]string{"license_name", "feature", "index", "count"},
+		nil,
+	)
+
+	licenseEarliestExpirationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "license", "earliest_expiration_seconds"),
+		"Earliest expiration date across all exported feature lines of a license, +Inf if all are permanent.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	featureExpirationIgnoredDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "expiration_ignored"),
+		"Features configured with ignore_expiration, left out of the earliest expiration of their license.",
+		[]string{"license_name", "feature"},
+		nil,
+	)
+
+	featureVersionInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "feature", "version_info"),
+		"Versions of a feature covered by the license lines, labeled by ISV.",
+		[]string{"license_name", "feature", "version", "isv"},
+		nil,
+	)
+
+	// timeNow is replaced in tests to pin "today" and "tomorrow".
+	timeNow = time.Now
+)
+
 type lmstatFeatureExpCollector struct {
 	config           *config.Config
 	logger           log.Logger
@@ -49,7 +91,7 @@ func NewLmstatFeatureExpCollector(cfg *config.Config, logger log.Logger) (Collec
 		lmstatFeatureExp: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "feature",
 				"expiration_seconds"),
-			"License feature expiration date in seconds labeled by app, name, index, licenses, vendor, version.",
+			"License feature expiration date in seconds labeled by app, name, index, licenses, vendor, version. Deprecated, use rlmlm_feature_line_expiration_seconds and rlmlm_feature_version_info.",
 			[]string{"app", "name", "index", "licenses", "vendor",
 				"version"}, nil,
 		),
@@ -58,10 +100,27 @@ func NewLmstatFeatureExpCollector(cfg *config.Config, logger log.Logger) (Collec
 
 // Update calls (*lmstatFeatureExpCollector).getLmstatFeatureExpDate to get the
 // platform specific memory metrics.
-func (c *lmstatFeatureExpCollector) Update(ch chan<- prometheus.Metric) error {
-	err := c.getLmstatFeatureExpDate(ch)
+func (c *lmstatFeatureExpCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := c.getLmstatFeatureExpDate(ctx, ch)
 	if err != nil {
-		return fmt.Errorf("couldn't get licenses feature expiration date: %s", err)
+		return fmt.Errorf("couldn't get licenses feature expiration date: %w", err)
 	}
 	return nil
 }
+
+// Describe implements the Collector interface.
+func (c *lmstatFeatureExpCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lmstatFeatureExp
+	ch <- featureExpUnparseableDesc
+	ch <- featureLineExpirationDesc
+	ch <- licenseEarliestExpirationDesc
+	ch <- featureExpirationIgnoredDesc
+	ch <- featureVersionInfoDesc
+	ch <- serverErrorDesc
+}
+
+// queryFeatureExpirations returns the license lines of license from `rlmstat -i`.
+func queryFeatureExpirations(ctx context.Context, cfg *config.Config, logger log.Logger, license config.License, target string) (map[int]*featureExp, error) {
+	c := &lmstatFeatureExpCollector{config: cfg, logger: logger}
+	return c.queryFeatureExp(ctx, license, target)
+}
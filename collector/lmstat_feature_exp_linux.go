@@ -4,13 +4,10 @@
 package collector
 
 import (
-	"bytes"
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
 	"math"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -22,166 +19,285 @@ import (
 )
 
 // getLmstatFeatureExpDate fetches and exposes feature expiration data for each configured license.
-func (c *lmstatFeatureExpCollector) getLmstatFeatureExpDate(ch chan<- prometheus.Metric) error {
+func (c *lmstatFeatureExpCollector) getLmstatFeatureExpDate(ctx context.Context, ch chan<- prometheus.Metric) error {
 	if c.config == nil {
 		return nil
 	}
+	if err := rlmstatAvailable(); err != nil {
+		return err
+	}
 
 	var firstErr error
 	for _, license := range c.config.Licenses {
-		if err := c.collectFeatureExpForLicense(ch, license); err != nil && firstErr == nil {
+		if mutes.muted(license.Name) {
+			continue
+		}
+		if err := c.collectFeatureExpForLicense(ctx, ch, license); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
 	return firstErr
 }
 
-// lmstatFeatureExpUpdate executes the rlmstat command to get expiration information.
-func (c *LmstatFeatureExpCollector) lmstatFeatureExpUpdate(ch chan<- prometheus.Metric, license config.License) {
-	level.Debug(c.logger).Log("msg", "Running rlmstat for feature expiration", "name", license.Name)
+// UpdateLicense exports the feature expiration dates of a single license.
+func (c *lmstatFeatureExpCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error {
+	if err := rlmstatAvailable(); err != nil {
+		return err
+	}
+	return c.collectFeatureExpForLicense(ctx, ch, license)
+}
+
+// collectFeatureExpForLicense executes `rlmstat -i` for a single license and
+// exports the expiration date of every license line.
+func (c *lmstatFeatureExpCollector) collectFeatureExpForLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) (err error) {
+	ctx, span := startLicenseSpan(ctx, "lmstat_feature_exp", license.Name)
+	defer func() {
+		endSpan(span, err)
+		collectionErrors.record("lmstat_feature_exp", license.Name, err)
+	}()
+
+	level.Debug(c.logger).Log("msg", "running rlmstat for feature expiration", "license", license.Name)
 
 	if license.FeaturesToExclude != "" && license.FeaturesToInclude != "" {
 		err := fmt.Errorf("features_to_include and features_to_exclude are both set for %s", license.Name)
 		level.Error(c.logger).Log("msg", "invalid feature filter configuration", "license", license.Name, "err", err)
-		return err
+		return configError(err)
 	}
 
-	args := []string{"-i"}
-	target := license.LicenseServer
-	if license.LicenseFile != "" {
-		target = license.LicenseFile
+	target := licenseTarget(license)
+	if target == "" {
+		return configError(fmt.Errorf("missing license_file or license_server for %s", license.Name))
 	}
-	args = append(args, "-c", target)
 
-	cmd := exec.Command("rlmstat", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		// FIX: Replaced undefined log.Errorf with go-kit/log
-		level.Error(c.logger).Log(
-			"msg", "Failed to create stdout pipe for rlmstat exp",
-			"license", license.Name,
-			"err", err,
-		)
-		return
-	}
-
-	if err := cmd.Start(); err != nil {
-		// FIX: Replaced undefined log.Errorf with go-kit/log
-		level.Error(c.logger).Log(
-			"msg", "Failed to start rlmstat exp command",
-			"license", license.Name,
-			"cmd", "rlmstat "+strings.Join(args, " "),
-			"err", err,
-		)
-		return
-	}
-
-	rlmstatOutput, err := io.ReadAll(stdout)
+	featuresExp, err := c.queryFeatureExp(ctx, license, target)
 	if err != nil {
-		// FIX: Replaced undefined log.Errorln with go-kit/log
-		level.Error(c.logger).Log("msg", "Failed to read rlmstat exp output", "license", license.Name, "err", err)
-		cmd.Wait()
-		return
+		var serr *serverError
+		if errors.As(err, &serr) {
+			exportServerError(ch, license.Name, serr.code)
+		}
+		return err
 	}
+	exportServerError(ch, license.Name, "")
 
-	if err := cmd.Wait(); err != nil {
-		// This block is often where a log.Fatalf/Fatalln was used.
-		// Since collectors shouldn't crash the main process, we log an error and return.
-
-		// FIX: Replaced undefined log.Fatalf/Fatalln with level.Error and return
-		if strings.Contains(string(rlmstatOutput), "License server status: Error") {
-			level.Error(c.logger).Log(
-				"msg", "License server error during expiration check (rlmstat -i)",
-				"license", license.Name,
-				"err", err,
-			)
-			return
+	include := splitCSVList(license.FeaturesToInclude)
+	exclude := splitCSVList(license.FeaturesToExclude)
+	var (
+		earliest = math.Inf(1)
+		lines    int
+		ignored  = make(map[string]bool)
+		versions = make(map[[3]string]bool)
+	)
+	for index, f := range featuresExp {
+		if !featureSelected(f.name, include, exclude) || !license.SelectsISV(f.vendor) {
+			continue
 		}
-
-		level.Error(c.logger).Log(
-			"msg", "rlmstat exp command failed with error",
-			"license", license.Name,
-			"err", err,
-		)
+		ignore := license.IgnoresExpiration(f.name)
+		if ignore && !ignored[f.name] {
+			ignored[f.name] = true
+			ch <- constMetric(featureExpirationIgnoredDesc, prometheus.GaugeValue, 1, license.Name, f.name)
+		}
+		// Several lines of a feature often cover the same version.
+		if key := [3]string{f.name, f.version, f.vendor}; !versions[key] {
+			versions[key] = true
+			ch <- constMetric(featureVersionInfoDesc, prometheus.GaugeValue, 1, license.Name, f.name, f.version, f.vendor)
+		}
+		if !f.parsed {
+			level.Warn(c.logger).Log("msg", "couldn't parse expiration date", "license", license.Name, "feature", f.name, "expires", f.rawExpires)
+			ch <- constMetric(featureExpUnparseableDesc, prometheus.GaugeValue, 1,
+				license.Name, f.name, strconv.Itoa(index), f.rawExpires)
+			continue
+		}
+		ch <- constMetric(c.lmstatFeatureExp, prometheus.GaugeValue, f.expires,
+			license.Name, f.name, strconv.Itoa(index), f.licenses, f.vendor, f.version)
+		ch <- constMetric(featureLineExpirationDesc, prometheus.GaugeValue, f.expires,
+			license.Name, f.name, strconv.Itoa(index), f.licenses)
+		if ignore {
+			continue
+		}
+		earliest = math.Min(earliest, f.expires)
+		lines++
+	}
+	if lines > 0 {
+		ch <- constMetric(licenseEarliestExpirationDesc, prometheus.GaugeValue, earliest, license.Name)
 	}
-
 	return nil
 }
 
-func runRlmstatCommand(args ...string) ([]byte, error) {
-	cmd := exec.Command("rlmstat", args...)
-	cmd.Env = append(os.Environ(), "LANG=C")
-
-	out, err := cmd.Output()
+// queryFeatureExp runs `rlmstat -i` against target and returns the parsed
+// license lines. With --rlmstat.snapshot they are read from the license pools
+// of the `rlmstat -a` run of the lmstat collector instead.
+func (c *lmstatFeatureExpCollector) queryFeatureExp(ctx context.Context, license config.License, target string) (map[int]*featureExp, error) {
+	if snapshotLicense(quirksForVersion(rlmstatVersion(ctx, c.logger).version), license) {
+		return c.querySnapshotFeatureExp(ctx, license, target)
+	}
+	ctx, phases := withCommandPhases(ctx)
+	out, err := runRlmstatCommand(ctx, priorityExpiration, license.Environ(), "-i", "-c", target)
+	commandPhaseTimes.observe("lmstat_feature_exp", license.Name, *phases)
 	if err != nil {
-		// Preserve stdout/stderr content for debugging if available.
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			out = append(out, exitErr.Stderr...)
+		if strings.Contains(string(out), "License server status: Error") {
+			code := classifyServerError(out)
+			level.Error(c.logger).Log("msg", "license server error during expiration check", "license", license.Name, "code", code, "err", err)
+			return nil, &serverError{code, networkError(fmt.Errorf("license server error for %s: %w", license.Name, err))}
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("rlmstat -i failed for %s: %w", license.Name, err)
 		}
-		return out, err
+		level.Debug(c.logger).Log("msg", "rlmstat -i exited with error, parsing output anyway", "license", license.Name, "err", err)
 	}
-	return out, nil
+
+	start := time.Now()
+	defer func() {
+		parseDurations.observe(c.logger, "lmstat_feature_exp", license.Name, len(out), time.Since(start))
+	}()
+	dataStr, err := splitOutput(out)
+	if err != nil {
+		captureParseFailure(c.logger, license.Name, []string{"-i", "-c", target}, out, err)
+		return nil, parseError(fmt.Errorf("couldn't split rlmstat -i output for %s: %w", license.Name, err))
+	}
+	loc, err := license.Location()
+	if err != nil {
+		return nil, configError(err)
+	}
+	featuresExp := parseLmstatLicenseFeatureExpDate(dataStr, loc)
+	applyFeatureExpAliases(featuresExp, license)
+	return featuresExp, nil
 }
 
-func splitFeatureExpOutput(raw []byte) ([][]string, error) {
-	r := csv.NewReader(bytes.NewReader(raw))
-	r.Comma = 'Ž'
-	r.LazyQuotes = true
-	r.Comment = '#'
-	records, err := r.ReadAll()
+// querySnapshotFeatureExp returns the license pools of the `rlmstat -a`
+// output against target, which the lmstat collector parses too: the command
+// runs once per scrape, or per collection of the license.
+func (c *lmstatFeatureExpCollector) querySnapshotFeatureExp(ctx context.Context, license config.License, target string) (map[int]*featureExp, error) {
+	ctx, phases := withCommandPhases(ctx)
+	out, err := runRlmstatCommand(ctx, priorityStatus, license.Environ(), "-a", "-c", target)
+	commandPhaseTimes.observe("lmstat_feature_exp", license.Name, *phases)
 	if err != nil {
-		return nil, err
+		if len(out) == 0 {
+			return nil, fmt.Errorf("rlmstat -a failed for %s: %w", license.Name, err)
+		}
+		level.Debug(c.logger).Log("msg", "rlmstat -a exited with error, parsing output anyway", "license", license.Name, "err", err)
 	}
 
-	filtered := make([][]string, 0, len(records))
-	seen := make(map[string]int)
-	for _, row := range records {
-		if len(row) == 0 {
+	start := time.Now()
+	defer func() {
+		parseDurations.observe(c.logger, "lmstat_feature_exp", license.Name, len(out), time.Since(start))
+	}()
+	dataStr, err := splitOutput(out)
+	if err != nil {
+		return nil, parseError(fmt.Errorf("couldn't split rlmstat -a output for %s: %w", license.Name, err))
+	}
+	loc, err := license.Location()
+	if err != nil {
+		return nil, configError(err)
+	}
+	featuresExp := parseLicensePoolExpirations(dataStr, loc)
+	applyFeatureExpAliases(featuresExp, license)
+	return featuresExp, nil
+}
+
+// commands implements commandLister.
+func (c *lmstatFeatureExpCollector) commands() []Command {
+	if c.config == nil {
+		return nil
+	}
+
+	var cmds []Command
+	for _, license := range c.config.Licenses {
+		target := commandTarget(license)
+		if target == "" {
 			continue
 		}
-		key := row[0]
-		if count, ok := seen[key]; ok {
-			seen[key] = count + 1
-			row[0] = strings.TrimSpace(row[0]) + strconv.Itoa(seen[key])
-		} else {
-			seen[key] = 1
+		cmd := licenseRlmstatCommand(license, "-i", "-c", target)
+		if *rlmstatSnapshot && license.Parser == "" {
+			cmd.Condition = "if rlmstat is older than v12, the run of the lmstat collector is shared otherwise"
 		}
-		filtered = append(filtered, row)
+		cmds = append(cmds, cmd)
 	}
-	return filtered, nil
+	return cmds
 }
 
-func parseFeatureExpRecords(records [][]string) []*featureExp {
-	features := make([]*featureExp, 0, len(records))
-	for _, row := range records {
-		if len(row) == 0 {
+// parseLmstatLicenseFeatureExpDate parses `rlmstat -i` output into license
+// lines indexed from 1 in the order they appear. Dates are interpreted in loc.
+func parseLmstatLicenseFeatureExpDate(outStr [][]string, loc *time.Location) map[int]*featureExp {
+	var index int
+	now := timeNow().In(loc)
+	featuresExp := make(map[int]*featureExp)
+	for _, row := range outStr {
+		matches := lmutilLicenseFeatureExpRegex.FindStringSubmatch(strings.Join(row, ""))
+		if matches == nil {
 			continue
 		}
+		index++
+		expires, ok := parseExpiry(matches[4], loc, now)
+		featuresExp[index] = &featureExp{
+			name:       matches[1],
+			version:    matches[2],
+			licenses:   matches[3],
+			expires:    expires,
+			rawExpires: matches[4],
+			parsed:     ok,
+			vendor:     matches[5],
+		}
+	}
+	return featuresExp
+}
+
+// parseLicensePoolExpirations parses the "license pool status" blocks of
+// `rlmstat -a` output into license lines like those of `rlmstat -i`, one per
+// pool, indexed from 1 in the order they appear. Dates are interpreted in loc.
+func parseLicensePoolExpirations(outStr [][]string, loc *time.Location) map[int]*featureExp {
+	var (
+		index   int
+		now     = timeNow().In(loc)
+		vendor  string
+		current *featureExp
+	)
+	featuresExp := make(map[int]*featureExp)
+	for _, row := range outStr {
 		line := strings.Join(row, "")
-		matches := lmutilLicenseFeatureExpRegex.FindStringSubmatch(line)
-		if matches == nil {
+		if matches := rlmPoolStatusRegex.FindStringSubmatch(line); matches != nil {
+			vendor, current = matches[1], nil
 			continue
 		}
-
-		expires := parseExpiry(matches[4])
-		features = append(features, &featureExp{
-			name:     matches[1],
-			version:  matches[2],
-			licenses: matches[3],
-			expires:  expires,
-			vendor:   matches[5],
-		})
+		if matches := rlmPoolFeatureRegex.FindStringSubmatch(line); matches != nil {
+			current = &featureExp{name: matches[1], version: matches[2], vendor: vendor}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		count := rlmPoolCountValueRegex.FindStringSubmatch(line)
+		exp := rlmPoolExpRegex.FindStringSubmatch(line)
+		if count == nil || exp == nil {
+			continue
+		}
+		index++
+		current.licenses = count[1]
+		current.rawExpires = exp[1]
+		current.expires, current.parsed = parseExpiry(exp[1], loc, now)
+		featuresExp[index] = current
+		current = nil
 	}
-	return features
+	return featuresExp
 }
 
-func parseExpiry(raw string) float64 {
+// parseExpiry returns the start of the expiration day in loc as a Unix
+// timestamp, +Inf for permanent licenses. "today" and "tomorrow" are relative
+// to now. The boolean is false if raw couldn't be parsed.
+func parseExpiry(raw string, loc *time.Location, now time.Time) (float64, bool) {
 	if raw == "" {
-		return math.Inf(1)
+		return math.Inf(1), true
 	}
 
 	if strings.EqualFold(raw, "permanent") || strings.EqualFold(raw, "none") {
-		return math.Inf(1)
+		return math.Inf(1), true
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch strings.ToLower(raw) {
+	case "today":
+		return float64(today.Unix()), true
+	case "tomorrow":
+		return float64(today.AddDate(0, 0, 1).Unix()), true
 	}
 
 	parts := strings.Split(raw, "-")
@@ -195,44 +311,21 @@ func parseExpiry(raw string) float64 {
 		if len(year) == 1 {
 			year = "000" + year
 		}
-		if t, err := time.Parse("02-Jan-2006", fmt.Sprintf("%s-%s-%s", day, month, year)); err == nil {
+		if t, err := time.ParseInLocation("02-Jan-2006", fmt.Sprintf("%s-%s-%s", day, month, year), loc); err == nil {
 			if t.Unix() <= 0 {
-				return math.Inf(1)
+				// Year 0 marks a permanent license.
+				return math.Inf(1), true
 			}
-			return float64(t.Unix())
+			return float64(t.Unix()), true
 		}
 	}
 
-	if t, err := time.Parse("Jan 02, 2006", raw); err == nil {
+	if t, err := time.ParseInLocation("Jan 02, 2006", raw, loc); err == nil {
 		if t.Unix() <= 0 {
-			return math.Inf(1)
+			return math.Inf(1), true
 		}
-		return float64(t.Unix())
+		return float64(t.Unix()), true
 	}
 
-	return math.Inf(1)
-}
-
-func splitCSVList(value string) []string {
-	if value == "" {
-		return nil
-	}
-	parts := strings.Split(value, ",")
-	result := make([]string, 0, len(parts))
-	for _, p := range parts {
-		trimmed := strings.TrimSpace(p)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
-}
-
-func contains(slice []string, item string) bool {
-	for _, v := range slice {
-		if v == item {
-			return true
-		}
-	}
-	return false
+	return math.NaN(), false
 }
@@ -15,9 +15,17 @@
 package collector
 
 import (
+	"context"
 	"io/ioutil"
 	"math"
 	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
 )
 
 const (
@@ -38,7 +46,7 @@ func TestParseLmstatLicenseFeatureExpDate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	featuresExp := parseLmstatLicenseFeatureExpDate(dataStr)
+	featuresExp := parseLmstatLicenseFeatureExpDate(dataStr, time.UTC)
 	found := false
 	for index, feature := range featuresExp {
 		if feature.name == "feature_11" {
@@ -98,3 +106,220 @@ func TestParseLmstatLicenseFeatureExpDate(t *testing.T) {
 		t.Fatalf("feature16 not found")
 	}
 }
+
+func TestParseExpiry(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	// Just after midnight in Berlin, still the previous day in UTC.
+	now := time.Date(2018, 12, 31, 0, 30, 0, 0, berlin)
+
+	tests := []struct {
+		raw      string
+		loc      *time.Location
+		expected float64
+		parsed   bool
+	}{
+		{"31-dec-2018", time.UTC, 1546214400, true},
+		{"31-dec-2018", berlin, 1546210800, true},
+		{"today", berlin, 1546210800, true},
+		{"tomorrow", berlin, 1546297200, true},
+		{"1-jan-0", berlin, math.Inf(1), true},
+		{"1-jan-1970", time.UTC, math.Inf(1), true},
+		{"15-jun-1970", time.UTC, 14256000, true},
+		{"Jun 15, 1970", time.UTC, 14256000, true},
+		{"permanent", berlin, math.Inf(1), true},
+		{"31-foo-2018", berlin, math.NaN(), false},
+	}
+	for _, test := range tests {
+		expires, parsed := parseExpiry(test.raw, test.loc, now)
+		if parsed != test.parsed {
+			t.Fatalf("Unexpected parse result for %q: %v", test.raw, parsed)
+		}
+		if parsed && expires != test.expected {
+			t.Fatalf("Unexpected expiry for %q in %s: %f != %f", test.raw, test.loc, expires, test.expected)
+		}
+	}
+}
+
+// collectFeatureExp collects license and returns its earliest expiration,
+// the feature12 lines by seat count and the ignored features.
+func collectFeatureExp(t *testing.T, license config.License) (float64, map[string]float64, []string) {
+	t.Helper()
+	collector, err := NewLmstatFeatureExpCollector(nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := collector.(*lmstatFeatureExpCollector)
+	ch := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.collectFeatureExpForLicense(context.Background(), ch, license)
+		close(ch)
+	}()
+
+	var (
+		earliest float64
+		lines    = make(map[string]float64)
+		ignored  []string
+	)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		labels := make(map[string]string)
+		for _, l := range pb.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		switch m.Desc() {
+		case licenseEarliestExpirationDesc:
+			earliest = pb.GetGauge().GetValue()
+		case featureExpirationIgnoredDesc:
+			ignored = append(ignored, labels["feature"])
+		case featureLineExpirationDesc:
+			if labels["feature"] == feature12String {
+				lines[labels["count"]] = pb.GetGauge().GetValue()
+			}
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	return earliest, lines, ignored
+}
+
+func TestCollectFeatureExpiration(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-i": testParseLmstatLicenseFeatureExpDate1})
+
+	earliest, lines, _ := collectFeatureExp(t, config.License{Name: "app1", LicenseServer: "27000@host1"})
+	// Both feature12 lines are kept with their own seat count.
+	if lines["50"] != 1546214400 || lines["2"] != 1538265600 {
+		t.Fatalf("Unexpected feature12 lines %v", lines)
+	}
+	// 30-sep-2018 is the earliest date in the fixture.
+	if earliest != 1538265600 {
+		t.Fatalf("Unexpected earliest expiration %f", earliest)
+	}
+}
+
+func TestCollectFeatureExpirationIgnored(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-i": testParseLmstatLicenseFeatureExpDate1})
+
+	license := config.License{Name: "app1", LicenseServer: "27000@host1", Features: map[string]config.Feature{
+		feature12String: {IgnoreExpiration: true},
+		"feature13":     {IgnoreExpiration: true},
+		"feature14":     {IgnoreExpiration: true},
+	}}
+	earliest, lines, ignored := collectFeatureExp(t, license)
+	// The lines of ignored features are still exported.
+	if lines["2"] != 1538265600 {
+		t.Fatalf("Unexpected feature12 lines %v", lines)
+	}
+	// Without the 30-sep-2018 lines, 31-dec-2018 is the earliest.
+	if earliest != 1546214400 {
+		t.Fatalf("Unexpected earliest expiration %f", earliest)
+	}
+	if len(ignored) != 3 {
+		t.Fatalf("Unexpected ignored features %v", ignored)
+	}
+}
+
+func TestCollectFeatureVersions(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-i": testParseLmstatLicenseFeatureExpDate1})
+
+	collector, err := NewLmstatFeatureExpCollector(nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		_ = collector.(*lmstatFeatureExpCollector).collectFeatureExpForLicense(context.Background(), ch,
+			config.License{Name: "app1", LicenseServer: "27000@host1"})
+		close(ch)
+	}()
+
+	versions := make(map[string][]string)
+	for m := range ch {
+		if m.Desc() != featureVersionInfoDesc {
+			continue
+		}
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		labels := make(map[string]string)
+		for _, l := range pb.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		versions[labels["feature"]] = append(versions[labels["feature"]], labels["version"]+"/"+labels["isv"])
+	}
+	// Both feature12 lines cover the same version.
+	if got := versions[feature12String]; len(got) != 1 || got[0] != "2018.12/vendor2" {
+		t.Fatalf("Unexpected feature12 versions %v", got)
+	}
+	if got := versions["feature13"]; len(got) != 1 || got[0] != "2018.09/vendor2" {
+		t.Fatalf("Unexpected feature13 versions %v", got)
+	}
+}
+
+func TestCollectFeatureExpirationSnapshot(t *testing.T) {
+	old := *rlmstatSnapshot
+	t.Cleanup(func() { *rlmstatSnapshot = old })
+	*rlmstatSnapshot = true
+	// Without -i, the expirations can only come from the -a run.
+	fakeRlmstat(t, map[string]string{"-version": testParseRlmV14, "-a": testParseRlmV14})
+
+	license := config.License{Name: "app1", LicenseServer: "5053@host1"}
+	cfg := &config.Config{Licenses: []config.License{license}}
+	lmstat, err := NewLmstatCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	featureExp, err := NewLmstatFeatureExpCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc := RlmlmCollector{
+		Config:     cfg,
+		Logger:     log.NewNopLogger(),
+		Collectors: map[string]Collector{"lmstat": lmstat, "lmstat_feature_exp": featureExp},
+	}
+
+	execShared.mu.Lock()
+	shared := execShared.count
+	execShared.mu.Unlock()
+	metrics, err := nc.collectLicense(context.Background(), license)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execShared.mu.Lock()
+	shared = execShared.count - shared
+	execShared.mu.Unlock()
+	if shared != 1 {
+		t.Fatalf("Expected the rlmstat -a run to be shared once, got %v", shared)
+	}
+
+	var earliest, issued float64
+	for _, m := range metrics {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		switch m.Desc() {
+		case licenseEarliestExpirationDesc:
+			earliest = pb.GetGauge().GetValue()
+		case featureIssuedDesc:
+			for _, l := range pb.GetLabel() {
+				if l.GetName() == "feature" && l.GetValue() == "feature1" {
+					issued = pb.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	// feature1 expires on 31-dec-2026, the other pools are permanent.
+	if earliest != 1798675200 || issued != 144 {
+		t.Fatalf("Unexpected earliest expiration %f and issued %f", earliest, issued)
+	}
+}
@@ -16,11 +16,22 @@
 package collector
 
 import (
+	"context"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
 )
 
-func (c *lmstatFeatureExpCollector) getLmstatFeatureExpDate(ch chan<- prometheus.Metric) error {
+func (c *lmstatFeatureExpCollector) getLmstatFeatureExpDate(ctx context.Context, ch chan<- prometheus.Metric) error {
 	level.Info(c.logger).Log("msg", "feature expiration collection not implemented on Windows")
 	return nil
 }
+
+func (c *lmstatFeatureExpCollector) UpdateLicense(ctx context.Context, ch chan<- prometheus.Metric, license config.License) error {
+	return nil
+}
+
+func (c *lmstatFeatureExpCollector) queryFeatureExp(ctx context.Context, license config.License, target string) (map[int]*featureExp, error) {
+	return nil, nil
+}
@@ -16,6 +16,7 @@ package collector
 
 import (
 	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -27,6 +28,14 @@ const (
 	testParseLmstatLicenseInfo1 = "fixtures/lmstat_app1.txt"
 	testParseLmstatServerDown   = "fixtures/lmstat_server_down.txt"
 	testParseLmstatServerUpWin  = "fixtures/lmstat_server_up_win.txt"
+	testParseLmstatServerIPv6   = "fixtures/lmstat_ipv6.txt"
+)
+
+var (
+	features             map[string]*feature
+	licUsersByFeature    map[string]map[string]float64
+	licHostsByFeature    map[string]map[string]float64
+	reservGroupByFeature map[string]map[string]float64
 )
 
 func TestContains(t *testing.T) {
@@ -40,6 +49,35 @@ func TestContains(t *testing.T) {
 	}
 }
 
+func TestTopUsers(t *testing.T) {
+	users := map[string]float64{"user1": 1, "user2": 5, "user3": 2, "user4": 2}
+	top := topUsers(users, 3)
+	if strings.Join(top, ",") != "user2,user3,user4" {
+		t.Fatalf("Unexpected top users %v", top)
+	}
+	if top := topUsers(users, 10); len(top) != 4 {
+		t.Fatalf("Unexpected top users %v", top)
+	}
+	if top := topUsers(users, 0); top != nil {
+		t.Fatalf("Expected no top users when disabled, got %v", top)
+	}
+}
+
+func TestUsageInconsistent(t *testing.T) {
+	if usageInconsistent(3, map[string]float64{"user1": 2, "user2": 1}) {
+		t.Fatal("Expected matching usage to be consistent")
+	}
+	if !usageInconsistent(4, map[string]float64{"user1": 2, "user2": 1}) {
+		t.Fatal("Expected a missing checkout to be inconsistent")
+	}
+	if !usageInconsistent(1, nil) {
+		t.Fatal("Expected usage without users to be inconsistent")
+	}
+	if usageInconsistent(0, nil) {
+		t.Fatal("Expected an unused feature to be consistent")
+	}
+}
+
 func TestParseLmstatVersion(t *testing.T) {
 	dataByte, err := ioutil.ReadFile(testParseLmstatVersionNew)
 	if err != nil {
@@ -161,6 +199,32 @@ func TestParseLmstatLicenseInfoServer(t *testing.T) {
 
 }
 
+func TestParseLmstatLicenseInfoServerIPv6(t *testing.T) {
+	dataByte, err := ioutil.ReadFile(testParseLmstatServerIPv6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataStr, err := splitOutput(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servers := parseLmstatLicenseInfoServer(dataStr)
+	if len(servers) != 3 {
+		t.Fatalf("Expected 3 servers, got %d", len(servers))
+	}
+	for _, fqdn := range []string{"2001:db8::1", "host2", "2001:db8::3"} {
+		info, ok := servers[fqdn]
+		if !ok {
+			t.Fatalf("Missing server %s", fqdn)
+		}
+		if info.fqdn != fqdn || info.port != "28000" || info.version != v11130String || !info.status {
+			t.Fatalf("Unexpected values for %s: %s, %s, %s, %t",
+				fqdn, info.fqdn, info.port, info.version, info.status)
+		}
+	}
+}
+
 func TestParseLmstatLicenseInfoVendor(t *testing.T) {
 	dataByte, err := ioutil.ReadFile(testParseLmstatLicenseInfo1)
 	if err != nil {
@@ -195,7 +259,7 @@ func TestParseLmstatLicenseInfoFeature(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	features, licUsersByFeature, reservGroupByFeature = parseLmstatLicenseInfoFeature(dataStr)
+	features, licUsersByFeature, licHostsByFeature, reservGroupByFeature, _ = parseLmstatLicenseInfoFeature(dataStr)
 	for name, info := range features {
 		if name == "feature11" {
 			if info.issued != 16384 || info.used != 80 {
@@ -231,6 +295,15 @@ func TestParseLmstatLicenseInfoFeature(t *testing.T) {
 		t.Fatalf("Couldn't parse user \"user11\" from feature34")
 	}
 
+	// user2 holds feature34 checkouts on several hosts.
+	if used := licHostsByFeature["feature34"]["server0161"]; used != 16 {
+		t.Fatalf("Unexpected values for feature34 on server0161: %v!=16", used)
+	}
+	if len(licHostsByFeature["feature34"]) <= len(licUsersByFeature["feature34"]) {
+		t.Fatalf("Expected more hosts than users for feature34, got %d hosts and %d users",
+			len(licHostsByFeature["feature34"]), len(licUsersByFeature["feature34"]))
+	}
+
 	var foundCmfy211 = false
 
 	for username, licused := range licUsersByFeature["feature31"] {
@@ -0,0 +1,188 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// parseLmstatParseable parses the output of `rlmstat -a -dq`. Every line is a
+// record type followed by key=value pairs, values may be double quoted:
+//
+//	server fqdn=host1 port=5053 status=UP master=yes version=v12.4
+//	isv name=vendor1 status=UP version=v12.4
+//	feature name=feature1 version=2018.12 issued=10 used=2 queued=1 isv=vendor1
+//	user feature=feature1 user="John Doe" host=host1 licenses=1
+//	reservation feature=feature1 group=GROUP1 count=8
+//	pool name=feature1 soft=8 overdraft=2
+//
+// Unknown record types are skipped so newer utilities keep working.
+func parseLmstatParseable(raw []byte) (*lmstatData, error) {
+	data := &lmstatData{
+		servers:               make(map[string]*server),
+		vendors:               make(map[string]*vendor),
+		features:              make(map[string]*feature),
+		usersByFeature:        make(map[string]map[string]float64),
+		hostsByFeature:        make(map[string]map[string]float64),
+		checkoutsByFeature:    make(map[string][]checkout),
+		reservationsByFeature: make(map[string]map[string]float64),
+		isvByFeature:          make(map[string]string),
+	}
+
+	// Pools may be listed before their feature.
+	var pools []map[string]string
+	records := 0
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		fields := splitParseableFields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		kv := parseableKeyValues(fields[1:])
+
+		switch fields[0] {
+		case "server":
+			fqdn := config.ServerHost(kv["fqdn"])
+			data.servers[fqdn] = &server{
+				fqdn:    fqdn,
+				port:    kv["port"],
+				version: kv["version"],
+				status:  kv["status"] == upString,
+				master:  kv["master"] == "yes",
+			}
+			if kv["status"] == upString && kv["version"] != "" {
+				data.banners = append(data.banners, serverBanner{
+					server:   kv["port"] + "@" + fqdn,
+					version:  kv["version"],
+					build:    kv["build"],
+					platform: kv["platform"],
+				})
+			}
+		case "isv":
+			data.vendors[kv["name"]] = &vendor{
+				status:  kv["status"] == upString,
+				version: kv["version"],
+			}
+		case "feature":
+			issued, _ := strconv.ParseFloat(kv["issued"], 64)
+			used, _ := strconv.ParseFloat(kv["used"], 64)
+			queued, _ := strconv.ParseFloat(kv["queued"], 64)
+			data.features[kv["name"]] = &feature{issued: issued, used: used, queued: queued}
+			if kv["isv"] != "" {
+				data.isvByFeature[kv["name"]] = kv["isv"]
+			}
+		case "user":
+			licenses, err := strconv.ParseFloat(kv["licenses"], 64)
+			if err != nil {
+				licenses = 1
+			}
+			addToNested(data.usersByFeature, kv["feature"], kv["user"], licenses)
+			if kv["host"] != "" {
+				addToNested(data.hostsByFeature, kv["feature"], kv["host"], licenses)
+			}
+			data.checkoutsByFeature[kv["feature"]] = append(data.checkoutsByFeature[kv["feature"]], checkout{kv["user"], kv["host"], licenses})
+		case "reservation":
+			count, _ := strconv.ParseFloat(kv["count"], 64)
+			addToNested(data.reservationsByFeature, kv["feature"], kv["group"], count)
+		case "pool":
+			pools = append(pools, kv)
+		default:
+			continue
+		}
+		records++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if records == 0 {
+		return nil, errUnparseableOutput
+	}
+	for _, kv := range pools {
+		f, ok := data.features[kv["name"]]
+		if !ok {
+			continue
+		}
+		if soft, err := strconv.ParseFloat(kv["soft"], 64); err == nil {
+			f.softLimit += soft
+			f.hasSoftLimit = true
+		}
+		if overdraft, err := strconv.ParseFloat(kv["overdraft"], 64); err == nil {
+			f.overdraft += overdraft
+			f.hasOverdraft = true
+		}
+	}
+	return data, nil
+}
+
+// splitParseableFields splits a line on whitespace, keeping double quoted
+// values (which may contain spaces) together.
+func splitParseableFields(line string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+		quoted  bool
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quoted && r == '\\':
+			current.WriteRune(r)
+			escaped = true
+		case r == '"':
+			current.WriteRune(r)
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+func parseableKeyValues(fields []string) map[string]string {
+	kv := make(map[string]string, len(fields))
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		kv[key] = value
+	}
+	return kv
+}
+
+func addToNested(m map[string]map[string]float64, outer, inner string, value float64) {
+	if m[outer] == nil {
+		m[outer] = make(map[string]float64)
+	}
+	m[outer][inner] += value
+}
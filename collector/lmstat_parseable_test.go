@@ -0,0 +1,101 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+const testParseLmstatParseable = "fixtures/lmstat_dq_app1.txt"
+
+func TestParseLmstatParseable(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseLmstatParseable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := parseLmstatParseable(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := data.servers["host1"]; s == nil || !s.status || !s.master || s.port != "5053" || s.version != "v12.4" {
+		t.Fatalf("Unexpected values for host1: %+v", s)
+	}
+	if s := data.servers["host2"]; s == nil || s.status || s.master || s.version != "" {
+		t.Fatalf("Unexpected values for host2: %+v", s)
+	}
+	// Servers that are down don't tell their version.
+	if b := data.banners; len(b) != 1 || b[0] != (serverBanner{server: "5053@host1", version: "v12.4"}) {
+		t.Fatalf("Unexpected server banners %+v", b)
+	}
+	if v := data.vendors["vendor1"]; v == nil || !v.status || v.version != "v12.4" {
+		t.Fatalf("Unexpected values for vendor1: %+v", v)
+	}
+	if f := data.features["feature1"]; f == nil || f.issued != 144 || f.used != 3 {
+		t.Fatalf("Unexpected values for feature1: %+v", f)
+	}
+	if used := data.usersByFeature["feature1"]["user1"]; used != 2 {
+		t.Fatalf("Unexpected values for feature1[user1]: %v!=2", used)
+	}
+	if used := data.usersByFeature["feature1"]["John Doe"]; used != 1 {
+		t.Fatalf("Unexpected values for feature1[John Doe]: %v!=1", used)
+	}
+	if used := data.hostsByFeature["feature1"]["server034"]; used != 2 || len(data.hostsByFeature["feature1"]) != 2 {
+		t.Fatalf("Unexpected hosts for feature1: %v", data.hostsByFeature["feature1"])
+	}
+	if c := data.checkoutsByFeature["feature1"]; len(c) != 2 || c[1] != (checkout{"John Doe", "server035", 1}) {
+		t.Fatalf("Unexpected checkouts of feature1: %+v", c)
+	}
+	if reserved := data.reservationsByFeature["feature1"]["GROUP1"]; reserved != 8 {
+		t.Fatalf("Unexpected values for feature1[GROUP1]: %v!=8", reserved)
+	}
+	if f := data.features["feature1"]; f.hasSoftLimit || f.hasOverdraft {
+		t.Fatalf("Unexpected limits for feature1: %+v", f)
+	}
+	if f := data.features["feature2"]; f == nil || !f.hasSoftLimit || f.softLimit != 20 || f.hasOverdraft {
+		t.Fatalf("Unexpected values for feature2: %+v", f)
+	}
+	if data.usersByFeature["feature2"] != nil {
+		t.Fatalf("Unexpected users for feature2: %v", data.usersByFeature["feature2"])
+	}
+}
+
+func TestParseLmstatParseableFallback(t *testing.T) {
+	dataByte, err := os.ReadFile("fixtures/lmstat_app1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseLmstatParseable(dataByte); err != errUnparseableOutput {
+		t.Fatalf("Unexpected error for human readable output: %v", err)
+	}
+	if _, err := legacyQuirks.parseHuman(dataByte); err != nil {
+		t.Fatalf("Unexpected error for human readable output: %v", err)
+	}
+}
+
+func TestVersionMajor(t *testing.T) {
+	for version, expected := range map[string]int{
+		"v11.14.0.1": 11,
+		"v12.4":      12,
+		"16":         16,
+		notFound:     -1,
+	} {
+		if major := versionMajor(version); major != expected {
+			t.Fatalf("versionMajor(%q) = %d, expected %d", version, major, expected)
+		}
+	}
+}
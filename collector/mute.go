@@ -0,0 +1,94 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	licenseMutedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "license", "muted"),
+		"Whether collecting the license is suspended through the mute API, e.g. during server maintenance.",
+		[]string{"license_name"},
+		nil,
+	)
+
+	mutes = &muteTracker{now: time.Now, until: make(map[string]time.Time)}
+)
+
+// muteTracker holds the licenses that aren't collected until a given time.
+type muteTracker struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	until map[string]time.Time
+}
+
+// mute suspends collecting license for d and returns when it ends.
+func (t *muteTracker) mute(license string, d time.Duration) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until := t.now().Add(d)
+	t.until[license] = until
+	return until
+}
+
+// unmute resumes collecting license, returning whether it was muted.
+func (t *muteTracker) unmute(license string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.until[license]
+	delete(t.until, license)
+	return ok && t.now().Before(until)
+}
+
+// muted returns whether license is muted, forgetting mutes that ended.
+func (t *muteTracker) muted(license string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.until[license]
+	if ok && !t.now().Before(until) {
+		delete(t.until, license)
+		return false
+	}
+	return ok
+}
+
+// collect sends whether each license of cfg is muted.
+func (t *muteTracker) collect(ch chan<- prometheus.Metric, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	for _, license := range cfg.Licenses {
+		ch <- constMetric(licenseMutedDesc, prometheus.GaugeValue, boolToFloat64(t.muted(license.Name)), license.Name)
+	}
+}
+
+// MuteLicense stops the collectors from querying the license name for d,
+// like during planned maintenance of its server, and returns when they
+// resume. Mutes are kept across reloads but not restarts.
+func MuteLicense(name string, d time.Duration) time.Time {
+	return mutes.mute(name, d)
+}
+
+// UnmuteLicense resumes collecting the license name before its mute ends. It
+// returns false if the license wasn't muted.
+func UnmuteLicense(name string) bool {
+	return mutes.unmute(name)
+}
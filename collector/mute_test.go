@@ -0,0 +1,66 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestMuteTracker(t *testing.T) {
+	now := time.Date(2025, 3, 29, 10, 0, 0, 0, time.UTC)
+	tr := &muteTracker{now: func() time.Time { return now }, until: make(map[string]time.Time)}
+
+	if until := tr.mute("app1", 2*time.Hour); !until.Equal(now.Add(2 * time.Hour)) {
+		t.Fatalf("Unexpected end of mute %v", until)
+	}
+	tr.mute("app2", time.Hour)
+	if !tr.muted("app1") || !tr.muted("app2") || tr.muted("app3") {
+		t.Fatal("Expected app1 and app2 to be muted")
+	}
+	if !tr.unmute("app2") || tr.muted("app2") || tr.unmute("app2") {
+		t.Fatal("Expected app2 to be unmuted once")
+	}
+	now = now.Add(2 * time.Hour)
+	if tr.muted("app1") || len(tr.until) != 0 {
+		t.Fatalf("Expected the mute of app1 to end, got %v", tr.until)
+	}
+}
+
+func TestCollectLicenseMuted(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": testParseLmstatQueued})
+
+	license := config.License{Name: "muted_app", LicenseServer: "27002@host2.domain.net"}
+	cfg := &config.Config{Licenses: []config.License{license}}
+	c, err := NewRlmlmCollector(cfg, log.NewNopLogger(), WithEnabled("lmstat"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	MuteLicense(license.Name, time.Hour)
+	defer UnmuteLicense(license.Name)
+	metrics, err := c.collectLicense(context.Background(), license)
+	if err != nil || len(metrics) != 0 {
+		t.Fatalf("Expected no metrics for a muted license, got %d: %v", len(metrics), err)
+	}
+
+	UnmuteLicense(license.Name)
+	if metrics, _ = c.collectLicense(context.Background(), license); len(metrics) == 0 {
+		t.Fatal("Expected metrics once unmuted")
+	}
+}
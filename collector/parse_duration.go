@@ -0,0 +1,66 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	slowParseThreshold = kingpin.Flag("rlmstat.slow-parse-threshold",
+		"Log a warning when parsing the rlmstat output of a license takes longer than this, which usually means pathological output. Zero disables the warning.").Default("1s").Duration()
+
+	parseDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "parse", "duration_seconds"),
+		"rlmlm_exporter: Time spent parsing the latest rlmstat output of a license, apart from running rlmstat.",
+		[]string{"collector", "license_name"},
+		nil,
+	)
+
+	parseDurations = &parseTimer{seconds: make(map[[2]string]float64)}
+)
+
+// parseTimer remembers how long parsing the latest rlmstat output of every
+// license took, by collector.
+type parseTimer struct {
+	mu      sync.Mutex
+	seconds map[[2]string]float64
+}
+
+// observe records that parsing size bytes of output of license took d,
+// logging a warning if it took longer than --rlmstat.slow-parse-threshold.
+func (t *parseTimer) observe(logger log.Logger, collector, license string, size int, d time.Duration) {
+	t.mu.Lock()
+	t.seconds[[2]string{collector, license}] = d.Seconds()
+	t.mu.Unlock()
+
+	if *slowParseThreshold > 0 && d > *slowParseThreshold {
+		level.Warn(logger).Log("msg", "slow rlmstat output parsing", "collector", collector, "license", license,
+			"duration", d, "bytes", size, "threshold", *slowParseThreshold)
+	}
+}
+
+func (t *parseTimer) collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, seconds := range t.seconds {
+		ch <- constMetric(parseDurationDesc, prometheus.GaugeValue, seconds, key[0], key[1])
+	}
+}
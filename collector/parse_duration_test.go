@@ -0,0 +1,55 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestParseTimer(t *testing.T) {
+	oldThreshold := *slowParseThreshold
+	*slowParseThreshold = time.Second
+	defer func() { *slowParseThreshold = oldThreshold }()
+
+	var buf bytes.Buffer
+	logger := log.NewLogfmtLogger(&buf)
+	timer := &parseTimer{seconds: make(map[[2]string]float64)}
+
+	timer.observe(logger, "lmstat", "app1", 100, 10*time.Millisecond)
+	if buf.Len() != 0 {
+		t.Fatalf("Unexpected warning %q", buf.String())
+	}
+	timer.observe(logger, "lmstat", "app1", 1<<20, 3*time.Second)
+	if !strings.Contains(buf.String(), "slow rlmstat output parsing") || !strings.Contains(buf.String(), "license=app1") {
+		t.Fatalf("Expected a slow parsing warning, got %q", buf.String())
+	}
+
+	ch := make(chan prometheus.Metric, 1)
+	timer.collect(ch)
+	close(ch)
+	var pb dto.Metric
+	if err := (<-ch).Write(&pb); err != nil {
+		t.Fatal(err)
+	}
+	if pb.GetGauge().GetValue() != 3 {
+		t.Fatalf("Expected the latest duration, got %v", pb.GetGauge().GetValue())
+	}
+}
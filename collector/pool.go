@@ -0,0 +1,165 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// execPriority orders waiting rlmstat runs, lower values run first.
+type execPriority int
+
+const (
+	// priorityStatus is used for server status and usage checks.
+	priorityStatus execPriority = iota
+	// priorityExpiration is used for expiration checks, which can wait.
+	priorityExpiration
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{"status", "expiration"}
+
+func (p execPriority) String() string {
+	return priorityNames[p]
+}
+
+var (
+	maxConcurrency = kingpin.Flag("rlmstat.max-concurrency",
+		"Maximum number of rlmstat processes running at once across all collectors, 0 for no limit.").Default("4").Int()
+
+	execInFlightDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exec", "in_flight"),
+		"rlmlm_exporter: Number of rlmstat processes currently running.",
+		nil, nil,
+	)
+	execQueuedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exec", "queued"),
+		"rlmlm_exporter: Number of rlmstat runs waiting for a free slot, by priority.",
+		[]string{"priority"}, nil,
+	)
+	execWaitSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exec", "wait_seconds_total"),
+		"rlmlm_exporter: Total time rlmstat runs spent waiting for a free slot, by priority.",
+		[]string{"priority"}, nil,
+	)
+	execTotalDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exec", "total"),
+		"rlmlm_exporter: Total number of rlmstat runs, by priority.",
+		[]string{"priority"}, nil,
+	)
+
+	pool = &execPool{limit: func() int { return *maxConcurrency }}
+)
+
+// execPool limits the number of concurrent rlmstat processes across all
+// collectors, handing free slots to the highest priority waiter first.
+type execPool struct {
+	limit func() int
+
+	mu       sync.Mutex
+	inFlight int
+	waiting  [numPriorities][]chan struct{}
+	waited   [numPriorities]time.Duration
+	total    [numPriorities]uint64
+}
+
+// acquire blocks until a slot is free and returns the function releasing it,
+// or returns the error of ctx if it is done first.
+func (p *execPool) acquire(ctx context.Context, prio execPriority) (func(), error) {
+	start := time.Now()
+	p.mu.Lock()
+	p.total[prio]++
+	if limit := p.limit(); limit <= 0 || (p.inFlight < limit && p.queued() == 0) {
+		p.inFlight++
+		p.mu.Unlock()
+		return p.release, nil
+	}
+	ready := make(chan struct{})
+	p.waiting[prio] = append(p.waiting[prio], ready)
+	p.mu.Unlock()
+
+	// The releasing run hands its slot over, inFlight stays the same.
+	var err error
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.mu.Lock()
+	p.waited[prio] += time.Since(start)
+	if err != nil && !p.dequeue(prio, ready) {
+		// The slot was handed over meanwhile, pass it on.
+		p.mu.Unlock()
+		p.release()
+		return nil, err
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return p.release, nil
+}
+
+// dequeue removes the waiter ready of priority prio, and reports whether it
+// was still waiting.
+func (p *execPool) dequeue(prio execPriority, ready chan struct{}) bool {
+	for i, w := range p.waiting[prio] {
+		if w == ready {
+			p.waiting[prio] = append(p.waiting[prio][:i:i], p.waiting[prio][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (p *execPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for prio := range p.waiting {
+		if len(p.waiting[prio]) > 0 {
+			next := p.waiting[prio][0]
+			p.waiting[prio] = p.waiting[prio][1:]
+			close(next)
+			return
+		}
+	}
+	p.inFlight--
+}
+
+func (p *execPool) queued() int {
+	var n int
+	for _, w := range p.waiting {
+		n += len(w)
+	}
+	return n
+}
+
+// collect sends the pool metrics to ch.
+func (p *execPool) collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch <- constMetric(execInFlightDesc, prometheus.GaugeValue, float64(p.inFlight))
+	for prio := execPriority(0); prio < numPriorities; prio++ {
+		ch <- constMetric(execQueuedDesc, prometheus.GaugeValue, float64(len(p.waiting[prio])), prio.String())
+		ch <- constMetric(execWaitSecondsDesc, prometheus.CounterValue, p.waited[prio].Seconds(), prio.String())
+		ch <- constMetric(execTotalDesc, prometheus.CounterValue, float64(p.total[prio]), prio.String())
+	}
+}
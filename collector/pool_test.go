@@ -0,0 +1,90 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExecPoolPriority(t *testing.T) {
+	p := &execPool{limit: func() int { return 1 }}
+	release, _ := p.acquire(context.Background(), priorityStatus)
+
+	order := make(chan execPriority, 2)
+	for _, prio := range []execPriority{priorityExpiration, priorityStatus} {
+		go func(prio execPriority) {
+			release, _ := p.acquire(context.Background(), prio)
+			defer release()
+			order <- prio
+		}(prio)
+		// Queue the expiration run before the status run.
+		for deadline := time.Now().Add(time.Second); ; {
+			p.mu.Lock()
+			n := len(p.waiting[prio])
+			p.mu.Unlock()
+			if n == 1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s run wasn't queued", prio)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	release()
+	if first := <-order; first != priorityStatus {
+		t.Fatalf("Expected the status run first, got %s", first)
+	}
+	if second := <-order; second != priorityExpiration {
+		t.Fatalf("Expected the expiration run second, got %s", second)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight != 0 || p.queued() != 0 || p.total[priorityStatus] != 2 {
+		t.Fatalf("Unexpected pool state in flight %d, queued %d, total %v", p.inFlight, p.queued(), p.total)
+	}
+}
+
+func TestExecPoolCanceled(t *testing.T) {
+	p := &execPool{limit: func() int { return 1 }}
+	release, _ := p.acquire(context.Background(), priorityStatus)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if next, err := p.acquire(ctx, priorityStatus); next != nil || err != context.DeadlineExceeded {
+		t.Fatalf("Expected the deadline to be exceeded, got %v", err)
+	}
+	p.mu.Lock()
+	queued := p.queued()
+	p.mu.Unlock()
+	if queued != 0 {
+		t.Fatalf("Expected the canceled run to leave the queue, got %d queued", queued)
+	}
+
+	release()
+	next, err := p.acquire(context.Background(), priorityStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight != 0 {
+		t.Fatalf("Expected no run in flight, got %d", p.inFlight)
+	}
+}
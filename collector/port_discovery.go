@@ -0,0 +1,225 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// license_server entries without a port are probed on the ports RLM servers
+// are usually configured on.
+const (
+	rlmFirstPort = 5053
+	rlmLastPort  = 5063
+)
+
+var (
+	serverDiscoveredPortDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "discovered_port"),
+		"rlmlm_exporter: Port the license server of a license_server entry without port was found on.",
+		[]string{"license_name", "host"},
+		nil,
+	)
+
+	serverPorts = newPortDiscovery(probeRLMPort)
+)
+
+const (
+	// portProbeTimeout bounds connecting to and reading from a probed port.
+	portProbeTimeout = 2 * time.Second
+	// Discovered ports are probed again after portFoundTTL, or right away
+	// if rlmstat fails. Hosts where no port was found are probed again
+	// after portMissingTTL.
+	portFoundTTL   = time.Hour
+	portMissingTTL = time.Minute
+)
+
+// discoveredPort is the port a host was found listening on, zero if none.
+// done is closed once the probe finished.
+type discoveredPort struct {
+	done    chan struct{}
+	port    int
+	expires time.Time
+}
+
+// portDiscovery caches the ports found by probe per host.
+type portDiscovery struct {
+	mu    sync.Mutex
+	hosts map[string]*discoveredPort
+	probe func(host string) int
+	now   func() time.Time
+}
+
+func newPortDiscovery(probe func(host string) int) *portDiscovery {
+	return &portDiscovery{
+		hosts: make(map[string]*discoveredPort),
+		probe: probe,
+		now:   time.Now,
+	}
+}
+
+// port returns the port host was found listening on, probing it unless a
+// probe finished recently. Concurrent callers share a probe.
+func (d *portDiscovery) port(host string) int {
+	d.mu.Lock()
+	p, ok := d.hosts[host]
+	// Probes in progress have no expiry yet.
+	if ok && (p.expires.IsZero() || d.now().Before(p.expires)) {
+		d.mu.Unlock()
+		<-p.done
+		return p.port
+	}
+	p = &discoveredPort{done: make(chan struct{})}
+	d.hosts[host] = p
+	d.mu.Unlock()
+
+	p.port = d.probe(host)
+	ttl := portFoundTTL
+	if p.port == 0 {
+		ttl = portMissingTTL
+	}
+	d.mu.Lock()
+	p.expires = d.now().Add(ttl)
+	d.mu.Unlock()
+	close(p.done)
+	return p.port
+}
+
+// cached returns the port host was last found listening on without probing.
+func (d *portDiscovery) cached(host string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if p, ok := d.hosts[host]; ok && !p.expires.IsZero() {
+		return p.port
+	}
+	return 0
+}
+
+// forget drops the ports found for the hosts of server, so that they are
+// probed again.
+func (d *portDiscovery) forget(server string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, host := range portlessHosts(server) {
+		if p, ok := d.hosts[host]; ok && p.port != 0 && !p.expires.IsZero() {
+			delete(d.hosts, host)
+		}
+	}
+}
+
+// target returns server with the discovered port added to the entries
+// without one. Entries whose port wasn't found are left to rlmstat's default.
+func (d *portDiscovery) target(server string) string {
+	return addPorts(server, d.port)
+}
+
+// cachedTarget is target without probing, entries whose port wasn't found
+// yet are left to rlmstat's default.
+func (d *portDiscovery) cachedTarget(server string) string {
+	return addPorts(server, d.cached)
+}
+
+// addPorts returns server with the port returned by port added to the
+// entries without one, unless zero.
+func addPorts(server string, port func(host string) int) string {
+	if len(portlessHosts(server)) == 0 {
+		return server
+	}
+	entries := strings.Split(server, ",")
+	for i, entry := range entries {
+		if strings.Contains(entry, "@") {
+			continue
+		}
+		if port := port(entry); port != 0 {
+			entries[i] = strconv.Itoa(port) + "@" + entry
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
+// portlessHosts returns the entries of server without a port.
+func portlessHosts(server string) []string {
+	var hosts []string
+	for _, entry := range strings.Split(server, ",") {
+		if entry != "" && !strings.Contains(entry, "@") {
+			hosts = append(hosts, entry)
+		}
+	}
+	return hosts
+}
+
+// exportDiscoveredPorts sends the ports found for the license_server entries
+// of license without a port.
+func exportDiscoveredPorts(ch chan<- prometheus.Metric, license config.License) {
+	if license.LicenseFile != "" {
+		return
+	}
+	for _, host := range portlessHosts(license.LicenseServer) {
+		if port := serverPorts.cached(host); port != 0 {
+			ch <- constMetric(serverDiscoveredPortDesc, prometheus.GaugeValue, float64(port),
+				license.Name, config.ServerHost(host))
+		}
+	}
+}
+
+// probeRLMPort probes the RLM ports of host in parallel and returns the
+// lowest one accepting connections, or zero. Ports answering HTTP, like the
+// RLM web interface, are skipped.
+func probeRLMPort(host string) int {
+	var (
+		wg    sync.WaitGroup
+		found [rlmLastPort - rlmFirstPort + 1]bool
+	)
+	for i := range found {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			found[i] = probePort(net.JoinHostPort(config.ServerHost(host), strconv.Itoa(rlmFirstPort+i)))
+		}(i)
+	}
+	wg.Wait()
+	for i, ok := range found {
+		if ok {
+			return rlmFirstPort + i
+		}
+	}
+	return 0
+}
+
+// probePort reports whether address accepts connections and doesn't answer
+// an HTTP request.
+func probePort(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, portProbeTimeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(portProbeTimeout))
+	if _, err := io.WriteString(conn, "HEAD / HTTP/1.0\r\n\r\n"); err != nil {
+		return true
+	}
+	reply := make([]byte, 5)
+	n, _ := io.ReadFull(conn, reply)
+	return !bytes.Equal(reply[:n], []byte("HTTP/"))
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPortDiscovery(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		probes = make(map[string]int)
+		ports  = map[string]int{"host1": 5055}
+	)
+	d := newPortDiscovery(func(host string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		probes[host]++
+		return ports[host]
+	})
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if target := d.target("host1,5053@host2,host3"); target != "5055@host1,5053@host2,host3" {
+				t.Errorf("Unexpected target %s", target)
+			}
+		}()
+	}
+	wg.Wait()
+	if probes["host1"] != 1 || probes["host3"] != 1 || probes["host2"] != 0 {
+		t.Fatalf("Expected a single probe of host1 and host3, got %v", probes)
+	}
+
+	// Hosts without a port found are probed again sooner.
+	now = now.Add(2 * portMissingTTL)
+	ports["host1"] = 5060
+	d.target("host1,host3")
+	if probes["host1"] != 1 || probes["host3"] != 2 {
+		t.Fatalf("Expected host3 to be probed again, got %v", probes)
+	}
+
+	d.forget("host1,5053@host2,host3")
+	if port := d.cached("host1"); port != 0 {
+		t.Fatalf("Expected host1 to be forgotten, got port %d", port)
+	}
+	if target := d.target("host1"); target != "5060@host1" {
+		t.Fatalf("Unexpected target after forgetting %s", target)
+	}
+}
+
+func TestPortDiscoveryCachedTarget(t *testing.T) {
+	probes := 0
+	d := newPortDiscovery(func(host string) int {
+		probes++
+		return 5055
+	})
+	// Listing the commands, like with --dry-run, doesn't probe.
+	if target := d.cachedTarget("host1,5053@host2"); target != "host1,5053@host2" || probes != 0 {
+		t.Fatalf("Unexpected target %s after %d probes", target, probes)
+	}
+	d.target("host1")
+	if target := d.cachedTarget("host1,5053@host2"); target != "5055@host1,5053@host2" || probes != 1 {
+		t.Fatalf("Unexpected target %s after %d probes", target, probes)
+	}
+}
+
+func TestProbePort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// Like an RLM server, don't answer HTTP.
+			conn.Close()
+		}
+	}()
+	if !probePort(ln.Addr().String()) {
+		t.Fatalf("Expected %s to be found", ln.Addr())
+	}
+
+	web := httptest.NewServer(http.NotFoundHandler())
+	defer web.Close()
+	if probePort(web.Listener.Addr().String()) {
+		t.Fatal("Expected the web server to be skipped")
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := closed.Addr().String()
+	closed.Close()
+	if probePort(addr) {
+		t.Fatalf("Expected nothing on %s", addr)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	productMappingPath = kingpin.Flag("path.product-mapping",
+		"YAML file mapping feature name regexes to the products exported as rlmlm_product_used and rlmlm_product_issued. Empty disables them.").Default("").String()
+
+	productUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "product", "used"),
+		"Sum of the used licenses of the features mapped to a product.",
+		[]string{"license_name", "product"},
+		nil,
+	)
+	productIssuedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "product", "issued"),
+		"Sum of the issued licenses of the features mapped to a product.",
+		[]string{"license_name", "product"},
+		nil,
+	)
+
+	products   *productMapping
+	productsMu sync.RWMutex
+)
+
+// productRule maps the features matching Match to Product, which may
+// reference the groups of Match like $1.
+type productRule struct {
+	Match   string `yaml:"match"`
+	Product string `yaml:"product"`
+	re      *regexp.Regexp
+}
+
+// productMapping is the file given with --path.product-mapping.
+type productMapping struct {
+	Products []productRule `yaml:"products"`
+}
+
+// LoadProductMapping reads the file given with --path.product-mapping, if
+// any, replacing the mapping in use. The mapping in use is kept on error.
+func LoadProductMapping() error {
+	var m *productMapping
+	if *productMappingPath != "" {
+		data, err := os.ReadFile(*productMappingPath)
+		if err != nil {
+			return err
+		}
+		if m, err = parseProductMapping(data); err != nil {
+			return fmt.Errorf("product mapping %s: %w", *productMappingPath, err)
+		}
+	}
+	productsMu.Lock()
+	products = m
+	productsMu.Unlock()
+	return nil
+}
+
+func parseProductMapping(data []byte) (*productMapping, error) {
+	var m productMapping
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, err
+	}
+	for i := range m.Products {
+		r := &m.Products[i]
+		re, err := regexp.Compile("^(?:" + r.Match + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid match %q: %w", r.Match, err)
+		}
+		if r.Product == "" {
+			return nil, fmt.Errorf("missing product for match %q", r.Match)
+		}
+		r.re = re
+	}
+	return &m, nil
+}
+
+// product returns the product of feature, or false if no rule matches it.
+// The first matching rule wins.
+func (m *productMapping) product(feature string) (string, bool) {
+	for _, r := range m.Products {
+		if match := r.re.FindStringSubmatchIndex(feature); match != nil {
+			return string(r.re.ExpandString(nil, r.Product, feature, match)), true
+		}
+	}
+	return "", false
+}
+
+// productTotals adds up the features of a license by product.
+type productTotals struct {
+	mapping *productMapping
+	used    map[string]float64
+	issued  map[string]float64
+}
+
+// newProductTotals returns totals using the mapping in use, nil if there is
+// none.
+func newProductTotals() *productTotals {
+	productsMu.RLock()
+	defer productsMu.RUnlock()
+	if products == nil {
+		return nil
+	}
+	return &productTotals{
+		mapping: products,
+		used:    make(map[string]float64),
+		issued:  make(map[string]float64),
+	}
+}
+
+// add counts f towards the product of the feature name, if any.
+func (t *productTotals) add(name string, f *feature) {
+	if t == nil {
+		return
+	}
+	if product, ok := t.mapping.product(name); ok {
+		t.used[product] += f.used
+		t.issued[product] += f.issued
+	}
+}
+
+// export sends the totals of license.
+func (t *productTotals) export(ch chan<- prometheus.Metric, license string) {
+	if t == nil {
+		return
+	}
+	for product, used := range t.used {
+		ch <- constMetric(productUsedDesc, prometheus.GaugeValue, used, license, product)
+		ch <- constMetric(productIssuedDesc, prometheus.GaugeValue, t.issued[product], license, product)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestProductMapping(t *testing.T) {
+	m, err := parseProductMapping([]byte(`products:
+  - match: solver_hpc.*
+    product: solver
+  - match: (solver|mesher)(_.*)?
+    product: $1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for feature, expected := range map[string]string{
+		"solver":       "solver",
+		"solver_hpc_4": "solver",
+		"mesher_pro":   "mesher",
+		"viewer":       "",
+	} {
+		if product, _ := m.product(feature); product != expected {
+			t.Fatalf("Expected %s to map to %q, got %q", feature, expected, product)
+		}
+	}
+
+	for _, invalid := range []string{"products:\n  - match: (\n    product: x\n", "products:\n  - match: x\n", "unknown: 1\n"} {
+		if _, err := parseProductMapping([]byte(invalid)); err == nil {
+			t.Fatalf("Expected an error parsing %q", invalid)
+		}
+	}
+}
+
+func TestProductTotals(t *testing.T) {
+	defer func(path string) {
+		*productMappingPath = path
+		_ = LoadProductMapping()
+	}(*productMappingPath)
+
+	if newProductTotals() != nil {
+		t.Fatal("Expected no totals without a mapping")
+	}
+	*productMappingPath = filepath.Join(t.TempDir(), "products.yml")
+	if err := os.WriteFile(*productMappingPath, []byte("products:\n  - match: feature.*\n    product: suite\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadProductMapping(); err != nil {
+		t.Fatal(err)
+	}
+
+	totals := newProductTotals()
+	totals.add("feature1", &feature{issued: 10, used: 4})
+	totals.add("feature2", &feature{issued: 5, used: 1})
+	totals.add("other", &feature{issued: 100, used: 100})
+	ch := make(chan prometheus.Metric, 4)
+	totals.export(ch, "app1")
+	close(ch)
+
+	got := make(map[*prometheus.Desc]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		got[m.Desc()] = pb.GetGauge().GetValue()
+	}
+	if len(got) != 2 || got[productUsedDesc] != 5 || got[productIssuedDesc] != 15 {
+		t.Fatalf("Unexpected product totals %v", got)
+	}
+}
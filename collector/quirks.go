@@ -0,0 +1,151 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rlmQuirks describes how the output of one range of rlmstat versions
+// differs from the historical lmutil layout the base regexes were written for.
+type rlmQuirks struct {
+	// name is exported as the quirks label of rlmlm_rlm_utility_version_info.
+	name string
+	// minMajor and maxMajor bound the rlmstat major versions, inclusive.
+	minMajor int
+	maxMajor int
+	// parseable is set when the utility understands the `-dq` switch.
+	parseable bool
+	// poolCountRegex matches the counters line below a feature in a
+	// "license pool status" block, nil if the version has no such blocks.
+	poolCountRegex *regexp.Regexp
+}
+
+var (
+	// legacyQuirks is used for lmutil compatible utilities and whenever the
+	// version couldn't be detected.
+	legacyQuirks = rlmQuirks{name: "legacy", minMajor: -1, maxMajor: 11}
+
+	// rlmQuirkTable lists the known rlmstat versions, oldest first. Versions
+	// newer than the last entry are handled like the last entry.
+	rlmQuirkTable = []rlmQuirks{
+		legacyQuirks,
+		{
+			name: "v12", minMajor: 12, maxMajor: 12, parseable: true,
+			poolCountRegex: rlmPoolCountV12Regex,
+		},
+		{
+			name: "v13-v16", minMajor: 13, maxMajor: 16, parseable: true,
+			poolCountRegex: rlmPoolCountRegex,
+		},
+	}
+
+	// versionProbes are tried in order until one prints a recognizable version.
+	versionProbes = [][]string{{"-version"}, {"-v"}}
+)
+
+// quirksForVersion returns the quirks of the given rlmstat version.
+func quirksForVersion(version string) rlmQuirks {
+	major := versionMajor(version)
+	if major < 0 {
+		return legacyQuirks
+	}
+	for _, q := range rlmQuirkTable {
+		if major >= q.minMajor && major <= q.maxMajor {
+			return q
+		}
+	}
+	return rlmQuirkTable[len(rlmQuirkTable)-1]
+}
+
+// parseHuman parses the human readable `rlmstat -a` output: the lmutil
+// compatible "Users of" sections plus, for RLM versions, the per ISV
+// "license pool status" blocks.
+func (q rlmQuirks) parseHuman(raw []byte) (*lmstatData, error) {
+	dataStr, err := splitOutput(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &lmstatData{
+		servers:      parseLmstatLicenseInfoServer(dataStr),
+		vendors:      parseLmstatLicenseInfoVendor(dataStr),
+		banners:      parseServerBanners(dataStr),
+		isvByFeature: parseFeatureISVs(dataStr),
+	}
+	data.features, data.usersByFeature, data.hostsByFeature, data.reservationsByFeature, data.checkoutsByFeature = parseLmstatLicenseInfoFeature(dataStr)
+	if q.poolCountRegex != nil {
+		q.parseLicensePools(dataStr, data)
+	}
+	if len(data.servers) == 0 && len(data.vendors) == 0 && len(data.features) == 0 {
+		return nil, errUnparseableOutput
+	}
+	return data, nil
+}
+
+// parseLicensePools adds the features of "license pool status" blocks to data.
+func (q rlmQuirks) parseLicensePools(outStr [][]string, data *lmstatData) {
+	var (
+		featureName string
+		// f is the feature of the current pool, set by its counters line.
+		f *feature
+	)
+	for _, line := range outStr {
+		lineJoined := strings.Join(line, "")
+		if matches := rlmPoolStatusRegex.FindStringSubmatch(lineJoined); matches != nil {
+			if _, ok := data.vendors[matches[1]]; !ok {
+				data.vendors[matches[1]] = &vendor{status: true, version: notFound}
+			}
+			featureName, f = "", nil
+			continue
+		}
+		if matches := rlmPoolFeatureRegex.FindStringSubmatch(lineJoined); matches != nil {
+			featureName, f = matches[1], nil
+			continue
+		}
+		if featureName == "" {
+			continue
+		}
+		if f == nil {
+			matches := q.poolCountRegex.FindStringSubmatch(lineJoined)
+			if matches == nil {
+				continue
+			}
+			issued, _ := strconv.ParseFloat(matches[q.poolCountRegex.SubexpIndex("count")], 64)
+			used, _ := strconv.ParseFloat(matches[q.poolCountRegex.SubexpIndex("inuse")], 64)
+			var ok bool
+			if f, ok = data.features[featureName]; ok {
+				// Several pools of the same feature add up.
+				f.issued += issued
+				f.used += used
+			} else {
+				f = &feature{issued: issued, used: used}
+				data.features[featureName] = f
+			}
+		}
+		for _, matches := range rlmPoolLimitRegex.FindAllStringSubmatch(lineJoined, -1) {
+			value, _ := strconv.ParseFloat(matches[2], 64)
+			switch matches[1] {
+			case "soft_limit":
+				f.softLimit += value
+				f.hasSoftLimit = true
+			case "overdraft":
+				f.overdraft += value
+				f.hasOverdraft = true
+			}
+		}
+	}
+}
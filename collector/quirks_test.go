@@ -0,0 +1,132 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+const (
+	testParseRlmV12 = "fixtures/lmstat_rlm_v12.txt"
+	testParseRlmV14 = "fixtures/lmstat_rlm_v14.txt"
+
+	testParseRlmV14Limits = "fixtures/lmstat_rlm_v14_limits.txt"
+)
+
+func TestQuirksForVersion(t *testing.T) {
+	for version, expected := range map[string]string{
+		notFound:     "legacy",
+		"v11.14.0.1": "legacy",
+		"v12.4":      "v12",
+		"v14.2":      "v13-v16",
+		"v16.0":      "v13-v16",
+		"v17.1":      "v13-v16",
+	} {
+		if q := quirksForVersion(version); q.name != expected {
+			t.Fatalf("quirksForVersion(%q) = %s, expected %s", version, q.name, expected)
+		}
+	}
+}
+
+func TestParseRlmVersionBanner(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseRlmV12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataStr, err := splitOutput(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := parseLmstatVersion(dataStr)
+	if info.version != "v12.4" || info.build != "2" || info.arch != notFound {
+		t.Fatalf("Unexpected values %s, %s, %s != v12.4, 2, %s", info.version, info.build, info.arch, notFound)
+	}
+}
+
+func TestParseLicensePools(t *testing.T) {
+	for fixture, version := range map[string]string{
+		testParseRlmV12: "v12.4",
+		testParseRlmV14: "v14.2",
+	} {
+		dataByte, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := quirksForVersion(version).parseHuman(dataByte)
+		if err != nil {
+			t.Fatalf("%s: %s", fixture, err)
+		}
+		if f := data.features["feature1"]; f == nil || f.issued != 144 || f.used != 3 {
+			t.Fatalf("%s: unexpected values for feature1: %+v", fixture, f)
+		}
+		if f := data.features["feature3"]; f == nil || f.issued != 25 || f.used != 0 {
+			t.Fatalf("%s: unexpected values for feature3: %+v", fixture, f)
+		}
+		if v := data.vendors["vendor1"]; v == nil || !v.status {
+			t.Fatalf("%s: unexpected values for vendor1: %+v", fixture, v)
+		}
+	}
+
+	// Only the v14 fixture has the version and platform lines of the banner.
+	dataByte, err := os.ReadFile(testParseRlmV14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := quirksForVersion("v14.2").parseHuman(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := serverBanner{server: "5053@host1", version: "v14.2", build: "2", platform: "x64_l1"}
+	if len(data.banners) != 1 || data.banners[0] != expected {
+		t.Fatalf("Unexpected server banners %+v", data.banners)
+	}
+
+	// The v12 layout isn't understood with the newer quirks and vice versa.
+	dataByte, err = os.ReadFile(testParseRlmV12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = quirksForVersion("v14.2").parseHuman(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.features) != 0 {
+		t.Fatalf("Unexpected features parsed from v12 layout with v14 quirks: %d", len(data.features))
+	}
+}
+
+func TestParseLicensePoolLimits(t *testing.T) {
+	dataByte, err := os.ReadFile(testParseRlmV14Limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := quirksForVersion("v14.2").parseHuman(dataByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := data.features["feature4"]; f == nil || f.issued != 15 || f.used != 11 || !f.hasSoftLimit || f.softLimit != 12 || f.hasOverdraft {
+		t.Fatalf("Unexpected values for feature4: %+v", f)
+	}
+	if f := data.features["feature5"]; f == nil || f.issued != 20 || f.used != 22 || f.hasSoftLimit || !f.hasOverdraft || f.overdraft != 2 {
+		t.Fatalf("Unexpected values for feature5: %+v", f)
+	}
+	if f := data.features["feature6"]; f == nil || f.hasSoftLimit || f.hasOverdraft {
+		t.Fatalf("Unexpected limits for feature6: %+v", f)
+	}
+}
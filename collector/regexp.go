@@ -6,10 +6,13 @@ var (
 	// Regexp to parse rlmstat output (compatible with the historical lmutil format).
 	lmutilVersionRegex = regexp.MustCompile(
 		`^rlmstat (?P<version>v[\d\.]+) build (?P<build>\d+) (?P<arch>[\w\_]+)`)
+	// RLM banner, e.g. "rlmutil v12.4BL2 Copyright (C) 2006-2018, Reprise Software, Inc."
+	rlmVersionRegex = regexp.MustCompile(
+		`^rlm\w* (?P<version>v[\d\.]+)BL(?P<build>\d+)`)
 	lmutilLicenseServersRegex = regexp.MustCompile(
-		`^License server status: (?P<servers>[\w\,\.\@\-]+)`)
+		`^License server status: (?P<servers>[\w\,\.\@\-\:\[\]]+)`)
 	lmutilLicenseServerStatusRegex = regexp.MustCompile(
-		`(?P<fqdn>[\w\.\-]+): license server (?P<status>\w+)(?P<master>\s` +
+		`(?P<fqdn>[\w\.\-]+|\[[[:xdigit:]\:\.]+\]|[[:xdigit:]]*:[[:xdigit:]\:\.]+): license server (?P<status>\w+)(?P<master>\s` +
 			`\(MASTER\))? (?P<version>v[\d\.]+)$`)
 	lmutilLicenseVendorStatusRegex = regexp.MustCompile(
 		`^\s+(?P<vendor>\w+): (?P<status>UP|DOWN) (?P<version>v[\d\.]+)$`)
@@ -17,16 +20,46 @@ var (
 		`^Users of (?P<name>.*):\s+\(Total of (?P<issued>\d+) \w+ issued\;\s+` +
 			`Total of (?P<used>\d+) \w+ in use\)$`)
 	lmutilLicenseFeatureUsageUserRegex = regexp.MustCompile(
-		`^\s+(?P<user>[\w[:print:]]+) [\w\-\.]+ [[:print:]]+ ?\(v[\w\.]+\) \([\w\-\.]+\/\d+ ` +
+		`^\s+(?P<user>[\w[:print:]]+) (?P<host>[\w\-\.]+) [[:print:]]+ ?\(v[\w\.]+\) \([\w\-\.]+\/\d+ ` +
 			`\d+\)\, start \w+ \d+\/\d+ \d+\:\d+(\,\s(?P<licenses>\d+)\s\w+|)` +
 			`(\s+\(linger\:\s\d+\s\/\s\d+\))?$`)
 	lmutilLicenseFeatureUsageUser2Regex = regexp.MustCompile(
-		`^\s+(?P<user>[\w[:print:]]+) [\w\-\.]+ ?\(v[\w\.]+\) \([\w\-\.]+\/\d+ ` +
+		`^\s+(?P<user>[\w[:print:]]+) (?P<host>[\w\-\.]+) ?\(v[\w\.]+\) \([\w\-\.]+\/\d+ ` +
 			`\d+\)\, start \w+ \d+\/\d+ \d+\:\d+(\,\s(?P<licenses>\d+)\s\w+|)` +
 			`(\s+\(linger\:\s\d+\s\/\s\d+\))?$`)
+	// ISV of the feature of a "Users of" section, e.g. `"feature1" v1.0, vendor: VENDOR1`.
+	lmutilLicenseFeatureVendorRegex = regexp.MustCompile(
+		`^\s+"(?P<feature>[^"]+)" v[\w\.]+, vendor: (?P<vendor>\w+)`)
+	lmutilLicenseFeatureQueuedRegex = regexp.MustCompile(
+		`^\s+(?P<user>[[:graph:]]+) .* queued for (?P<queued>\d+) licenses?$`)
 	lmutilLicenseFeatureGroupReservRegex = regexp.MustCompile(
 		`^(\s+|)(?P<reservation>\d+)\s+\w+\s+for\s+(HOST_GROUP|GROUP)\s+` +
 			`(?P<group>\w+).*$`)
+	// RLM server banner: the status line, the software version and the
+	// platform of the server.
+	rlmServerStatusRegex = regexp.MustCompile(
+		`^\s*rlm status on (?P<host>[\w\.\-\:\[\]]+) \(port (?P<port>\d+)\)`)
+	rlmServerVersionRegex = regexp.MustCompile(
+		`^\s*rlm software version (?P<version>v[\w\.]+)(?:\s+\(build:\s*(?P<build>\w+)\))?`)
+	rlmServerPlatformRegex = regexp.MustCompile(
+		`^\s*Platform type:\s*(?P<platform>[[:graph:]]+)`)
+	// RLM "license pool status" blocks.
+	rlmPoolStatusRegex = regexp.MustCompile(
+		`^\s*(?P<vendor>\w+) license pool status on (?P<host>[\w\.\-\:\[\]]+) \(port (?P<port>\d+)\)`)
+	rlmPoolFeatureRegex = regexp.MustCompile(
+		`^\s+(?P<feature>[[:graph:]]+) v(?P<version>[\w\.]+)$`)
+	rlmPoolCountV12Regex = regexp.MustCompile(
+		`^\s*count: (?P<count>\d+), # reservations: (?P<reservations>\d+), ` +
+			`(?:soft_limit: \d+, )?inuse: (?P<inuse>\d+)`)
+	rlmPoolCountRegex = regexp.MustCompile(
+		`^\s*count: (?P<count>\d+), # res: (?P<reservations>\d+), ` +
+			`(?:soft_limit: \d+, )?inuse: (?P<inuse>\d+)`)
+	// Soft limit and overdraft counters, on the counters line or the lines
+	// below it, for ISVs with elastic licensing.
+	rlmPoolLimitRegex = regexp.MustCompile(`\b(?P<key>soft_limit|overdraft): (?P<value>\d+)`)
+	// Seat count and expiration date on the counters line of a license pool.
+	rlmPoolCountValueRegex = regexp.MustCompile(`^\s*count: (?P<count>\d+),`)
+	rlmPoolExpRegex        = regexp.MustCompile(`\bexp: (?P<expires>[\w\-]+)`)
 	// rlmstat -c port@hostname -i
 	lmutilLicenseFeatureExpRegex = regexp.MustCompile(
 		`^(?P<feature>[[:graph:]]+)\s+(?P<version>[\d\.]+)\s+` +
@@ -0,0 +1,65 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sort"
+	"sync"
+)
+
+// reportPeaks keeps the peak usage of the features between two email
+// reports.
+var reportPeaks = &reportPeakTracker{peaks: make(map[featureKey]float64)}
+
+// FeaturePeak is the highest usage of a feature seen over a period.
+type FeaturePeak struct {
+	License string
+	Feature string
+	Used    float64
+}
+
+type reportPeakTracker struct {
+	mu    sync.Mutex
+	peaks map[featureKey]float64
+}
+
+func (t *reportPeakTracker) observe(license, feature string, used float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := featureKey{license, feature}
+	if peak, ok := t.peaks[key]; !ok || used > peak {
+		t.peaks[key] = used
+	}
+}
+
+// TakeReportPeaks returns the peak usage of every feature collected since
+// the previous call, sorted by license and feature, and starts over.
+func TakeReportPeaks() []FeaturePeak {
+	reportPeaks.mu.Lock()
+	peaks := reportPeaks.peaks
+	reportPeaks.peaks = make(map[featureKey]float64)
+	reportPeaks.mu.Unlock()
+
+	list := make([]FeaturePeak, 0, len(peaks))
+	for key, used := range peaks {
+		list = append(list, FeaturePeak{License: key.license, Feature: key.feature, Used: used})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].License != list[j].License {
+			return list[i].License < list[j].License
+		}
+		return list[i].Feature < list[j].Feature
+	})
+	return list
+}
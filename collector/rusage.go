@@ -0,0 +1,79 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	subprocessCPUDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "subprocess", "cpu_seconds_total"),
+		"rlmlm_exporter: CPU time used by completed rlmstat processes, by mode.",
+		[]string{"mode"}, nil,
+	)
+	subprocessMaxRSSDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "subprocess", "max_rss_bytes"),
+		"rlmlm_exporter: Largest resident set size of any completed rlmstat process.",
+		nil, nil,
+	)
+	subprocessRunsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "subprocess", "runs_total"),
+		"rlmlm_exporter: Number of completed rlmstat processes.",
+		nil, nil,
+	)
+
+	usage = &subprocessUsage{}
+)
+
+// subprocessUsage accumulates the resource usage of completed processes.
+type subprocessUsage struct {
+	mu     sync.Mutex
+	user   time.Duration
+	system time.Duration
+	maxRSS float64
+	runs   uint64
+}
+
+// record adds the usage of a completed process. state may be nil if the
+// process couldn't be started.
+func (u *subprocessUsage) record(state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.runs++
+	u.user += state.UserTime()
+	u.system += state.SystemTime()
+	if rss, ok := maxRSSBytes(state); ok && rss > u.maxRSS {
+		u.maxRSS = rss
+	}
+}
+
+// collect sends the usage metrics to ch.
+func (u *subprocessUsage) collect(ch chan<- prometheus.Metric) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	ch <- constMetric(subprocessCPUDesc, prometheus.CounterValue, u.user.Seconds(), "user")
+	ch <- constMetric(subprocessCPUDesc, prometheus.CounterValue, u.system.Seconds(), "system")
+	ch <- constMetric(subprocessMaxRSSDesc, prometheus.GaugeValue, u.maxRSS)
+	ch <- constMetric(subprocessRunsDesc, prometheus.CounterValue, float64(u.runs))
+}
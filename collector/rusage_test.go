@@ -0,0 +1,40 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package collector
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestSubprocessUsage(t *testing.T) {
+	u := &subprocessUsage{}
+	u.record(nil)
+
+	cmd := exec.Command("sh", "-c", "true")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Couldn't run sh: %v", err)
+	}
+	u.record(cmd.ProcessState)
+
+	if u.runs != 1 {
+		t.Fatalf("Expected 1 run, got %d", u.runs)
+	}
+	if u.maxRSS <= 0 {
+		t.Fatalf("Expected a positive max RSS, got %v", u.maxRSS)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	commandSandbox = kingpin.Flag("command.sandbox",
+		"Run rlmstat with a read-only filesystem, a seccomp filter denying system administration syscalls and resource limits (Linux only).").Bool()
+	sandboxCPUTime = kingpin.Flag("command.sandbox.cpu-time",
+		"CPU time an rlmstat run may use in the sandbox before it is killed. Zero disables the limit.").Default("30s").Duration()
+	sandboxMemory = kingpin.Flag("command.sandbox.memory",
+		"Address space an rlmstat run may use in the sandbox. Zero disables the limit.").Default("1GB").Bytes()
+	sandboxMaxOutput = kingpin.Flag("command.sandbox.max-output",
+		"Output an rlmstat run may print in the sandbox before it is killed. Zero disables the limit.").Default("16MB").Bytes()
+
+	errOutputTooLarge = errors.New("rlmstat output exceeds --command.sandbox.max-output")
+)
+
+// sandboxLimits are the resource limits of a sandboxed process, zero for no
+// limit.
+type sandboxLimits struct {
+	cpuSeconds uint64
+	memory     uint64
+	output     uint64
+}
+
+func (l sandboxLimits) String() string {
+	return fmt.Sprintf("%d:%d:%d", l.cpuSeconds, l.memory, l.output)
+}
+
+func parseSandboxLimits(s string) (sandboxLimits, error) {
+	var l sandboxLimits
+	if _, err := fmt.Sscanf(s, "%d:%d:%d", &l.cpuSeconds, &l.memory, &l.output); err != nil {
+		return l, fmt.Errorf("invalid sandbox limits %q: %w", s, err)
+	}
+	return l, nil
+}
+
+func flagSandboxLimits() sandboxLimits {
+	return sandboxLimits{
+		cpuSeconds: uint64(math.Ceil(sandboxCPUTime.Seconds())),
+		memory:     uint64(*sandboxMemory),
+		output:     uint64(*sandboxMaxOutput),
+	}
+}
+
+// runSandboxed runs cmd in the sandbox and returns its output like
+// cmd.Output, killing it once it prints more than the output limit. timer
+// times the run.
+func runSandboxed(cmd *exec.Cmd, limits sandboxLimits, timer *commandTimer) ([]byte, error) {
+	if err := sandbox(cmd, limits); err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &cappedBuffer{max: limits.output}
+	if cmd.Stderr != nil {
+		// The caller captures stderr too.
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	} else {
+		cmd.Stderr = stderr
+	}
+	if err := timer.startCommand(cmd); err != nil {
+		return nil, err
+	}
+
+	r := timer.reader(stdout)
+	if limits.output > 0 {
+		r = io.LimitReader(r, int64(limits.output)+1)
+	}
+	out, readErr := io.ReadAll(r)
+	if limits.output > 0 && uint64(len(out)) > limits.output {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return out[:limits.output], errOutputTooLarge
+	}
+	if readErr != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return out, readErr
+	}
+	if err := timer.waitCommand(cmd); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitErr.Stderr = stderr.Bytes()
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+// cappedBuffer keeps the first max bytes written to it, or all of them if
+// max is zero, and drops the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	max uint64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.max > 0 {
+		if room := b.max - uint64(b.Len()); uint64(len(p)) > room {
+			b.Buffer.Write(p[:room])
+			return len(p), nil
+		}
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,305 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The sandbox is set up by the exporter itself, started again in new user
+// and mount namespaces with sandboxArg0 as its name: it applies the limits
+// passed in sandboxLimitsEnv, makes every mount read-only, installs the
+// seccomp filter and then executes the utility.
+const (
+	sandboxArg0      = "rlmlm-sandbox"
+	sandboxLimitsEnv = "RLMLM_SANDBOX_LIMITS"
+	// sandboxExitCode is returned when the sandbox couldn't be set up, like
+	// shells do for commands that can't be executed.
+	sandboxExitCode = 126
+)
+
+// sandboxArches maps the supported architectures to their audit
+// architecture, checked by the seccomp filter as syscall numbers differ.
+var sandboxArches = map[string]uint32{
+	"386":     unix.AUDIT_ARCH_I386,
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"arm":     unix.AUDIT_ARCH_ARM,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"ppc64le": unix.AUDIT_ARCH_PPC64LE,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+}
+
+// sandboxDeniedSyscalls fail with EPERM in the sandbox. Utilities only need
+// to read files and talk to license servers, these administer the system,
+// escape the namespaces or inspect other processes.
+var sandboxDeniedSyscalls = []uint32{
+	unix.SYS_ACCT,
+	unix.SYS_ADD_KEY,
+	unix.SYS_ADJTIMEX,
+	unix.SYS_BPF,
+	unix.SYS_CHROOT,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_FSCONFIG,
+	unix.SYS_FSMOUNT,
+	unix.SYS_FSOPEN,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_KEYCTL,
+	unix.SYS_MOUNT,
+	unix.SYS_MOUNT_SETATTR,
+	unix.SYS_MOVE_MOUNT,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_OPEN_TREE,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_PTRACE,
+	unix.SYS_REBOOT,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_SETDOMAINNAME,
+	unix.SYS_SETHOSTNAME,
+	unix.SYS_SETNS,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_SWAPOFF,
+	unix.SYS_SWAPON,
+	unix.SYS_UMOUNT2,
+	unix.SYS_UNSHARE,
+	unix.SYS_USERFAULTFD,
+}
+
+// sandboxCloneFlags are the clone flags creating namespaces.
+const sandboxCloneFlags = unix.CLONE_NEWCGROUP | unix.CLONE_NEWIPC | unix.CLONE_NEWNET | unix.CLONE_NEWNS |
+	unix.CLONE_NEWPID | unix.CLONE_NEWUSER | unix.CLONE_NEWUTS
+
+// SandboxInit runs the sandbox setup when the exporter was started for it
+// by --command.sandbox, in which case it doesn't return. It must be called
+// first thing in main.
+func SandboxInit() {
+	if len(os.Args) < 3 || os.Args[0] != sandboxArg0 || os.Getenv(sandboxLimitsEnv) == "" {
+		return
+	}
+	// The filter and no_new_privs apply to the thread executing the utility.
+	runtime.LockOSThread()
+	err := enterSandbox(os.Args[1], os.Args[2:])
+	fmt.Fprintf(os.Stderr, "rlmlm_exporter: couldn't set up the sandbox: %s\n", err)
+	os.Exit(sandboxExitCode)
+}
+
+// sandbox turns cmd into a run of the exporter setting up the sandbox for
+// the original command.
+func sandbox(cmd *exec.Cmd, limits sandboxLimits) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	if _, ok := sandboxArches[runtime.GOARCH]; !ok {
+		return fmt.Errorf("--command.sandbox isn't supported on %s", runtime.GOARCH)
+	}
+	cmd.Args = append([]string{sandboxArg0, cmd.Path}, cmd.Args...)
+	// Started from /proc so that it works even if the binary was replaced.
+	cmd.Path = "/proc/self/exe"
+	cmd.Env = append(cmd.Env, sandboxLimitsEnv+"="+limits.String())
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		// The user namespace grants the rights to remount in the mount
+		// namespace without privileges on the host.
+		Cloneflags:                 syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+	return nil
+}
+
+// enterSandbox restricts the process and executes path, only returning on
+// errors.
+func enterSandbox(path string, argv []string) error {
+	limits, err := parseSandboxLimits(os.Getenv(sandboxLimitsEnv))
+	if err != nil {
+		return err
+	}
+	if err := os.Unsetenv(sandboxLimitsEnv); err != nil {
+		return err
+	}
+	if err := setSandboxLimits(limits); err != nil {
+		return err
+	}
+	if err := remountReadOnly(); err != nil {
+		return err
+	}
+	if err := installSeccompFilter(); err != nil {
+		return err
+	}
+	return unix.Exec(path, argv, os.Environ())
+}
+
+func setSandboxLimits(limits sandboxLimits) error {
+	for _, l := range []struct {
+		resource int
+		name     string
+		value    uint64
+	}{
+		{unix.RLIMIT_CPU, "CPU time", limits.cpuSeconds},
+		{unix.RLIMIT_AS, "memory", limits.memory},
+		// Files written count towards the output too.
+		{unix.RLIMIT_FSIZE, "file size", limits.output},
+	} {
+		if l.value == 0 {
+			continue
+		}
+		if err := unix.Setrlimit(l.resource, &unix.Rlimit{Cur: l.value, Max: l.value}); err != nil {
+			return fmt.Errorf("couldn't limit %s: %w", l.name, err)
+		}
+	}
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{}); err != nil {
+		return fmt.Errorf("couldn't disable core dumps: %w", err)
+	}
+	return nil
+}
+
+// remountReadOnly makes every mount of the mount namespace read-only. Mounts
+// other than the root one which can't be remounted are left as they are.
+func remountReadOnly() error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("couldn't make the mounts private: %w", err)
+	}
+	mounts, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	for _, mount := range mounts {
+		var st unix.Statfs_t
+		err := unix.Statfs(mount, &st)
+		if err == nil {
+			// Flags locked by the host must be kept when remounting.
+			flags := uintptr(st.Flags) & (unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC |
+				unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME)
+			err = unix.Mount("", mount, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|flags, "")
+		}
+		if err != nil && mount == "/" {
+			return fmt.Errorf("couldn't remount / read-only: %w", err)
+		}
+	}
+	return nil
+}
+
+// mountPoints lists the mount points of the process.
+func mountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, unescapeMountPoint(fields[4]))
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPoint decodes the octal escapes of spaces and other special
+// characters in mountinfo paths.
+func unescapeMountPoint(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// seccompFilter returns the BPF program denying sandboxDeniedSyscalls, the
+// clone flags creating namespaces and syscalls of other architectures.
+func seccompFilter(arch uint32) []unix.SockFilter {
+	const (
+		offsetNr   = 0
+		offsetArch = 4
+		// The low 32 bits of the first argument on little endian
+		// architectures, the clone flags.
+		offsetArg0 = 16
+
+		// x32 syscalls on amd64 have this bit set.
+		x32SyscallBit = 0x40000000
+	)
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+	deny := stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM))
+
+	filter := []unix.SockFilter{
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetArch),
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, arch, 1, 0),
+		stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetNr),
+		jump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, x32SyscallBit, 0, 1),
+		deny,
+	}
+	for _, nr := range sandboxDeniedSyscalls {
+		filter = append(filter, jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, 0, 1), deny)
+	}
+	// clone3 passes its flags in memory the filter can't read, C libraries
+	// fall back to clone on ENOSYS.
+	filter = append(filter,
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE3, 0, 1),
+		stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)),
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, unix.SYS_CLONE, 0, 3),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetArg0),
+		jump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, sandboxCloneFlags, 0, 1),
+		deny,
+		stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW),
+	)
+	return filter
+}
+
+func installSeccompFilter() error {
+	filter := seccompFilter(sandboxArches[runtime.GOARCH])
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("couldn't set no_new_privs: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("couldn't install the seccomp filter: %w", err)
+	}
+	return nil
+}
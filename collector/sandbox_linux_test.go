@@ -0,0 +1,87 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary set up the sandbox like the exporter does.
+func TestMain(m *testing.M) {
+	SandboxInit()
+	os.Exit(m.Run())
+}
+
+func TestSandbox(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh in PATH")
+	}
+	oldPath, oldSandbox, oldMaxOutput := *rlmstatPath, *commandSandbox, *sandboxMaxOutput
+	t.Cleanup(func() {
+		*rlmstatPath, *commandSandbox, *sandboxMaxOutput = oldPath, oldSandbox, oldMaxOutput
+	})
+	*rlmstatPath, *commandSandbox, *sandboxMaxOutput = sh, true, 1024
+
+	run := func(script string) ([]byte, error) {
+		return runRlmstatCommand(context.Background(), priorityStatus, nil, "-c", script)
+	}
+
+	out, err := run("echo $LANG")
+	if err != nil {
+		if strings.Contains(err.Error(), "operation not permitted") {
+			t.Skipf("user namespaces unavailable: %v", err)
+		}
+		t.Fatalf("Unexpected error: %v %s", err, out)
+	}
+	if s := strings.TrimSpace(string(out)); s != "C" {
+		t.Fatalf("Unexpected output %q", s)
+	}
+
+	path := filepath.Join(t.TempDir(), "written")
+	out, err = run("echo x > " + shellQuote(path))
+	if err == nil || !strings.Contains(string(out), "Read-only file system") {
+		t.Fatalf("Expected the write to fail on a read-only filesystem, got %v %q", err, out)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Fatalf("Unexpected file written from the sandbox")
+	}
+
+	if unshare, err := exec.LookPath("unshare"); err == nil {
+		out, err = run(shellQuote(unshare) + " -U true")
+		if err == nil {
+			t.Fatalf("Expected unshare to be denied, got %q", out)
+		}
+	}
+
+	out, err = run("while :; do echo 0123456789; done")
+	if !errors.Is(err, errOutputTooLarge) || len(out) != 1024 {
+		t.Fatalf("Expected the output to be cut at 1024 bytes, got %v after %d bytes", err, len(out))
+	}
+}
+
+func TestUnescapeMountPoint(t *testing.T) {
+	if s := unescapeMountPoint(`/mnt/my\040licenses`); s != "/mnt/my licenses" {
+		t.Fatalf("Unexpected mount point %q", s)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	schedulerQueueDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scheduler", "queue_depth"),
+		"rlmlm_exporter: Number of background license collections due and not finished yet.",
+		nil,
+		nil,
+	)
+	schedulerLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scheduler", "lag_seconds"),
+		"rlmlm_exporter: Delay between the scheduled and the actual start of the last background collection of a license.",
+		[]string{"license_name"},
+		nil,
+	)
+	schedulerSkippedRunsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scheduler", "skipped_runs_total"),
+		"rlmlm_exporter: Background collections of a license skipped because the previous one was still running.",
+		[]string{"license_name"},
+		nil,
+	)
+	schedulerRetriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scheduler", "retries_total"),
+		"rlmlm_exporter: Background collections of a license retried after a failure, ahead of the next scheduled one.",
+		[]string{"license_name"},
+		nil,
+	)
+	schedulerNextRunDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scheduler", "next_run_timestamp_seconds"),
+		"rlmlm_exporter: Time of the next scheduled background collection of a license, in seconds since the epoch.",
+		[]string{"license_name"},
+		nil,
+	)
+)
+
+// licenseSchedule is the scheduling state of a license.
+type licenseSchedule struct {
+	lag     time.Duration
+	skipped float64
+	retries float64
+	next    time.Time
+}
+
+// schedulerStats tracks how well the background collections keep up with
+// their interval.
+type schedulerStats struct {
+	mu       sync.Mutex
+	running  int
+	licenses map[string]*licenseSchedule
+}
+
+func newSchedulerStats() *schedulerStats {
+	return &schedulerStats{licenses: make(map[string]*licenseSchedule)}
+}
+
+func (s *schedulerStats) licenseLocked(name string) *licenseSchedule {
+	l, ok := s.licenses[name]
+	if !ok {
+		l = &licenseSchedule{}
+		s.licenses[name] = l
+	}
+	return l
+}
+
+// start records the start of a collection of license. lag is the delay
+// since it was scheduled, negative for collections out of schedule.
+func (s *schedulerStats) start(license string, lag time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running++
+	if lag >= 0 {
+		s.licenseLocked(license).lag = lag
+	}
+}
+
+// finish records the end of a collection of license scheduled at
+// scheduled and returns the time of the next collection: the first time
+// scheduled plus a multiple of interval after now. The runs in between are
+// counted as skipped.
+func (s *schedulerStats) finish(license string, scheduled time.Time, interval time.Duration, now time.Time) time.Time {
+	next := scheduled.Add(interval)
+	var skipped float64
+	for !next.After(now) {
+		next = next.Add(interval)
+		skipped++
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	l := s.licenseLocked(license)
+	l.skipped += skipped
+	l.next = next
+	return next
+}
+
+// plan records the first scheduled collection of license.
+func (s *schedulerStats) plan(license string, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.licenseLocked(license).next = next
+}
+
+// retried counts a retry of a failed collection of license.
+func (s *schedulerStats) retried(license string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.licenseLocked(license).retries++
+}
+
+// finishUnscheduled records the end of a collection out of schedule.
+func (s *schedulerStats) finishUnscheduled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+}
+
+func (s *schedulerStats) describe(ch chan<- *prometheus.Desc) {
+	ch <- schedulerQueueDepthDesc
+	ch <- schedulerLagDesc
+	ch <- schedulerSkippedRunsDesc
+	ch <- schedulerRetriesDesc
+	ch <- schedulerNextRunDesc
+}
+
+func (s *schedulerStats) collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch <- constMetric(schedulerQueueDepthDesc, prometheus.GaugeValue, float64(s.running))
+	for name, l := range s.licenses {
+		ch <- constMetric(schedulerLagDesc, prometheus.GaugeValue, l.lag.Seconds(), name)
+		ch <- constMetric(schedulerSkippedRunsDesc, prometheus.CounterValue, l.skipped, name)
+		ch <- constMetric(schedulerRetriesDesc, prometheus.CounterValue, l.retries, name)
+		if !l.next.IsZero() {
+			ch <- constMetric(schedulerNextRunDesc, prometheus.GaugeValue,
+				float64(l.next.UnixNano())/1e9, name)
+		}
+	}
+}
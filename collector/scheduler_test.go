@@ -0,0 +1,50 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerStats(t *testing.T) {
+	s := newSchedulerStats()
+	start := time.Unix(1000, 0)
+
+	s.start("app1", 2*time.Second)
+	if s.running != 1 {
+		t.Fatalf("Expected 1 running collection, got %d", s.running)
+	}
+	// Finished within the interval.
+	next := s.finish("app1", start, time.Minute, start.Add(10*time.Second))
+	if !next.Equal(start.Add(time.Minute)) {
+		t.Fatalf("Unexpected next run %v", next)
+	}
+
+	// A collection lasting 2.5 intervals skips the two runs it overlaps.
+	s.start("app1", 0)
+	next = s.finish("app1", next, time.Minute, next.Add(150*time.Second))
+	if !next.Equal(start.Add(4 * time.Minute)) {
+		t.Fatalf("Unexpected next run after skipped runs %v", next)
+	}
+
+	// Collections out of schedule don't change the lag.
+	s.start("app1", -1)
+	s.finishUnscheduled()
+
+	l := s.licenses["app1"]
+	if s.running != 0 || l.skipped != 2 || l.lag != 0 || !l.next.Equal(next) {
+		t.Fatalf("Unexpected state: running %d, %+v", s.running, l)
+	}
+}
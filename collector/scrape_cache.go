@@ -0,0 +1,135 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// scrapeCollectorsSize bounds the collectors kept for distinct collect[] and
+// license[] filters.
+const scrapeCollectorsSize = 16
+
+var scrapeCollectors = newCollectorLRU(scrapeCollectorsSize, func(filters, licenses []string) (*RlmlmCollector, error) {
+	return NewRlmlmCollector(defaultConfig, defaultLogger, WithFilters(filters...), WithLicenses(licenses...))
+})
+
+// collectorLRU keeps the most recently used collectors by filters, so that
+// scrapes don't run the collector factories again. The collectors are
+// stateless, scrapes share them.
+//
+// Collectors are built outside mu, since building them takes the lock of the
+// collector states, which SetCollectorEnabled holds while it resets. Scrapes
+// with the same filters wait for a single build.
+type collectorLRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	calls   map[string]*lruCall
+	// generation counts the resets, builds started before one aren't kept.
+	generation uint64
+	build      func(filters, licenses []string) (*RlmlmCollector, error)
+}
+
+// lruCall is a build in progress, done is closed once it returns.
+type lruCall struct {
+	done      chan struct{}
+	collector *RlmlmCollector
+	err       error
+}
+
+type lruEntry struct {
+	key       string
+	collector *RlmlmCollector
+}
+
+func newCollectorLRU(size int, build func(filters, licenses []string) (*RlmlmCollector, error)) *collectorLRU {
+	return &collectorLRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		calls:   make(map[string]*lruCall),
+		build:   build,
+	}
+}
+
+// get returns the collector for filters and licenses, building it if it
+// isn't kept. Failures aren't kept, they come from invalid filters.
+func (l *collectorLRU) get(filters, licenses []string) (*RlmlmCollector, error) {
+	key := filtersKey(filters) + "|" + filtersKey(licenses)
+
+	l.mu.Lock()
+	if e, ok := l.entries[key]; ok {
+		l.order.MoveToFront(e)
+		l.mu.Unlock()
+		return e.Value.(*lruEntry).collector, nil
+	}
+	if call, ok := l.calls[key]; ok {
+		l.mu.Unlock()
+		<-call.done
+		return call.collector, call.err
+	}
+	call := &lruCall{done: make(chan struct{})}
+	l.calls[key] = call
+	generation := l.generation
+	l.mu.Unlock()
+
+	call.collector, call.err = l.build(filters, licenses)
+
+	l.mu.Lock()
+	if l.calls[key] == call {
+		delete(l.calls, key)
+	}
+	if call.err == nil && generation == l.generation {
+		l.entries[key] = l.order.PushFront(&lruEntry{key: key, collector: call.collector})
+		if l.order.Len() > l.size {
+			oldest := l.order.Back()
+			l.order.Remove(oldest)
+			delete(l.entries, oldest.Value.(*lruEntry).key)
+		}
+	}
+	l.mu.Unlock()
+	close(call.done)
+	return call.collector, call.err
+}
+
+// reset drops every collector, after the configuration or the enabled
+// collectors changed.
+func (l *collectorLRU) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.entries = make(map[string]*list.Element)
+	l.calls = make(map[string]*lruCall)
+	l.generation++
+}
+
+// filtersKey identifies a set of filters regardless of their order.
+func filtersKey(filters []string) string {
+	sorted := append([]string(nil), filters...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// ScrapeCollector returns the collector for a scrape with the collect[]
+// filters and the license[] licenses, none for all enabled collectors and
+// all licenses. It is built once and shared until the configuration or the
+// enabled collectors change.
+func ScrapeCollector(filters, licenses []string) (*RlmlmCollector, error) {
+	return scrapeCollectors.get(filters, licenses)
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCollectorLRU(t *testing.T) {
+	builds := make(map[string]int)
+	l := newCollectorLRU(2, func(filters, licenses []string) (*RlmlmCollector, error) {
+		key := filtersKey(filters)
+		if len(licenses) > 0 {
+			key += "|" + filtersKey(licenses)
+		}
+		if key == "missing" {
+			return nil, errors.New("missing collector: missing")
+		}
+		builds[key]++
+		return &RlmlmCollector{}, nil
+	})
+	get := func(filters ...string) *RlmlmCollector {
+		t.Helper()
+		c, err := l.get(filters, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	all := get()
+	if get() != all {
+		t.Fatal("Expected the unfiltered collector to be reused")
+	}
+	get("lmstat", "lint")
+	get("lint", "lmstat")
+	if builds[""] != 1 || builds["lint,lmstat"] != 1 {
+		t.Fatalf("Expected a single build per set of filters, got %v", builds)
+	}
+
+	// The least recently used collector is dropped, here lint,lmstat.
+	get()
+	get("lmstat")
+	get("lint", "lmstat")
+	if builds[""] != 1 || builds["lint,lmstat"] != 2 {
+		t.Fatalf("Unexpected builds after eviction %v", builds)
+	}
+
+	if _, err := l.get([]string{"missing"}, nil); err == nil {
+		t.Fatal("Expected an error for a missing collector")
+	}
+	if l.order.Len() != 2 {
+		t.Fatalf("Expected failures not to be kept, got %d entries", l.order.Len())
+	}
+
+	// Licenses are part of the key.
+	if c, _ := l.get(nil, []string{"app2", "app1"}); c == all {
+		t.Fatal("Expected a distinct collector for a license subset")
+	}
+	if _, err := l.get(nil, []string{"app1", "app2"}); err != nil || builds["|app1,app2"] != 1 {
+		t.Fatalf("Expected a single build per set of licenses, got %v: %v", builds, err)
+	}
+
+	l.reset()
+	if get() == all {
+		t.Fatal("Expected a new collector after reset")
+	}
+}
+
+func TestCollectorLRUResetDuringBuild(t *testing.T) {
+	building := make(chan struct{})
+	release := make(chan struct{})
+	builds := 0
+	l := newCollectorLRU(2, func(filters, licenses []string) (*RlmlmCollector, error) {
+		builds++
+		if builds == 1 {
+			close(building)
+			<-release
+		}
+		return &RlmlmCollector{}, nil
+	})
+
+	got := make(chan *RlmlmCollector)
+	go func() {
+		c, _ := l.get([]string{"lmstat"}, nil)
+		got <- c
+	}()
+	<-building
+
+	// A reset doesn't wait for the build in progress.
+	reset := make(chan struct{})
+	go func() {
+		l.reset()
+		close(reset)
+	}()
+	select {
+	case <-reset:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected reset not to wait for a build")
+	}
+	close(release)
+	stale := <-got
+
+	// The build started before the reset isn't kept.
+	if c, err := l.get([]string{"lmstat"}, nil); err != nil || c == stale || builds != 2 {
+		t.Fatalf("Expected a new collector after the reset, got %d builds: %v", builds, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Codes of the errors a license server reports, exported as the code label of
+// rlmlm_server_error.
+const (
+	serverErrorBadHostid     = "bad_hostid"
+	serverErrorClockWindback = "clock_windback"
+	serverErrorAllInUse      = "all_in_use"
+	serverErrorCommunication = "communication_error"
+	serverErrorUnknown       = "unknown"
+)
+
+var (
+	serverErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "error"),
+		"Whether the license server of a license reports an error, labeled by the error code.",
+		[]string{"license_name", "code"},
+		nil,
+	)
+
+	// serverErrorPatterns classify the error messages of rlmstat, the first
+	// match wins.
+	serverErrorPatterns = []struct {
+		code  string
+		regex *regexp.Regexp
+	}{
+		{serverErrorBadHostid, regexp.MustCompile(`(?i)\b(bad|wrong|invalid) host ?id\b|\bhost ?id (mismatch|does not match)`)},
+		{serverErrorClockWindback, regexp.MustCompile(`(?i)\bclock (windback|setback|set back|has been set back)\b|\bwindback\b`)},
+		{serverErrorAllInUse, regexp.MustCompile(`(?i)\ball licenses in use\b|\blicensed number of users already reached\b`)},
+		{serverErrorCommunication, regexp.MustCompile(`(?i)\bcommunications? error\b|\bcannot (connect to|read data from|write data to) license server\b`)},
+	}
+
+	// serverErrorCodes are the codes exported for every license, so that
+	// rlmlm_server_error is 0 rather than missing while the server is fine.
+	serverErrorCodes = []string{
+		serverErrorBadHostid, serverErrorClockWindback, serverErrorAllInUse,
+		serverErrorCommunication, serverErrorUnknown,
+	}
+)
+
+// serverError is a license server reporting "License server status: Error",
+// classified by code.
+type serverError struct {
+	code string
+	err  error
+}
+
+func (e *serverError) Error() string { return e.err.Error() }
+
+func (e *serverError) Unwrap() error { return e.err }
+
+// classifyServerError returns the code of the error reported in the rlmstat
+// output out, serverErrorUnknown if it isn't one of serverErrorPatterns.
+func classifyServerError(out []byte) string {
+	for _, p := range serverErrorPatterns {
+		if p.regex.Match(out) {
+			return p.code
+		}
+	}
+	return serverErrorUnknown
+}
+
+// exportServerError sends rlmlm_server_error for every code, 1 for code and 0
+// for the others. An empty code reports a healthy server.
+func exportServerError(ch chan<- prometheus.Metric, license, code string) {
+	for _, c := range serverErrorCodes {
+		var value float64
+		if c == code {
+			value = 1
+		}
+		ch <- constMetric(serverErrorDesc, prometheus.GaugeValue, value, license, c)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestClassifyServerError(t *testing.T) {
+	for out, expected := range map[string]string{
+		"License server status: Error\n  Bad hostid on license server":                  serverErrorBadHostid,
+		"License server status: Error\n  License server hostid mismatch":                serverErrorBadHostid,
+		"License server status: Error\n  Clock windback detected":                       serverErrorClockWindback,
+		"License server status: Error\n  System clock has been set back":                serverErrorClockWindback,
+		"License server status: Error\n  All licenses in use":                           serverErrorAllInUse,
+		"License server status: Error\n  Communications error with license server":      serverErrorCommunication,
+		"License server status: Error\n  Cannot connect to license server (-17)":        serverErrorCommunication,
+		"License server status: Error\n  Something nobody has seen before":              serverErrorUnknown,
+		"License server status: Error\n  hostid localhost: Communications error (-103)": serverErrorCommunication,
+	} {
+		if code := classifyServerError([]byte(out)); code != expected {
+			t.Fatalf("Expected %s for %q, got %s", expected, out, code)
+		}
+	}
+}
+
+func TestExportServerError(t *testing.T) {
+	ch := make(chan prometheus.Metric, len(serverErrorCodes))
+	exportServerError(ch, "app1", serverErrorClockWindback)
+	close(ch)
+
+	values := make(map[string]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range pb.GetLabel() {
+			if l.GetName() == "code" {
+				values[l.GetValue()] = pb.GetGauge().GetValue()
+			}
+		}
+	}
+	if len(values) != len(serverErrorCodes) {
+		t.Fatalf("Expected every code to be exported, got %v", values)
+	}
+	for code, value := range values {
+		if (code == serverErrorClockWindback) != (value == 1) {
+			t.Fatalf("Unexpected value %v for code %s", value, code)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var rlmstatSnapshot = kingpin.Flag("rlmstat.snapshot",
+	"Collect the expiration dates of a license from the license pools of the same rlmstat -a run as its usage instead of running rlmstat -i, so that all the metrics of the license come from one consistent snapshot. Needs RLM v12 or later; the parseable rlmstat output isn't used, it has no expiration dates.").Bool()
+
+// snapshotLicense returns whether every family of license is collected from
+// the human readable `rlmstat -a` output, which the collectors of a scrape
+// share through the command cache. Only the license pool blocks of RLM
+// carry expiration dates, so licenses read with a vendor parser or by older
+// utilities keep their separate runs, as do those with query_features, which
+// don't run `rlmstat -a`.
+func snapshotLicense(q rlmQuirks, license config.License) bool {
+	return *rlmstatSnapshot && license.Parser == "" && !license.QueryFeatures && q.poolCountRegex != nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxStderrCapture bounds the stderr kept of an rlmstat run.
+	maxStderrCapture = 64 << 10
+	// maxStderrInError bounds the stderr quoted in errors and logs.
+	maxStderrInError = 512
+
+	stderrClassOther = "other"
+)
+
+var (
+	rlmstatStderrDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "rlmstat", "stderr_lines_total"),
+		"rlmlm_exporter: Lines rlmstat printed on stderr, classified by the kind of message.",
+		[]string{"class"},
+		nil,
+	)
+
+	stderrLines = newLicenseCounter(rlmstatStderrDesc)
+
+	// stderrPatterns classify the lines rlmstat prints on stderr, the first
+	// match wins and other lines are counted as stderrClassOther.
+	stderrPatterns = []struct {
+		class string
+		regex *regexp.Regexp
+	}{
+		{"communication_error", regexp.MustCompile(`(?i)\bcommunications? error\b`)},
+		{"connection_refused", regexp.MustCompile(`(?i)\bconnection refused\b|\bcannot connect to license server\b`)},
+		{"timeout", regexp.MustCompile(`(?i)\btimed out\b|\btimeout\b`)},
+		{"license_file", regexp.MustCompile(`(?i)\b(cannot|can't|unable to) (open|read|find) license file\b|\bno license file\b`)},
+		{"warning", regexp.MustCompile(`(?i)\bwarning\b`)},
+	}
+)
+
+// stderrError is a failed rlmstat run quoting what it printed on stderr.
+type stderrError struct {
+	err    error
+	stderr string
+}
+
+func (e *stderrError) Error() string { return e.err.Error() + ": " + e.stderr }
+
+func (e *stderrError) Unwrap() error { return e.err }
+
+// withStderr returns err quoting stderr, if there is any.
+func withStderr(err error, stderr []byte) error {
+	s := quoteStderr(stderr)
+	if s == "" {
+		return err
+	}
+	return &stderrError{err, s}
+}
+
+// quoteStderr returns stderr on a single line, truncated to
+// maxStderrInError bytes.
+func quoteStderr(stderr []byte) string {
+	s := strings.Join(strings.Fields(string(stderr)), " ")
+	if len(s) > maxStderrInError {
+		s = s[:maxStderrInError] + "..."
+	}
+	return s
+}
+
+// countStderr counts the non-empty lines of stderr by class.
+func countStderr(stderr []byte) {
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		class := stderrClassOther
+		for _, p := range stderrPatterns {
+			if p.regex.MatchString(line) {
+				class = p.class
+				break
+			}
+		}
+		stderrLines.inc(class)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecRlmstatStderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+	script := filepath.Join(t.TempDir(), "rlmstat")
+	body := "#!/bin/sh\necho status\necho 'Warning: license file is old' >&2\n" +
+		"[ \"$1\" = -a ] && exit 0\necho 'Communications error with license server' >&2\nexit 1\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Unexpected error writing script: %v", err)
+	}
+	oldPath := *rlmstatPath
+	defer func() { *rlmstatPath = oldPath }()
+	*rlmstatPath = script
+
+	before := map[string]float64{}
+	for class, count := range stderrLines.counts {
+		before[class] = count
+	}
+
+	// Successful runs keep stderr out of the output.
+	out, _, err := execRlmstat(context.Background(), priorityStatus, nil, []string{"-a"})
+	if err != nil || string(out) != "status\n" {
+		t.Fatalf("Unexpected output %q, error %v", out, err)
+	}
+
+	out, _, err = execRlmstat(context.Background(), priorityStatus, nil, []string{"-i"})
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || !strings.HasSuffix(err.Error(), "Warning: license file is old Communications error with license server") {
+		t.Fatalf("Expected the error to quote stderr, got %v", err)
+	}
+	if !strings.Contains(string(out), "Communications error") {
+		t.Fatalf("Expected stderr in the output of a failed run, got %q", out)
+	}
+
+	for class, expected := range map[string]float64{"warning": 2, "communication_error": 1} {
+		if got := stderrLines.counts[class] - before[class]; got != expected {
+			t.Fatalf("Expected %v more %s lines, got %v", expected, class, got)
+		}
+	}
+}
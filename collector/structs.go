@@ -36,12 +36,58 @@ type vendor struct {
 type feature struct {
 	issued float64
 	used   float64
+	queued float64
+	// softLimit and overdraft are only reported by some RLM ISVs, the has
+	// fields tell whether they were.
+	softLimit    float64
+	overdraft    float64
+	hasSoftLimit bool
+	hasOverdraft bool
 }
 
 type featureExp struct {
-	name     string
-	expires  float64
+	name       string
+	expires    float64
+	rawExpires string
+	// parsed is false if rawExpires isn't a known date format.
+	parsed   bool
 	licenses string
 	vendor   string
 	version  string
 }
+
+// serverBanner is what an RLM server tells about itself at the top of its
+// status.
+type serverBanner struct {
+	// server is the port@host of the server.
+	server   string
+	version  string
+	build    string
+	platform string
+}
+
+// checkout is a user holding seats of a feature from a host.
+type checkout struct {
+	user  string
+	host  string
+	seats float64
+}
+
+// lmstatData holds everything parsed from a single `rlmstat -a` run.
+type lmstatData struct {
+	servers               map[string]*server
+	vendors               map[string]*vendor
+	features              map[string]*feature
+	usersByFeature        map[string]map[string]float64
+	hostsByFeature        map[string]map[string]float64
+	reservationsByFeature map[string]map[string]float64
+	// checkoutsByFeature lists the checkouts of each feature, with the host
+	// of the user if the output tells it.
+	checkoutsByFeature map[string][]checkout
+	// output is the raw rlmstat output, for the custom_metrics of a license.
+	output []byte
+	// banners holds the banners of the RLM servers that printed one.
+	banners []serverBanner
+	// isvByFeature is the ISV of the features, for the outputs that tell it.
+	isvByFeature map[string]string
+}
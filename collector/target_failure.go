@@ -0,0 +1,128 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons rlmstat failed to query a license, exported as the reason label of
+// rlmlm_target_failure_reason.
+const (
+	targetFailureConnectionRefused = "connection_refused"
+	targetFailureTimeout           = "timeout"
+	targetFailureHostNotFound      = "host_not_found"
+	targetFailureAuth              = "auth_failure"
+	targetFailureISVDown           = "isv_down"
+	targetFailureParse             = "parse_error"
+	targetFailureExec              = "exec_error"
+	targetFailureConfig            = "config_error"
+	targetFailureUnknown           = "unknown"
+)
+
+var (
+	targetUpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "target", "up"),
+		"Whether rlmstat could query the license target, see rlmlm_target_failure_reason for why not.",
+		[]string{"license_name", "license_server"},
+		nil,
+	)
+	targetFailureReasonDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "target", "failure_reason"),
+		"Why rlmstat couldn't query the license target at the latest attempt, labeled by reason.",
+		[]string{"license_name", "reason"},
+		nil,
+	)
+
+	// targetFailurePatterns classify the messages rlmstat prints when it
+	// fails, the first match wins.
+	targetFailurePatterns = []struct {
+		reason string
+		regex  *regexp.Regexp
+	}{
+		{targetFailureAuth, regexp.MustCompile(`(?i)\b(bad|invalid|incorrect|wrong) password\b|\bpassword (required|invalid|incorrect)\b|\bauthentication failed\b|\bnot (authorized|a license administrator)\b`)},
+		{targetFailureHostNotFound, regexp.MustCompile(`(?i)\bcannot find server host name\b|\bunknown host\b|\bhost name lookup failed\b|\bname or service not known\b|\bno such host\b`)},
+		{targetFailureTimeout, regexp.MustCompile(`(?i)\btimed out\b|\btimeout\b|\boperation now in progress\b`)},
+		{targetFailureConnectionRefused, regexp.MustCompile(`(?i)\bconnection refused\b|\bcannot connect to license server\b|\berror connecting to\b`)},
+		{targetFailureISVDown, regexp.MustCompile(`(?i)\b(vendor daemon|isv server|isv)( \S+)? (is )?(down|not running)\b|\bdaemon: down\b`)},
+	}
+
+	// targetFailureExitCodes classify the rlmstat exit codes of
+	// errorDescriptionString that tell why the server couldn't be queried.
+	targetFailureExitCodes = map[int]string{
+		241: targetFailureConnectionRefused,
+		242: targetFailureHostNotFound,
+		204: targetFailureTimeout,
+		193: targetFailureAuth,
+	}
+
+	// targetFailureReasons are the reasons exported for every license, so that
+	// rlmlm_target_failure_reason is 0 rather than missing while it is up.
+	targetFailureReasons = []string{
+		targetFailureConnectionRefused, targetFailureTimeout, targetFailureHostNotFound,
+		targetFailureAuth, targetFailureISVDown, targetFailureParse, targetFailureExec,
+		targetFailureConfig, targetFailureUnknown,
+	}
+)
+
+// targetFailure is rlmstat failing to query a license, classified by reason.
+type targetFailure struct {
+	reason string
+	err    error
+}
+
+func (e *targetFailure) Error() string { return e.err.Error() }
+
+func (e *targetFailure) Unwrap() error { return e.err }
+
+// classifyTargetFailure returns why rlmstat failed with err, given its
+// output out including stderr.
+func classifyTargetFailure(err error, out []byte) string {
+	if errorType(err) == errorTypeTimeout {
+		return targetFailureTimeout
+	}
+	for _, p := range targetFailurePatterns {
+		if p.regex.Match(out) {
+			return p.reason
+		}
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if reason, ok := targetFailureExitCodes[exitErr.ExitCode()]; ok {
+			return reason
+		}
+		return targetFailureUnknown
+	}
+	switch errorType(err) {
+	case errorTypeParse:
+		return targetFailureParse
+	case errorTypeConfig:
+		return targetFailureConfig
+	case errorTypeExec:
+		return targetFailureExec
+	}
+	return targetFailureUnknown
+}
+
+// exportTargetFailure sends rlmlm_target_failure_reason for license, 1 for
+// reason and 0 for the others. An empty reason means the target is up.
+func exportTargetFailure(ch chan<- prometheus.Metric, license, reason string) {
+	for _, r := range targetFailureReasons {
+		ch <- constMetric(targetFailureReasonDesc, prometheus.GaugeValue, boolToFloat64(r == reason), license, r)
+	}
+}
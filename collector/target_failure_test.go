@@ -0,0 +1,96 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestClassifyTargetFailure(t *testing.T) {
+	exit := func(code int) error {
+		err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+		if err == nil {
+			t.Fatal("Expected the command to fail")
+		}
+		return err
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh in PATH")
+	}
+
+	for i, tc := range []struct {
+		err      error
+		out      string
+		expected string
+	}{
+		{exit(1), `Error connecting to "rlm" server: Connection refused (-17)`, targetFailureConnectionRefused},
+		{exit(1), "rlmstat: Bad password for the license server", targetFailureAuth},
+		{exit(1), "lookup host9: no such host", targetFailureHostNotFound},
+		{exit(1), "Communications timeout with license server", targetFailureTimeout},
+		{exit(1), "ISV acme is down", targetFailureISVDown},
+		{exit(242), "", targetFailureHostNotFound},
+		{exit(3), "", targetFailureUnknown},
+		{fmt.Errorf("rlmstat: %w", context.DeadlineExceeded), "", targetFailureTimeout},
+		{parseError(errUnparseableOutput), "garbage", targetFailureParse},
+		{errRlmstatUnavailable, "", targetFailureExec},
+	} {
+		if reason := classifyTargetFailure(tc.err, []byte(tc.out)); reason != tc.expected {
+			t.Fatalf("Case %d: expected %s, got %s", i, tc.expected, reason)
+		}
+	}
+}
+
+func TestTargetFailureReason(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": "fixtures/lmstat_refused.txt"})
+
+	c := &LmstatCollector{logger: log.NewNopLogger()}
+	ch := make(chan prometheus.Metric, 50)
+	err := c.UpdateLicense(context.Background(), ch, config.License{Name: "app1", LicenseServer: "5053@host1"})
+	close(ch)
+	var tf *targetFailure
+	if !errors.As(err, &tf) || tf.reason != targetFailureConnectionRefused {
+		t.Fatalf("Expected a refused connection, got %v", err)
+	}
+
+	var up, reasons float64 = -1, 0
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		switch m.Desc() {
+		case targetUpDesc:
+			up = pb.GetGauge().GetValue()
+		case targetFailureReasonDesc:
+			for _, l := range pb.GetLabel() {
+				if l.GetName() == "reason" && l.GetValue() == targetFailureConnectionRefused {
+					reasons += pb.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	if up != 0 || reasons != 1 {
+		t.Fatalf("Expected rlmlm_target_up 0 for a refused connection, got %v and reason %v", up, reasons)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var licenseTenantDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "license", "tenant_info"),
+	"rlmlm_exporter: Tenant of the license, to join the tenant label onto the license metrics by license_name.",
+	[]string{"license_name", "tenant"},
+	nil,
+)
+
+// collectTenants sends the tenant of every license of cfg that has one.
+func collectTenants(ch chan<- prometheus.Metric, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	for _, license := range cfg.Licenses {
+		if license.Tenant != "" {
+			ch <- constMetric(licenseTenantDesc, prometheus.GaugeValue, 1, license.Name, license.Tenant)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the collection spans. It does nothing unless the main
+// package installs a tracer provider.
+var tracer = otel.Tracer("github.com/iambengiey/rlmlm_exporter/collector")
+
+// startLicenseSpan starts the span of collecting license with collector.
+func startLicenseSpan(ctx context.Context, collector, license string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "license", trace.WithAttributes(
+		attribute.String("collector", collector),
+		attribute.String("license_name", license),
+	))
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestCollectSpans(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": "fixtures/lmstat_app1.txt"})
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	cfg := &config.Config{Licenses: []config.License{{Name: "app1", LicenseServer: "27000@host1"}}}
+	lmstat, err := NewLmstatCollector(cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := RlmlmCollector{Config: cfg, Logger: log.NewNopLogger(), Collectors: map[string]Collector{"lmstat": lmstat}}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	for range ch {
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for child, parent := range map[string]string{"collector": "scrape", "license": "collector", "rlmstat": "license"} {
+		if spans[child] == nil || spans[parent] == nil {
+			t.Fatalf("Missing %s or %s span in %v", child, parent, spans)
+		}
+		if spans[child].Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Fatalf("Span %s isn't a child of %s", child, parent)
+		}
+	}
+}
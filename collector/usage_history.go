@@ -0,0 +1,153 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// historySamples is the number of samples kept per feature of a license,
+// spread evenly over the retention.
+const historySamples = 720
+
+var usageHistory = newHistoryTracker(6 * time.Hour)
+
+// UsageSample is the usage of a feature at a collection.
+type UsageSample struct {
+	Time   time.Time `json:"time"`
+	Issued float64   `json:"issued"`
+	Used   float64   `json:"used"`
+}
+
+// historyRing holds the latest samples of a feature, oldest first from next.
+type historyRing struct {
+	samples []UsageSample
+	next    int
+}
+
+// historyTracker keeps the usage of every feature over the retention in
+// memory, for the built-in graphs.
+type historyTracker struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	retention time.Duration
+	rings     map[featureKey]*historyRing
+}
+
+func newHistoryTracker(retention time.Duration) *historyTracker {
+	return &historyTracker{now: time.Now, retention: retention, rings: make(map[featureKey]*historyRing)}
+}
+
+// SetUsageHistoryRetention sets how long the usage of features is kept for
+// UsageHistory. Zero disables the history and drops the samples kept so far.
+func SetUsageHistoryRetention(retention time.Duration) {
+	usageHistory.mu.Lock()
+	defer usageHistory.mu.Unlock()
+	usageHistory.retention = retention
+	usageHistory.rings = make(map[featureKey]*historyRing)
+}
+
+// observe records the usage of feature. Samples closer together than the
+// retention divided by historySamples are merged, keeping the highest usage,
+// so that frequent scrapes don't shorten the history.
+func (t *historyTracker) observe(license, feature string, used, issued float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.retention <= 0 {
+		return
+	}
+
+	now := t.now()
+	key := featureKey{license, feature}
+	ring, ok := t.rings[key]
+	if !ok {
+		ring = &historyRing{samples: make([]UsageSample, 0, historySamples)}
+		t.rings[key] = ring
+	}
+	if n := len(ring.samples); n > 0 {
+		last := &ring.samples[(ring.next+n-1)%n]
+		if now.Sub(last.Time) < t.retention/historySamples {
+			last.Issued = issued
+			last.Used = max(last.Used, used)
+			return
+		}
+	}
+	sample := UsageSample{Time: now, Issued: issued, Used: used}
+	if len(ring.samples) < historySamples {
+		ring.samples = append(ring.samples, sample)
+		return
+	}
+	ring.samples[ring.next] = sample
+	ring.next = (ring.next + 1) % historySamples
+}
+
+// history returns the samples of feature within the retention by license,
+// oldest first. Licenses that stopped serving the feature longer ago than
+// the retention are dropped.
+func (t *historyTracker) history(feature string) map[string][]UsageSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	since := t.now().Add(-t.retention)
+	result := make(map[string][]UsageSample)
+	for key, ring := range t.rings {
+		var samples []UsageSample
+		n := len(ring.samples)
+		for i := range n {
+			sample := ring.samples[(ring.next+i)%n]
+			if sample.Time.After(since) {
+				samples = append(samples, sample)
+			}
+		}
+		if len(samples) == 0 {
+			delete(t.rings, key)
+			continue
+		}
+		if key.feature == feature {
+			result[key.license] = samples
+		}
+	}
+	return result
+}
+
+// features returns the sorted names of the features with samples.
+func (t *historyTracker) features() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for key := range t.rings {
+		seen[key.feature] = true
+	}
+	features := make([]string, 0, len(seen))
+	for feature := range seen {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	return features
+}
+
+// UsageHistory returns the usage of feature collected within the retention
+// set with SetUsageHistoryRetention, by license and oldest first.
+func UsageHistory(feature string) map[string][]UsageSample {
+	return usageHistory.history(feature)
+}
+
+// UsageHistoryFeatures returns the sorted names of the features UsageHistory
+// has samples of.
+func UsageHistoryFeatures() []string {
+	return usageHistory.features()
+}
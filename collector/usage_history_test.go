@@ -0,0 +1,58 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryTracker(t *testing.T) {
+	now := time.Date(2025, 3, 29, 10, 0, 0, 0, time.UTC)
+	tr := newHistoryTracker(historySamples * time.Minute)
+	tr.now = func() time.Time { return now }
+
+	tr.observe("app1", "feature1", 3, 10)
+	// Merged into the previous sample, keeping the peak.
+	now = now.Add(30 * time.Second)
+	tr.observe("app1", "feature1", 5, 10)
+	now = now.Add(30 * time.Second)
+	tr.observe("app1", "feature1", 4, 10)
+	tr.observe("app2", "feature2", 1, 2)
+
+	samples := tr.history("feature1")["app1"]
+	if len(samples) != 2 || samples[0].Used != 5 || samples[1].Used != 4 {
+		t.Fatalf("Unexpected samples %+v", samples)
+	}
+	if features := tr.features(); len(features) != 2 || features[0] != "feature1" {
+		t.Fatalf("Unexpected features %v", features)
+	}
+
+	// The ring keeps the latest samples only.
+	for i := range historySamples {
+		now = now.Add(time.Minute)
+		tr.observe("app1", "feature1", float64(i), 10)
+	}
+	samples = tr.history("feature1")["app1"]
+	if len(samples) != historySamples || samples[0].Used != 0 || samples[len(samples)-1].Used != historySamples-1 {
+		t.Fatalf("Unexpected ring of %d samples from %v to %v", len(samples), samples[0], samples[len(samples)-1])
+	}
+	// Features no longer collected within the retention are dropped.
+	if history := tr.history("feature2"); len(history) != 0 {
+		t.Fatalf("Expected no samples of feature2, got %v", history)
+	}
+	if features := tr.features(); len(features) != 1 {
+		t.Fatalf("Unexpected features %v", features)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "fmt"
+
+// FeatureSample is the state of a feature parsed by a vendor Parser.
+type FeatureSample struct {
+	Feature string
+	Issued  float64
+	Used    float64
+	Queued  float64
+	// Users maps the users holding the feature to their number of licenses.
+	// It is optional and used like the users of the built-in parsers, by
+	// monitor_users and top_users.
+	Users map[string]float64
+}
+
+// Parser parses the `rlmstat -a -c <target>` output of ISVs whose status
+// format the built-in parsers don't understand. Licenses select one by the
+// name it was registered with in their parser setting.
+type Parser interface {
+	Parse(raw []byte) ([]FeatureSample, error)
+}
+
+// ParserFunc adapts a function to the Parser interface.
+type ParserFunc func(raw []byte) ([]FeatureSample, error)
+
+// Parse implements Parser.
+func (f ParserFunc) Parse(raw []byte) ([]FeatureSample, error) {
+	return f(raw)
+}
+
+// vendorParsers holds the registered parsers by name. It is only written
+// from init functions, before any collection.
+var vendorParsers = make(map[string]Parser)
+
+// RegisterParser makes p available to licenses configured with `parser:
+// name`. It is meant to be called from the init function of a package
+// imported by the exporter, and panics if name is taken.
+func RegisterParser(name string, p Parser) {
+	if name == "" || name == parserHuman || name == parserParseable {
+		panic(fmt.Sprintf("invalid parser name %q", name))
+	}
+	if _, ok := vendorParsers[name]; ok {
+		panic(fmt.Sprintf("parser %q registered twice", name))
+	}
+	vendorParsers[name] = p
+}
+
+// vendorParse returns a parse function that turns the samples of the parser
+// registered as name into lmstatData. Samples of the same feature add up.
+func vendorParse(name string) (func([]byte) (*lmstatData, error), error) {
+	p, ok := vendorParsers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parser %q", name)
+	}
+	return func(raw []byte) (*lmstatData, error) {
+		samples, err := p.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(samples) == 0 {
+			return nil, errUnparseableOutput
+		}
+		data := &lmstatData{
+			servers:               make(map[string]*server),
+			vendors:               make(map[string]*vendor),
+			features:              make(map[string]*feature),
+			usersByFeature:        make(map[string]map[string]float64),
+			hostsByFeature:        make(map[string]map[string]float64),
+			checkoutsByFeature:    make(map[string][]checkout),
+			reservationsByFeature: make(map[string]map[string]float64),
+		}
+		for _, s := range samples {
+			f, ok := data.features[s.Feature]
+			if !ok {
+				f = &feature{}
+				data.features[s.Feature] = f
+			}
+			f.issued += s.Issued
+			f.used += s.Used
+			f.queued += s.Queued
+			for user, used := range s.Users {
+				if data.usersByFeature[s.Feature] == nil {
+					data.usersByFeature[s.Feature] = make(map[string]float64)
+				}
+				data.usersByFeature[s.Feature][user] += used
+				data.checkoutsByFeature[s.Feature] = append(data.checkoutsByFeature[s.Feature], checkout{user: user, seats: used})
+			}
+		}
+		return data, nil
+	}, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	testVendorFeatureRegex = regexp.MustCompile(`^\s+\[(\S+)\] (\d+) of (\d+) in use$`)
+	testVendorUserRegex    = regexp.MustCompile(`^\s+(\S+)@\S+ (\d+)$`)
+)
+
+func init() {
+	RegisterParser("test_vendor", ParserFunc(func(raw []byte) ([]FeatureSample, error) {
+		var samples []FeatureSample
+		for _, line := range strings.Split(string(raw), "\n") {
+			if m := testVendorFeatureRegex.FindStringSubmatch(line); m != nil {
+				used, _ := strconv.ParseFloat(m[2], 64)
+				issued, _ := strconv.ParseFloat(m[3], 64)
+				samples = append(samples, FeatureSample{Feature: m[1], Used: used, Issued: issued, Users: map[string]float64{}})
+			} else if m := testVendorUserRegex.FindStringSubmatch(line); m != nil && len(samples) > 0 {
+				used, _ := strconv.ParseFloat(m[2], 64)
+				samples[len(samples)-1].Users[m[1]] = used
+			}
+		}
+		return samples, nil
+	}))
+}
+
+func TestVendorParser(t *testing.T) {
+	fakeRlmstat(t, map[string]string{"-a": "fixtures/lmstat_vendor.txt"})
+
+	c := &LmstatCollector{logger: log.NewNopLogger()}
+	license := config.License{Name: "cadence", LicenseServer: "5053@host1", Parser: "test_vendor"}
+	data, parser, err := c.queryLicense(context.Background(), license, "5053@host1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parser != "test_vendor" {
+		t.Fatalf("Unexpected parser %q", parser)
+	}
+	if f := data.features["Virtuoso_Layout"]; f == nil || f.used != 3 || f.issued != 10 {
+		t.Fatalf("Unexpected Virtuoso_Layout: %+v", f)
+	}
+	if users := data.usersByFeature["Virtuoso_Layout"]; len(users) != 2 || users["user1"] != 2 {
+		t.Fatalf("Unexpected Virtuoso_Layout users: %v", users)
+	}
+	if f := data.features["Spectre_Sim"]; f == nil || f.issued != 4 {
+		t.Fatalf("Unexpected Spectre_Sim: %+v", f)
+	}
+
+	license.Parser = "missing"
+	if _, _, err := c.queryLicense(context.Background(), license, "5053@host1"); err == nil || !strings.Contains(err.Error(), `unknown parser "missing"`) {
+		t.Fatalf("Expected an error for an unknown parser, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// flexlmMapping maps the metrics to the names of flexlm_exporter.
+//
+//go:embed compat_flexlm.yml
+var flexlmMapping []byte
+
+// compat, if set, additionally exposes the metrics under the names of
+// another exporter.
+var compat *compatMapping
+
+// compatMapping renames metrics and their labels.
+type compatMapping struct {
+	// Labels are renamed in every mapped metric, from the name used here to
+	// the old one.
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// Metrics are mapped by the first rule matching their name, metrics
+	// without a matching rule aren't mapped.
+	Metrics []compatRule `yaml:"metrics"`
+}
+
+// compatRule maps the metrics whose whole name matches Match to Name, which
+// may refer to the groups of Match like $1.
+type compatRule struct {
+	Match  string            `yaml:"match"`
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// loadCompatMapping reads the mapping file at path, or the flexlm_exporter
+// mapping if path is empty.
+func loadCompatMapping(path string) (*compatMapping, error) {
+	data := flexlmMapping
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return parseCompatMapping(data)
+}
+
+func parseCompatMapping(data []byte) (*compatMapping, error) {
+	var m compatMapping
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, err
+	}
+	for i := range m.Metrics {
+		r := &m.Metrics[i]
+		re, err := regexp.Compile("^(?:" + r.Match + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid match %q: %w", r.Match, err)
+		}
+		if r.Name == "" {
+			return nil, fmt.Errorf("missing name for match %q", r.Match)
+		}
+		r.re = re
+	}
+	return &m, nil
+}
+
+// rename returns the mapped name of a metric, or false if it isn't mapped.
+func (m *compatMapping) rename(name string) (*compatRule, string, bool) {
+	for i := range m.Metrics {
+		r := &m.Metrics[i]
+		if match := r.re.FindStringSubmatchIndex(name); match != nil {
+			return r, string(r.re.ExpandString(nil, r.Name, name, match)), true
+		}
+	}
+	return nil, "", false
+}
+
+// label returns the mapped name of a label of a metric mapped by r.
+func (m *compatMapping) label(r *compatRule, name string) string {
+	if mapped, ok := r.Labels[name]; ok {
+		return mapped
+	}
+	if mapped, ok := m.Labels[name]; ok {
+		return mapped
+	}
+	return name
+}
+
+// compatGatherer adds the mapped copies of the metrics gathered by g.
+type compatGatherer struct {
+	g prometheus.Gatherer
+	m *compatMapping
+}
+
+// withCompat returns g, or g with the mapped metrics added if compat is set.
+func withCompat(g prometheus.Gatherer) prometheus.Gatherer {
+	if compat == nil {
+		return g
+	}
+	return compatGatherer{g: g, m: compat}
+}
+
+// Gather implements prometheus.Gatherer. Mapped names that are already
+// taken aren't added.
+func (c compatGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := c.g.Gather()
+	taken := make(map[string]bool, len(families))
+	for _, mf := range families {
+		taken[mf.GetName()] = true
+	}
+
+	mapped := families
+	for _, mf := range families {
+		r, name, ok := c.m.rename(mf.GetName())
+		if !ok || taken[name] {
+			continue
+		}
+		taken[name] = true
+		mapped = append(mapped, renamedFamily(mf, name, func(label string) string {
+			return c.m.label(r, label)
+		}))
+	}
+	sort.Slice(mapped, func(i, j int) bool { return mapped[i].GetName() < mapped[j].GetName() })
+	return mapped, err
+}
+
+// renamedFamily returns a copy of mf named name, with its labels renamed by
+// label.
+func renamedFamily(mf *dto.MetricFamily, name string, label func(string) string) *dto.MetricFamily {
+	copied := proto.Clone(mf).(*dto.MetricFamily)
+	copied.Name = proto.String(name)
+	for _, metric := range copied.Metric {
+		for _, lp := range metric.Label {
+			lp.Name = proto.String(label(lp.GetName()))
+		}
+		sort.Slice(metric.Label, func(i, j int) bool {
+			return metric.Label[i].GetName() < metric.Label[j].GetName()
+		})
+	}
+	return copied
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCompatGatherer(t *testing.T) {
+	m, err := loadCompatMapping("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	used := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_feature_used", Help: "Used."},
+		[]string{"license_name", "feature"})
+	used.WithLabelValues("app1", "feature1").Set(3)
+	isv := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_isv_status", Help: "ISV."},
+		[]string{"license_name", "isv", "version"})
+	isv.WithLabelValues("app1", "vendor1", "v14.2").Set(1)
+	unmapped := prometheus.NewGauge(prometheus.GaugeOpts{Name: "rlmlm_data_age_seconds", Help: "Age."})
+	registry.MustRegister(used, isv, unmapped)
+
+	families, err := compatGatherer{g: registry, m: m}.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, mf := range families {
+		var labels []string
+		for _, lp := range mf.Metric[0].Label {
+			labels = append(labels, lp.GetName()+"="+lp.GetValue())
+		}
+		got[mf.GetName()] = strings.Join(labels, ",")
+	}
+
+	want := map[string]string{
+		"rlmlm_feature_used":     "feature=feature1,license_name=app1",
+		"flexlm_feature_used":    "app=app1,name=feature1",
+		"rlmlm_isv_status":       "isv=vendor1,license_name=app1,version=v14.2",
+		"flexlm_vendor_status":   "app=app1,name=vendor1,version=v14.2",
+		"rlmlm_data_age_seconds": "",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for name, labels := range want {
+		if l, ok := got[name]; !ok || l != labels {
+			t.Fatalf("Expected %s{%s}, got %v", name, labels, got)
+		}
+	}
+}
+
+func TestParseCompatMapping(t *testing.T) {
+	for _, bad := range []string{
+		"metrics:\n  - match: rlmlm_(\n    name: old\n",
+		"metrics:\n  - match: rlmlm_x\n",
+		"metric:\n  - match: rlmlm_x\n    name: old\n",
+	} {
+		if _, err := parseCompatMapping([]byte(bad)); err == nil {
+			t.Fatalf("Expected an error for %q", bad)
+		}
+	}
+}
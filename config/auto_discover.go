@@ -0,0 +1,180 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log/level"
+)
+
+// ReasonInvalidAutoDiscover rejects license groups whose auto_discover_dir
+// can't be scanned.
+const ReasonInvalidAutoDiscover = "invalid_auto_discover"
+
+// licenseFileExt is the extension of the license files scanned in an
+// auto_discover_dir.
+const licenseFileExt = ".lic"
+
+// expandAutoDiscover replaces the licenses with an AutoDiscoverDir by one
+// license per ISV of the license files in that directory, named after the
+// group and the ISV, e.g. app_acme. They keep the other settings of the
+// group and use the first file mentioning the ISV as license_file. Groups
+// that can't be scanned are rejected.
+func (cfg *Config) expandAutoDiscover() {
+	expanded := make([]License, 0, len(cfg.Licenses))
+	for _, license := range cfg.Licenses {
+		if license.AutoDiscoverDir == "" {
+			expanded = append(expanded, license)
+			continue
+		}
+		discovered, err := license.discover()
+		if err != nil {
+			level.Error(cfgLogger).Log("msg", "rejecting invalid license", "license", license.Name, "reason", ReasonInvalidAutoDiscover, "err", err)
+			cfg.Rejected = append(cfg.Rejected, Rejection{License: license.Name, Reason: ReasonInvalidAutoDiscover, Err: err})
+			continue
+		}
+		if len(discovered) == 0 {
+			level.Warn(cfgLogger).Log("msg", "no ISV found in auto_discover_dir", "license", license.Name, "dir", license.AutoDiscoverDir)
+		}
+		expanded = append(expanded, discovered...)
+	}
+	cfg.Licenses = expanded
+}
+
+// discover returns the licenses of the ISVs found in the license files of
+// AutoDiscoverDir, sorted by name.
+func (l License) discover() ([]License, error) {
+	if l.LicenseFile != "" || l.LicenseServer != "" {
+		return nil, fmt.Errorf("auto_discover_dir of %s can't be combined with license_file or license_server", l.Name)
+	}
+	if !filepath.IsAbs(l.AutoDiscoverDir) {
+		return nil, fmt.Errorf("auto_discover_dir %q of %s is not an absolute path", l.AutoDiscoverDir, l.Name)
+	}
+	if _, err := os.Stat(l.AutoDiscoverDir); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(l.AutoDiscoverDir, "*"+licenseFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	// fileByISV holds the first file mentioning each ISV, in the sorted order
+	// of Glob. A list of files would need the separator of the platform, which
+	// is rejected in targets on Windows.
+	fileByISV := make(map[string]string)
+	for _, path := range paths {
+		isvs, err := licenseFileISVs(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, isv := range isvs {
+			if _, ok := fileByISV[isv]; !ok {
+				fileByISV[isv] = path
+			}
+		}
+	}
+
+	isvs := make([]string, 0, len(fileByISV))
+	for isv := range fileByISV {
+		isvs = append(isvs, isv)
+	}
+	sort.Strings(isvs)
+	licenses := make([]License, 0, len(isvs))
+	for _, isv := range isvs {
+		license := l
+		license.Name = l.Name + "_" + isv
+		license.AutoDiscoverDir = ""
+		license.LicenseFile = fileByISV[isv]
+		licenses = append(licenses, license)
+	}
+	return licenses, nil
+}
+
+// licenseFileISVs returns the ISVs an RLM or FlexLM license file declares
+// with ISV, VENDOR or DAEMON lines, or licenses with LICENSE, FEATURE or
+// INCREMENT lines.
+func licenseFileISVs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		isvs []string
+		seen = make(map[string]bool)
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var isv string
+		switch strings.ToUpper(fields[0]) {
+		case "ISV", "VENDOR", "DAEMON", "LICENSE":
+			isv = fields[1]
+		case "FEATURE", "INCREMENT":
+			if len(fields) > 2 {
+				isv = fields[2]
+			}
+		}
+		if isv != "" && !strings.Contains(isv, "=") && !seen[isv] {
+			seen[isv] = true
+			isvs = append(isvs, isv)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+	return isvs, nil
+}
+
+// Rediscover scans the auto_discover_dir of the license groups again and
+// returns the configuration with the licenses found, and whether they
+// changed. cfg is returned as is if it has no group.
+func (cfg *Config) Rediscover() (*Config, bool) {
+	if cfg == nil || !cfg.hasAutoDiscover() {
+		return cfg, false
+	}
+	next := *cfg
+	next.Licenses = append([]License(nil), cfg.source...)
+	next.Rejected = nil
+	next.expandAutoDiscover()
+	next.dropInvalidLicenses()
+	if reflect.DeepEqual(next.Licenses, cfg.Licenses) && sameRejections(next.Rejected, cfg.Rejected) {
+		return cfg, false
+	}
+	return &next, true
+}
+
+// hasAutoDiscover returns whether a license of the configuration file is a
+// group with an auto_discover_dir.
+func (cfg *Config) hasAutoDiscover() bool {
+	for _, license := range cfg.source {
+		if license.AutoDiscoverDir != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sameRejections compares rejections by license, reason and message.
+func sameRejections(a, b []Rejection) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].License != b[i].License || a[i].Reason != b[i].Reason || a[i].Err.Error() != b[i].Err.Error() {
+			return false
+		}
+	}
+	return true
+}
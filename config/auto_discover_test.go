@@ -0,0 +1,55 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAutoDiscover(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("acme.lic", "HOST server1 any 5053\nISV acme\nLICENSE acme solver 1.0 permanent 10 sig=x\n")
+	write("shared.lic", "# ISV commented\nISV acme\nISV beta\n")
+	write("notes.txt", "ISV gamma\n")
+
+	path := filepath.Join(t.TempDir(), "licenses.yml")
+	data := []byte("licenses:\n  - name: srv\n    auto_discover_dir: " + dir + "\n    monitor_users: true\n  - name: missing\n    auto_discover_dir: " + filepath.Join(dir, "missing") + "\n")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Licenses) != 2 || cfg.Licenses[0].Name != "srv_acme" || cfg.Licenses[1].Name != "srv_beta" {
+		t.Fatalf("unexpected licenses %+v", cfg.Licenses)
+	}
+	if got := cfg.Licenses[0]; got.LicenseFile != filepath.Join(dir, "acme.lic") || !got.MonitorUsers || got.AutoDiscoverDir != "" {
+		t.Fatalf("unexpected discovered license %+v", got)
+	}
+	if got := cfg.Licenses[1].LicenseFile; got != filepath.Join(dir, "shared.lic") {
+		t.Fatalf("unexpected license file of beta %s", got)
+	}
+	if len(cfg.Rejected) != 1 || cfg.Rejected[0].License != "missing" || cfg.Rejected[0].Reason != ReasonInvalidAutoDiscover {
+		t.Fatalf("expected the missing directory to be rejected, got %+v", cfg.Rejected)
+	}
+
+	if next, changed := cfg.Rediscover(); changed || next != cfg {
+		t.Fatal("expected no change without new license files")
+	}
+	write("gamma.lic", "VENDOR gamma\n")
+	next, changed := cfg.Rediscover()
+	if !changed || len(next.Licenses) != 3 || next.Licenses[2].Name != "srv_gamma" {
+		t.Fatalf("expected gamma to be discovered, got %+v", next.Licenses)
+	}
+	if len(cfg.Licenses) != 2 {
+		t.Fatal("expected the previous configuration to be left alone")
+	}
+}
@@ -0,0 +1,109 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultBillingGroup is charged for the seats of users matching no group,
+// and of features whose users aren't monitored.
+const DefaultBillingGroup = "unassigned"
+
+// Billing adds up the seat-hours of every feature by group of users over
+// monthly periods, for chargeback to departments.
+type Billing struct {
+	// Dir holds a CSV file per period, written as the period goes and
+	// completed when it closes.
+	Dir string `yaml:"dir"`
+	// StartDay is the day of the month periods start on, 1 to 28. Defaults
+	// to 1.
+	StartDay int `yaml:"start_day,omitempty"`
+	// Timezone is the IANA zone periods start in, UTC if empty.
+	Timezone string `yaml:"timezone,omitempty"`
+	// Groups assign users to groups, the first matching group wins.
+	Groups []BillingGroup `yaml:"groups,omitempty"`
+	// DefaultGroup defaults to DefaultBillingGroup.
+	DefaultGroup string `yaml:"default_group,omitempty"`
+	// Rates are the prices of a seat-hour by feature, features without one
+	// cost nothing.
+	Rates map[string]float64 `yaml:"rates,omitempty"`
+}
+
+// BillingGroup charges the seats of the users matching Users, an anchored
+// regex, to Group.
+type BillingGroup struct {
+	Users string `yaml:"users"`
+	Group string `yaml:"group"`
+	re    *regexp.Regexp
+}
+
+// validate checks the settings and compiles the user regexes.
+func (b *Billing) validate() error {
+	if b.Dir == "" {
+		return errors.New("missing dir")
+	}
+	if b.StartDay == 0 {
+		b.StartDay = 1
+	}
+	if b.StartDay < 1 || b.StartDay > 28 {
+		return fmt.Errorf("start_day %d isn't between 1 and 28", b.StartDay)
+	}
+	if _, err := b.Location(); err != nil {
+		return err
+	}
+	if b.DefaultGroup == "" {
+		b.DefaultGroup = DefaultBillingGroup
+	}
+	for i := range b.Groups {
+		g := &b.Groups[i]
+		if g.Group == "" {
+			return fmt.Errorf("missing group for users %q", g.Users)
+		}
+		re, err := regexp.Compile("^(?:" + g.Users + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid users %q of group %s: %w", g.Users, g.Group, err)
+		}
+		g.re = re
+	}
+	return nil
+}
+
+// Location returns the time zone periods start in.
+func (b *Billing) Location() (*time.Location, error) {
+	if b.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// Group returns the group charged for the seats of user.
+func (b *Billing) Group(user string) string {
+	for _, g := range b.Groups {
+		if g.re.MatchString(user) {
+			return g.Group
+		}
+	}
+	return b.DefaultGroup
+}
+
+// Period returns the start and end of the period t falls in.
+func (b *Billing) Period(t time.Time) (time.Time, time.Time) {
+	loc, err := b.Location()
+	if err != nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), b.StartDay, 0, 0, 0, 0, loc)
+	if t.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,57 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBilling(t *testing.T) {
+	data := []byte(`billing:
+  dir: /var/lib/rlmlm_exporter/billing
+  start_day: 16
+  timezone: Europe/Paris
+  groups:
+    - users: "cad_.*"
+      group: design
+  rates:
+    maya: 1.5
+`)
+	cfg, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := cfg.Billing
+	if b == nil || b.DefaultGroup != DefaultBillingGroup || b.Rates["maya"] != 1.5 {
+		t.Fatalf("unexpected billing settings %+v", b)
+	}
+	if group := b.Group("cad_alice"); group != "design" {
+		t.Fatalf("expected cad_alice in design, got %s", group)
+	}
+	if group := b.Group("xcad_bob"); group != DefaultBillingGroup {
+		t.Fatalf("expected xcad_bob in %s, got %s", DefaultBillingGroup, group)
+	}
+
+	loc, _ := b.Location()
+	start, end := b.Period(time.Date(2025, 1, 15, 23, 30, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2025, 1, 16, 0, 0, 0, 0, loc)) || !end.Equal(time.Date(2025, 2, 16, 0, 0, 0, 0, loc)) {
+		t.Fatalf("unexpected period %s to %s", start, end)
+	}
+	start, _ = b.Period(time.Date(2025, 1, 15, 22, 30, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2024, 12, 16, 0, 0, 0, 0, loc)) {
+		t.Fatalf("unexpected period start %s", start)
+	}
+
+	for _, invalid := range []string{
+		"billing: {}",
+		"billing: {dir: /tmp, start_day: 31}",
+		"billing: {dir: /tmp, timezone: Nowhere/City}",
+		"billing: {dir: /tmp, groups: [{users: '(', group: design}]}",
+		"billing: {dir: /tmp, groups: [{users: 'cad_.*'}]}",
+	} {
+		if _, err := Parse([]byte(invalid + "\n")); err == nil {
+			t.Fatalf("expected an error for %s", invalid)
+		}
+	}
+}
@@ -5,9 +5,16 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -36,11 +43,290 @@ type License struct {
 	MonitorUsers        bool   `yaml:"monitor_users"`
 	MonitorReservations bool   `yaml:"monitor_reservations"`
 	MonitorComputers    bool   `yaml:"monitor_computers"`
+	// TopUsers exports the users holding the most seats of each feature, up
+	// to this many, even without MonitorUsers. Zero disables it.
+	TopUsers int `yaml:"top_users,omitempty"`
+	// Timezone is the IANA zone expiration dates are interpreted in, UTC if empty.
+	Timezone string `yaml:"timezone,omitempty"`
+	// Features holds settings of single features by name.
+	Features map[string]Feature `yaml:"features,omitempty"`
+	// Env is set on top of the exporter's environment for the rlm utilities
+	// run for this license, e.g. RLM_CONNECT_TIMEOUT.
+	Env map[string]string `yaml:"env,omitempty"`
+	// CustomMetrics are extracted from ISV specific lines of the rlmstat
+	// output.
+	CustomMetrics []CustomMetric `yaml:"custom_metrics,omitempty"`
+	// FeatureAliases maps feature names reported by rlmstat, like the
+	// numeric codes of some ISVs, to the names they are exported as.
+	FeatureAliases map[string]string `yaml:"feature_aliases,omitempty"`
+	// DerivedMetrics are computed from the parsed features with expressions.
+	DerivedMetrics []DerivedMetric `yaml:"derived_metrics,omitempty"`
+	// AutoDiscoverDir makes the license a group expanded into a license per
+	// ISV of the license files in this directory, see Config.Rediscover.
+	AutoDiscoverDir string `yaml:"auto_discover_dir,omitempty"`
+	// Parser names a parser registered with collector.RegisterParser that
+	// reads the rlmstat output of ISVs with an unusual status format instead
+	// of the built-in ones.
+	Parser string `yaml:"parser,omitempty"`
+	// ISVs restricts the ISVs whose status and features are exported, for
+	// RLM servers hosting many ISVs. All are exported if empty.
+	ISVs []string `yaml:"isvs,omitempty"`
+	// QueryFeatures runs rlmstat for each feature of FeaturesToInclude
+	// instead of once for all features, sparing servers serving many more
+	// features than monitored.
+	QueryFeatures bool `yaml:"query_features,omitempty"`
+	// OnExhausted is a command run once a feature stays exhausted, all its
+	// licenses in use, for ExhaustedFor, like to reclaim idle seats. It
+	// is a text/template rendered with an ExhaustedEvent and run without a
+	// shell.
+	OnExhausted  string        `yaml:"on_exhausted,omitempty"`
+	ExhaustedFor time.Duration `yaml:"exhausted_for,omitempty"`
+	// Tenant is the business unit the license belongs to, whose scoped
+	// requests only see the licenses of the tenant.
+	Tenant string `yaml:"tenant,omitempty"`
+}
+
+// Feature holds the settings of a single feature of a license.
+type Feature struct {
+	// IgnoreExpiration keeps the feature out of expiration summaries, for
+	// licenses that are left to lapse like evaluations.
+	IgnoreExpiration bool `yaml:"ignore_expiration"`
+}
+
+// IgnoresExpiration reports whether the expiration of feature is ignored.
+func (l License) IgnoresExpiration(feature string) bool {
+	return l.Features[feature].IgnoreExpiration
+}
+
+// FeatureName returns the name feature is exported as.
+func (l License) FeatureName(feature string) string {
+	if alias, ok := l.FeatureAliases[feature]; ok {
+		return alias
+	}
+	return feature
+}
+
+// validateFeatureAliases checks that no feature is aliased to an empty name.
+func (l License) validateFeatureAliases() error {
+	for feature, alias := range l.FeatureAliases {
+		if strings.TrimSpace(alias) == "" {
+			return fmt.Errorf("empty alias for feature %q of %s", feature, l.Name)
+		}
+	}
+	return nil
+}
+
+// SelectsISV reports whether the features of isv are exported. Features
+// whose ISV the output doesn't tell, with an empty isv, always are.
+func (l License) SelectsISV(isv string) bool {
+	if len(l.ISVs) == 0 || isv == "" {
+		return true
+	}
+	for _, selected := range l.ISVs {
+		if strings.EqualFold(selected, isv) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateISVs checks that no ISV name is empty.
+func (l License) validateISVs() error {
+	for _, isv := range l.ISVs {
+		if strings.TrimSpace(isv) == "" {
+			return fmt.Errorf("empty ISV name in isvs of %s", l.Name)
+		}
+	}
+	return nil
+}
+
+// QueriedFeatures returns the features rlmstat is run for with
+// QueryFeatures, FeaturesToInclude under the names rlmstat reports.
+func (l License) QueriedFeatures() []string {
+	reported := make(map[string]string, len(l.FeatureAliases))
+	for feature, alias := range l.FeatureAliases {
+		reported[alias] = feature
+	}
+	var features []string
+	for _, name := range strings.Split(l.FeaturesToInclude, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if feature, ok := reported[name]; ok {
+			name = feature
+		}
+		features = append(features, name)
+	}
+	return features
+}
+
+// validateQueryFeatures checks that query_features has features to query
+// that can't be taken for rlmstat options.
+func (l License) validateQueryFeatures() error {
+	if !l.QueryFeatures {
+		return nil
+	}
+	if l.Parser != "" {
+		return fmt.Errorf("query_features can't be used with the %s parser of %s", l.Parser, l.Name)
+	}
+	features := l.QueriedFeatures()
+	if len(features) == 0 {
+		return fmt.Errorf("query_features needs features_to_include for %s", l.Name)
+	}
+	for _, feature := range features {
+		if strings.HasPrefix(feature, "-") || strings.ContainsAny(feature, " \t") {
+			return fmt.Errorf("invalid feature %q to query for %s", feature, l.Name)
+		}
+	}
+	return nil
+}
+
+// Environ returns Env as NAME=value pairs sorted by name.
+func (l License) Environ() []string {
+	env := make([]string, 0, len(l.Env))
+	for name, value := range l.Env {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// validateEnv checks that the names of Env can be set in an environment.
+func (l License) validateEnv() error {
+	for name := range l.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid env variable name %q for %s", name, l.Name)
+		}
+	}
+	return nil
+}
+
+// Location returns the time zone expiration dates of the license are
+// interpreted in.
+func (l License) Location() (*time.Location, error) {
+	if l.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone for %s: %w", l.Name, err)
+	}
+	return loc, nil
+}
+
+// ActivationServer is an RLM Activation Pro server whose activation keys
+// are monitored.
+type ActivationServer struct {
+	Name string `yaml:"name"`
+	// URL serves the activation key report as CSV.
+	URL        string `yaml:"url"`
+	HTTPClient `yaml:",inline"`
+}
+
+// HeartbeatSource provides the last activity of the users of license
+// clients, a CSV report read from File or downloaded from URL.
+type HeartbeatSource struct {
+	File       string `yaml:"file,omitempty"`
+	URL        string `yaml:"url,omitempty"`
+	HTTPClient `yaml:",inline"`
+}
+
+// validate checks that exactly one of File and URL is set.
+func (h HeartbeatSource) validate() error {
+	if (h.File == "") == (h.URL == "") {
+		return errors.New("exactly one of file and url must be set")
+	}
+	return h.HTTPClient.validate()
+}
+
+// HTTPClient configures the connections to an HTTP server. Servers with the
+// same settings share their connections.
+type HTTPClient struct {
+	// CAFile verifies the server certificate instead of the system roots.
+	CAFile string `yaml:"ca_file,omitempty"`
+	// CertFile and KeyFile hold a client certificate, read again when they
+	// change.
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+	// BearerTokenFile holds a token sent as Authorization header, read on
+	// every request so that rotated tokens are picked up.
+	BearerTokenFile string `yaml:"bearer_token_file,omitempty"`
+	// ProxyURL defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment.
+	ProxyURL string `yaml:"proxy_url,omitempty"`
+	// NoProxy connects directly, ignoring the proxy environment, for servers
+	// only reachable directly when the default egress goes through a proxy.
+	NoProxy bool `yaml:"no_proxy,omitempty"`
+	// Timeout of a request, zero uses the collector default.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// PinnedSPKI holds base64 SHA-256 hashes of the public keys the server
+	// certificate may have, optionally prefixed by "sha256//" as in curl's
+	// --pinnedpubkey. If set, the certificate is trusted if its key matches one
+	// of them, whoever signed it, which suits the self-signed certificates of
+	// RLM's embedded web server.
+	PinnedSPKI []string `yaml:"pinned_spki_sha256,omitempty"`
+}
+
+// PinnedKeys returns the decoded PinnedSPKI hashes.
+func (h HTTPClient) PinnedKeys() ([][sha256.Size]byte, error) {
+	keys := make([][sha256.Size]byte, 0, len(h.PinnedSPKI))
+	for _, pin := range h.PinnedSPKI {
+		hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256//"))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("invalid pinned_spki_sha256 %q: not a base64 SHA-256 hash", pin)
+		}
+		keys = append(keys, [sha256.Size]byte(hash))
+	}
+	return keys, nil
+}
+
+// validate checks that the client certificate is complete and the proxy URL
+// valid.
+func (h HTTPClient) validate() error {
+	if (h.CertFile == "") != (h.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+	if h.NoProxy && h.ProxyURL != "" {
+		return errors.New("proxy_url and no_proxy are mutually exclusive")
+	}
+	if h.ProxyURL != "" {
+		u, err := url.Parse(h.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy_url %q: missing scheme or host", h.ProxyURL)
+		}
+	}
+	if _, err := h.PinnedKeys(); err != nil {
+		return err
+	}
+	return nil
 }
 
 // Configuration for all licences.
 type Config struct {
-	Licenses []License `yaml:"licenses"`
+	Licenses          []License          `yaml:"licenses"`
+	ActivationServers []ActivationServer `yaml:"activation_servers,omitempty"`
+	// Heartbeat, if set, is correlated with the checkouts by the idle
+	// collector.
+	Heartbeat *HeartbeatSource `yaml:"heartbeat,omitempty"`
+	// Billing, if set, adds up the seat-hours of the features for
+	// chargeback.
+	Billing *Billing `yaml:"billing,omitempty"`
+	// Report, if set, emails a summary on a schedule.
+	Report *Report `yaml:"report,omitempty"`
+	// Tenants lists the tenants of the licenses with a token.
+	Tenants []Tenant `yaml:"tenants,omitempty"`
+
+	// Rejected lists the licenses dropped while loading because they are
+	// invalid, so they can be exposed as metrics.
+	Rejected []Rejection `yaml:"-"`
+
+	// source holds the licenses as loaded, before the groups are expanded.
+	source []License
 }
 
 // Configuration is kept for backwards-compatibility with older code paths that
@@ -49,6 +335,13 @@ type Configuration = Config
 
 // Load parses the YAML file at path and returns a Config.
 func Load(path string) (*Config, error) {
+	return LoadTemplate(path, "")
+}
+
+// LoadTemplate renders the YAML file at path as a text/template with the
+// variables of the YAML file at varsPath, so that a template can be shared by
+// sites, and returns the Config it holds. An empty varsPath loads path as is.
+func LoadTemplate(path, varsPath string) (*Config, error) {
 	if path == "" {
 		return nil, errors.New("config path is empty")
 	}
@@ -60,14 +353,62 @@ func Load(path string) (*Config, error) {
 		level.Error(cfgLogger).Log("msg", "failed to read config file", "path", clean, "err", err)
 		return nil, err
 	}
+	if varsPath != "" {
+		if data, err = render(data, varsPath); err != nil {
+			level.Error(cfgLogger).Log("msg", "failed to render config template", "path", clean, "vars", varsPath, "err", err)
+			return nil, err
+		}
+	}
+	return Parse(data)
+}
 
+// Parse returns the Config held by the YAML document data, like the body of
+// a configuration pushed over HTTP.
+func Parse(data []byte) (*Config, error) {
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		level.Error(cfgLogger).Log("msg", "failed to parse YAML", "err", err)
 		return nil, err
 	}
 
-	level.Info(cfgLogger).Log("msg", "configuration loaded", "licenses", len(cfg.Licenses))
+	for _, server := range cfg.ActivationServers {
+		if err := server.validate(); err != nil {
+			err = fmt.Errorf("activation server %s: %w", server.Name, err)
+			level.Error(cfgLogger).Log("msg", "invalid activation server", "err", err)
+			return nil, err
+		}
+	}
+	if cfg.Heartbeat != nil {
+		if err := cfg.Heartbeat.validate(); err != nil {
+			err = fmt.Errorf("heartbeat: %w", err)
+			level.Error(cfgLogger).Log("msg", "invalid heartbeat source", "err", err)
+			return nil, err
+		}
+	}
+	if cfg.Report != nil {
+		if err := cfg.Report.validate(); err != nil {
+			err = fmt.Errorf("report: %w", err)
+			level.Error(cfgLogger).Log("msg", "invalid report settings", "err", err)
+			return nil, err
+		}
+	}
+	if cfg.Billing != nil {
+		if err := cfg.Billing.validate(); err != nil {
+			err = fmt.Errorf("billing: %w", err)
+			level.Error(cfgLogger).Log("msg", "invalid billing settings", "err", err)
+			return nil, err
+		}
+	}
+	if err := validateTenants(cfg.Tenants); err != nil {
+		err = fmt.Errorf("tenants: %w", err)
+		level.Error(cfgLogger).Log("msg", "invalid tenants", "err", err)
+		return nil, err
+	}
+	cfg.source = append([]License(nil), cfg.Licenses...)
+	cfg.expandAutoDiscover()
+	cfg.dropInvalidLicenses()
+
+	level.Info(cfgLogger).Log("msg", "configuration loaded", "licenses", len(cfg.Licenses), "rejected", len(cfg.Rejected))
 	return &cfg, nil
 }
 
@@ -75,3 +416,72 @@ func Load(path string) (*Config, error) {
 func LoadConfig(path string) (*Config, error) {
 	return Load(path)
 }
+
+// licenseEnvVars are read, in order, by FromEnv.
+var licenseEnvVars = []string{"RLM_LICENSE", "LM_LICENSE_FILE"}
+
+// FromEnv builds a Config from the RLM_LICENSE and LM_LICENSE_FILE variables
+// a license client machine is usually configured with. Every entry of the
+// os.PathListSeparator separated lists becomes a license named after it;
+// port@host entries are used as license_server and anything else as
+// license_file.
+func FromEnv() (*Config, error) {
+	var (
+		cfg  Config
+		seen = make(map[string]bool)
+	)
+	for _, name := range licenseEnvVars {
+		for _, entry := range splitList(os.Getenv(name)) {
+			entry = strings.TrimSpace(entry)
+			if entry == "" || seen[entry] {
+				continue
+			}
+			seen[entry] = true
+
+			license := License{Name: entry}
+			if strings.Contains(entry, "@") {
+				license.LicenseServer = entry
+			} else {
+				license.LicenseFile = entry
+			}
+			cfg.Licenses = append(cfg.Licenses, license)
+		}
+	}
+	if len(cfg.Licenses) == 0 {
+		return nil, fmt.Errorf("none of %s is set", strings.Join(licenseEnvVars, ", "))
+	}
+	cfg.dropInvalidLicenses()
+
+	level.Info(cfgLogger).Log("msg", "configuration seeded from environment", "licenses", len(cfg.Licenses))
+	return &cfg, nil
+}
+
+// splitList splits a list of license entries separated by
+// os.PathListSeparator, which is a colon on Unix, without splitting bracketed
+// IPv6 addresses.
+func splitList(list string) []string {
+	var (
+		entries []string
+		start   int
+		depth   int
+	)
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case filepath.ListSeparator:
+			if depth == 0 {
+				entries = append(entries, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if list != "" {
+		entries = append(entries, list[start:])
+	}
+	return entries
+}
@@ -0,0 +1,116 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CustomMetric is a gauge taken from the rlmstat output lines matching Regex,
+// for ISVs printing their own status lines like "tokens remaining: 42".
+type CustomMetric struct {
+	// Name is exported as rlmlm_custom_<name>.
+	Name string `yaml:"name"`
+	Help string `yaml:"help,omitempty"`
+	// Regex is matched against every line of the output. Its named groups
+	// but ValueGroup become labels.
+	Regex string `yaml:"regex"`
+	// ValueGroup names the group holding the value.
+	ValueGroup string `yaml:"value_group"`
+}
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Compile compiles Regex and returns it with the label names taken from its
+// named groups, in the order they appear.
+func (m CustomMetric) Compile() (*regexp.Regexp, []string, error) {
+	if !metricNameRegex.MatchString(m.Name) {
+		return nil, nil, fmt.Errorf("invalid custom metric name %q", m.Name)
+	}
+	re, err := regexp.Compile(m.Regex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("custom metric %s: %w", m.Name, err)
+	}
+
+	var (
+		labels   []string
+		hasValue bool
+		seen     = make(map[string]bool)
+	)
+	for _, name := range re.SubexpNames()[1:] {
+		switch {
+		case name == "":
+			continue
+		case seen[name]:
+			return nil, nil, fmt.Errorf("custom metric %s: group %q is used twice", m.Name, name)
+		case name == m.ValueGroup:
+			hasValue = true
+		case !labelNameRegex.MatchString(name) || strings.HasPrefix(name, "__"):
+			return nil, nil, fmt.Errorf("custom metric %s: group %q isn't a valid label name", m.Name, name)
+		case name == "license_name":
+			return nil, nil, fmt.Errorf("custom metric %s: group %q clashes with the license_name label", m.Name, name)
+		default:
+			labels = append(labels, name)
+		}
+		seen[name] = true
+	}
+	if !hasValue {
+		return nil, nil, fmt.Errorf("custom metric %s: value_group %q isn't a named group of the regex", m.Name, m.ValueGroup)
+	}
+	return re, labels, nil
+}
+
+// customMetricSignatures holds the help and labels of the custom and derived
+// metrics of the licenses added so far, by exported name without namespace.
+// Metrics of the same name must have the same help and labels in every
+// license.
+type customMetricSignatures map[string]customMetricSignature
+
+type customMetricSignature struct{ help, labels string }
+
+// add validates the custom and derived metrics of license against those
+// added before, and records them if they are all valid. It returns the
+// rejection reason with the error.
+func (s customMetricSignatures) add(license License) (string, error) {
+	sigs := make(map[string]customMetricSignature, len(license.CustomMetrics)+len(license.DerivedMetrics))
+	for _, m := range license.CustomMetrics {
+		_, labels, err := m.Compile()
+		if err != nil {
+			return ReasonInvalidCustomMetric, fmt.Errorf("license %s: %w", license.Name, err)
+		}
+		if err := s.check(sigs, "custom_"+m.Name, customMetricSignature{m.Help, strings.Join(labels, ",")}); err != nil {
+			return ReasonInvalidCustomMetric, fmt.Errorf("license %s: custom metric %s %w", license.Name, m.Name, err)
+		}
+	}
+	for _, m := range license.DerivedMetrics {
+		if _, err := m.Compile(); err != nil {
+			return ReasonInvalidDerivedMetric, fmt.Errorf("license %s: %w", license.Name, err)
+		}
+		if err := s.check(sigs, "derived_"+m.Name, customMetricSignature{help: m.Help}); err != nil {
+			return ReasonInvalidDerivedMetric, fmt.Errorf("license %s: derived metric %s %w", license.Name, m.Name, err)
+		}
+	}
+	for name, sig := range sigs {
+		s[name] = sig
+	}
+	return "", nil
+}
+
+// check adds sig to the signatures of the license being added, sigs, if it
+// isn't there yet and matches those of other licenses.
+func (s customMetricSignatures) check(sigs map[string]customMetricSignature, name string, sig customMetricSignature) error {
+	if _, ok := sigs[name]; ok {
+		return errors.New("is defined twice")
+	}
+	if prev, ok := s[name]; ok && prev != sig {
+		return errors.New("differs in help or labels from another license")
+	}
+	sigs[name] = sig
+	return nil
+}
@@ -0,0 +1,64 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestCustomMetricCompile(t *testing.T) {
+	m := CustomMetric{
+		Name:       "tokens_remaining",
+		Regex:      `(?P<vendor>\w+) tokens remaining: (?P<tokens>\d+) \((?:pool )?(?P<pool>\w+)\)`,
+		ValueGroup: "tokens",
+	}
+	_, labels, err := m.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[0] != "vendor" || labels[1] != "pool" {
+		t.Fatalf("unexpected labels %v", labels)
+	}
+
+	for _, bad := range []CustomMetric{
+		{Name: "tokens-remaining", Regex: `(?P<v>\d+)`, ValueGroup: "v"},
+		{Name: "tokens", Regex: `(?P<v>\d+`, ValueGroup: "v"},
+		{Name: "tokens", Regex: `(?P<v>\d+)`, ValueGroup: "value"},
+		{Name: "tokens", Regex: `(?P<license_name>\w+) (?P<v>\d+)`, ValueGroup: "v"},
+		{Name: "tokens", Regex: `(?P<1pool>\w+) (?P<v>\d+)`, ValueGroup: "v"},
+		{Name: "tokens", Regex: `(?P<pool>\w+) (?P<pool>\w+) (?P<v>\d+)`, ValueGroup: "v"},
+	} {
+		if _, _, err := bad.Compile(); err == nil {
+			t.Fatalf("expected an error for %+v", bad)
+		}
+	}
+}
+
+func TestValidateCustomMetrics(t *testing.T) {
+	tokens := CustomMetric{Name: "tokens", Regex: `tokens: (?P<v>\d+)`, ValueGroup: "v"}
+	withPool := CustomMetric{Name: "tokens", Regex: `(?P<pool>\w+) tokens: (?P<v>\d+)`, ValueGroup: "v"}
+
+	signatures := make(customMetricSignatures)
+	if _, err := signatures.add(License{Name: "app1", CustomMetrics: []CustomMetric{tokens}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signatures.add(License{Name: "app2", CustomMetrics: []CustomMetric{tokens}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signatures.add(License{Name: "app3", CustomMetrics: []CustomMetric{withPool}}); err == nil {
+		t.Fatal("expected an error for labels differing between licenses")
+	}
+	if _, err := signatures.add(License{Name: "app4", CustomMetrics: []CustomMetric{tokens, tokens}}); err == nil {
+		t.Fatal("expected an error for a metric defined twice")
+	}
+
+	// Rejected licenses don't define the metric for the others.
+	signatures = make(customMetricSignatures)
+	bad := CustomMetric{Name: "other", Regex: `(`, ValueGroup: "v"}
+	if _, err := signatures.add(License{Name: "app1", CustomMetrics: []CustomMetric{withPool, bad}}); err == nil {
+		t.Fatal("expected an error for an invalid regex")
+	}
+	if _, err := signatures.add(License{Name: "app2", CustomMetrics: []CustomMetric{tokens}}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,38 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/iambengiey/rlmlm_exporter/expr"
+)
+
+// DerivedMetricFunctions are the functions the expressions of derived
+// metrics may call besides min, max and abs. Each takes a feature name,
+// which may contain * wildcards to add up several features.
+var DerivedMetricFunctions = []string{"issued", "used", "queued", "users", "features"}
+
+// DerivedMetric is a gauge computed from the features of a license, for
+// sites needing values no built-in metric provides, like the seats of a
+// bundle spread over several features.
+type DerivedMetric struct {
+	// Name is exported as rlmlm_derived_<name>.
+	Name string `yaml:"name"`
+	Help string `yaml:"help,omitempty"`
+	// Expr is evaluated at every collection, see the expr package, e.g.
+	// `used("solver") + used("solver_hpc") / 4`.
+	Expr string `yaml:"expr"`
+}
+
+// Compile validates the name and compiles the expression of m.
+func (m DerivedMetric) Compile() (*expr.Program, error) {
+	if !metricNameRegex.MatchString(m.Name) {
+		return nil, fmt.Errorf("invalid derived metric name %q", m.Name)
+	}
+	p, err := expr.Compile(m.Expr, DerivedMetricFunctions...)
+	if err != nil {
+		return nil, fmt.Errorf("derived metric %s: %w", m.Name, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,30 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import "testing"
+
+func TestValidateDerivedMetrics(t *testing.T) {
+	bundle := DerivedMetric{Name: "bundle_seats", Expr: `used("solver") + used("solver_hpc") / 4`}
+
+	signatures := make(customMetricSignatures)
+	if _, err := signatures.add(License{Name: "app1", DerivedMetrics: []DerivedMetric{bundle}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []DerivedMetric{
+		{Name: "bundle seats", Expr: `1`},
+		{Name: "bundle_seats", Expr: `used("solver") +`},
+		{Name: "bundle_seats", Expr: `seats("solver")`},
+		{Name: "bundle_seats", Help: "Other help.", Expr: `1`},
+	} {
+		reason, err := signatures.add(License{Name: "app2", DerivedMetrics: []DerivedMetric{bad}})
+		if err == nil || reason != ReasonInvalidDerivedMetric {
+			t.Fatalf("expected %s for %+v, got %q: %v", ReasonInvalidDerivedMetric, bad, reason, err)
+		}
+	}
+	// Custom and derived metrics of the same name are exported under other names.
+	tokens := CustomMetric{Name: "bundle_seats", Regex: `seats: (?P<v>\d+)`, ValueGroup: "v"}
+	if _, err := signatures.add(License{Name: "app3", CustomMetrics: []CustomMetric{tokens}, DerivedMetrics: []DerivedMetric{bundle}}); err != nil {
+		t.Fatal(err)
+	}
+}
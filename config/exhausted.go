@@ -0,0 +1,136 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// DefaultExhaustedFor is how long a feature stays exhausted before
+// on_exhausted runs by default.
+const DefaultExhaustedFor = 10 * time.Minute
+
+// ExhaustedEvent is what the on_exhausted command of a license is rendered
+// with, as in {{.Feature}}.
+type ExhaustedEvent struct {
+	License string
+	Feature string
+	Issued  float64
+	Used    float64
+	Queued  float64
+	// Since is when the feature was first seen exhausted.
+	Since time.Time
+}
+
+// ExhaustedAfter returns how long a feature stays exhausted before
+// on_exhausted runs.
+func (l License) ExhaustedAfter() time.Duration {
+	if l.ExhaustedFor == 0 {
+		return DefaultExhaustedFor
+	}
+	return l.ExhaustedFor
+}
+
+// ExhaustedCommand returns the arguments of the on_exhausted command for
+// event. The command is split on the whitespace outside of the template
+// actions before they are filled in, so that actions may hold spaces, like
+// {{ .Feature }}, and every value is passed as a single argument.
+func (l License) ExhaustedCommand(event ExhaustedEvent) ([]string, error) {
+	if _, err := newExhaustedTemplate(l.OnExhausted); err != nil {
+		return nil, err
+	}
+	fields := splitTemplateFields(l.OnExhausted)
+	args := make([]string, 0, len(fields))
+	for _, field := range fields {
+		tmpl, err := newExhaustedTemplate(field)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, event); err != nil {
+			return nil, err
+		}
+		args = append(args, buf.String())
+	}
+	return args, nil
+}
+
+func newExhaustedTemplate(text string) (*template.Template, error) {
+	return template.New("on_exhausted").Option("missingkey=error").Parse(text)
+}
+
+// splitTemplateFields splits s around the whitespace outside of {{ }}
+// actions and of the strings within them.
+func splitTemplateFields(s string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+		action bool
+		quote  rune
+		escape bool
+	)
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			switch {
+			case escape:
+				escape = false
+			case r == '\\' && quote == '"':
+				escape = true
+			case r == quote:
+				quote = 0
+			}
+		case action:
+			switch {
+			case r == '"' || r == '`' || r == '\'':
+				quote = r
+			case r == '}' && i+1 < len(runes) && runes[i+1] == '}':
+				action = false
+				field.WriteString("}}")
+				i++
+				continue
+			}
+		case r == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			action = true
+			field.WriteString("{{")
+			i++
+			continue
+		case unicode.IsSpace(r):
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			continue
+		}
+		field.WriteRune(r)
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// validateOnExhausted checks that the on_exhausted command renders.
+func (l License) validateOnExhausted() error {
+	if l.OnExhausted == "" {
+		return nil
+	}
+	if l.ExhaustedFor < 0 {
+		return fmt.Errorf("negative exhausted_for for %s", l.Name)
+	}
+	args, err := l.ExhaustedCommand(ExhaustedEvent{License: l.Name, Feature: "feature"})
+	if err != nil {
+		return fmt.Errorf("invalid on_exhausted for %s: %w", l.Name, err)
+	}
+	if len(args) == 0 || args[0] == "" {
+		return fmt.Errorf("on_exhausted of %s has no command", l.Name)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import "net/url"
+
+// redacted replaces the secrets of a configuration shown to users.
+const redacted = "<redacted>"
+
+// Redacted returns a copy of the configuration to show, like on /config,
+// without the values of the license environments, the on_exhausted commands,
+// the passwords of URLs and the paths of token, key and password files.
+func (cfg *Config) Redacted() *Config {
+	if cfg == nil {
+		return nil
+	}
+	r := *cfg
+	r.Licenses = make([]License, len(cfg.Licenses))
+	for i, l := range cfg.Licenses {
+		if l.Env != nil {
+			env := make(map[string]string, len(l.Env))
+			for name := range l.Env {
+				env[name] = redacted
+			}
+			l.Env = env
+		}
+		if l.OnExhausted != "" {
+			l.OnExhausted = redacted
+		}
+		r.Licenses[i] = l
+	}
+	r.ActivationServers = make([]ActivationServer, len(cfg.ActivationServers))
+	for i, s := range cfg.ActivationServers {
+		s.URL = redactURL(s.URL)
+		s.HTTPClient = s.HTTPClient.redacted()
+		r.ActivationServers[i] = s
+	}
+	if cfg.Heartbeat != nil {
+		h := *cfg.Heartbeat
+		h.URL = redactURL(h.URL)
+		h.HTTPClient = h.HTTPClient.redacted()
+		r.Heartbeat = &h
+	}
+	if cfg.Report != nil {
+		report := *cfg.Report
+		if report.SMTP.PasswordFile != "" {
+			report.SMTP.PasswordFile = redacted
+		}
+		r.Report = &report
+	}
+	r.Tenants = make([]Tenant, len(cfg.Tenants))
+	for i, t := range cfg.Tenants {
+		if t.TokenFile != "" {
+			t.TokenFile = redacted
+		}
+		r.Tenants[i] = t
+	}
+	return &r
+}
+
+func (h HTTPClient) redacted() HTTPClient {
+	if h.KeyFile != "" {
+		h.KeyFile = redacted
+	}
+	if h.BearerTokenFile != "" {
+		h.BearerTokenFile = redacted
+	}
+	h.ProxyURL = redactURL(h.ProxyURL)
+	return h
+}
+
+// redactURL replaces the password of rawURL, if any.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if _, ok := u.User.Password(); !ok {
+		return rawURL
+	}
+	return u.Redacted()
+}
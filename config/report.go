@@ -0,0 +1,84 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultReportExpiringWithin is how far ahead the email report lists
+// expiring features by default.
+const DefaultReportExpiringWithin = 30 * 24 * time.Hour
+
+// Report emails a summary of the usage, the expiring features and the
+// servers down on a schedule, for readers without Grafana.
+type Report struct {
+	// Schedule is a cron expression, e.g. "0 8 * * 1-5".
+	Schedule string `yaml:"schedule"`
+	// Timezone is the IANA zone the schedule runs in, UTC if empty.
+	Timezone string `yaml:"timezone,omitempty"`
+	// Subject defaults to "License report".
+	Subject string `yaml:"subject,omitempty"`
+	// ExpiringWithin lists the features expiring within this duration,
+	// DefaultReportExpiringWithin if zero.
+	ExpiringWithin time.Duration `yaml:"expiring_within,omitempty"`
+	SMTP           SMTP          `yaml:"smtp"`
+
+	schedule *Schedule
+	location *time.Location
+}
+
+// SMTP configures the server reports are sent through. STARTTLS is used if
+// the server offers it.
+type SMTP struct {
+	// Address is the host:port of the server.
+	Address string   `yaml:"address"`
+	From    string   `yaml:"from"`
+	To      []string `yaml:"to"`
+	// Username and the password read from PasswordFile authenticate with
+	// PLAIN, which needs TLS unless the server is local.
+	Username     string `yaml:"username,omitempty"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+}
+
+// validate checks the settings, parses the schedule and sets the defaults.
+func (r *Report) validate() error {
+	schedule, err := ParseSchedule(r.Schedule)
+	if err != nil {
+		return err
+	}
+	r.schedule = schedule
+	r.location = time.UTC
+	if r.Timezone != "" {
+		if r.location, err = time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	if r.Subject == "" {
+		r.Subject = "License report"
+	}
+	if r.ExpiringWithin == 0 {
+		r.ExpiringWithin = DefaultReportExpiringWithin
+	}
+	if r.ExpiringWithin < 0 {
+		return errors.New("expiring_within must be positive")
+	}
+	if _, _, err := net.SplitHostPort(r.SMTP.Address); err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+	if r.SMTP.From == "" || len(r.SMTP.To) == 0 {
+		return errors.New("smtp needs from and to")
+	}
+	if (r.SMTP.Username == "") != (r.SMTP.PasswordFile == "") {
+		return errors.New("smtp username and password_file must be set together")
+	}
+	return nil
+}
+
+// Due reports whether a report is scheduled in the minute of t.
+func (r *Report) Due(t time.Time) bool {
+	return r.schedule.Matches(t.In(r.location))
+}
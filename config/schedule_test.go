@@ -0,0 +1,80 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	// 2025-03-03 is a Monday.
+	monday := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"0 8 * * *", monday, true},
+		{"0 8 * * *", monday.Add(time.Minute), false},
+		{"0 8 * * 1-5", monday, true},
+		{"0 8 * * 1-5", monday.AddDate(0, 0, 5), false},
+		{"0 8 * * 0", monday.AddDate(0, 0, 6), true},
+		{"0 8 * * 7", monday.AddDate(0, 0, 6), true},
+		{"*/15 * * * *", monday.Add(45 * time.Minute), true},
+		{"*/15 * * * *", monday.Add(50 * time.Minute), false},
+		{"5/20 8 * * *", monday.Add(45 * time.Minute), true},
+		{"0 8,17 1 * *", monday.AddDate(0, 0, -2).Add(9 * time.Hour), true},
+		// Either restricted day field matches, as in cron.
+		{"0 8 1 * 1", monday, true},
+		{"0 8 1 * 2", monday, false},
+		{"0 8 * 4 *", monday, false},
+	} {
+		s, err := ParseSchedule(tc.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Matches(tc.t); got != tc.want {
+			t.Fatalf("expected %q to match %s: %v, got %v", tc.expr, tc.t, tc.want, got)
+		}
+	}
+
+	for _, invalid := range []string{"", "0 8 * *", "60 8 * * *", "0 8 0 * *", "0 8 * * 8", "0 8-6 * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(invalid); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	cfg, err := Parse([]byte(`report:
+  schedule: "0 8 * * 1-5"
+  timezone: Europe/Paris
+  smtp:
+    address: smtp.example.com:587
+    from: rlmlm@example.com
+    to: [it@example.com]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := cfg.Report
+	if r.Subject != "License report" || r.ExpiringWithin != DefaultReportExpiringWithin {
+		t.Fatalf("unexpected defaults %+v", r)
+	}
+	// 08:00 in Paris is 07:00 UTC in winter.
+	if !r.Due(time.Date(2025, 3, 3, 7, 0, 0, 0, time.UTC)) || r.Due(time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the report to be due at 08:00 in Paris")
+	}
+
+	for _, invalid := range []string{
+		"report: {schedule: '0 8 * *', smtp: {address: 'smtp:25', from: a@b, to: [c@d]}}",
+		"report: {schedule: '0 8 * * *', smtp: {address: smtp, from: a@b, to: [c@d]}}",
+		"report: {schedule: '0 8 * * *', smtp: {address: 'smtp:25', from: a@b}}",
+		"report: {schedule: '0 8 * * *', smtp: {address: 'smtp:25', from: a@b, to: [c@d], username: me}}",
+	} {
+		if _, err := Parse([]byte(invalid + "\n")); err == nil {
+			t.Fatalf("expected an error for %s", invalid)
+		}
+	}
+}
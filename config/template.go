@@ -0,0 +1,39 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// render executes the configuration template data with the variables of the
+// YAML file at varsPath, e.g. {{ .site }} or {{ .servers.primary }}. Using a
+// variable missing from the file is an error rather than an empty value.
+func render(data []byte, varsPath string) ([]byte, error) {
+	raw, err := os.ReadFile(filepath.Clean(varsPath))
+	if err != nil {
+		// Not wrapped, a missing vars file isn't a missing configuration file
+		// that callers may fall back from.
+		return nil, fmt.Errorf("couldn't read vars file: %v", err)
+	}
+	vars := make(map[string]interface{})
+	if err := yaml.Unmarshal(raw, &vars); err != nil {
+		return nil, fmt.Errorf("invalid vars file %s: %w", varsPath, err)
+	}
+
+	tmpl, err := template.New("config").Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("couldn't render config template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
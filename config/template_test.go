@@ -0,0 +1,45 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "licenses.yml")
+	vars := filepath.Join(dir, "site.yml")
+	template := []byte(`licenses:
+{{- range .isvs }}
+  - name: {{ $.site }}_{{ . }}
+    license_server: {{ $.servers.port }}@{{ $.servers.host }}
+{{- end }}
+`)
+	if err := os.WriteFile(path, template, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(vars, []byte("site: paris\nservers:\n  host: rlm.paris.example.com\n  port: 5053\nisvs: [acme, beta]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadTemplate(path, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Licenses) != 2 || cfg.Licenses[1].Name != "paris_beta" || cfg.Licenses[1].LicenseServer != "5053@rlm.paris.example.com" {
+		t.Fatalf("unexpected licenses %+v", cfg.Licenses)
+	}
+
+	if err := os.WriteFile(vars, []byte("site: paris\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTemplate(path, vars); err == nil {
+		t.Fatal("expected an error for missing variables")
+	}
+	if _, err := LoadTemplate(path, filepath.Join(dir, "missing.yml")); err == nil {
+		t.Fatal("expected an error for a missing vars file")
+	}
+}
@@ -0,0 +1,101 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-kit/log/level"
+)
+
+// Tenant is a business unit served a view of its own licenses, those whose
+// tenant is Name, by a central exporter.
+type Tenant struct {
+	Name string `yaml:"name"`
+	// TokenFile holds the bearer token scoping requests to the tenant. It
+	// is read on every request so that rotated tokens are picked up.
+	TokenFile string `yaml:"token_file,omitempty"`
+	// All makes the token see every license, like for the central
+	// Prometheus, rather than those of the tenant.
+	All bool `yaml:"all,omitempty"`
+}
+
+// validateTenants checks that the tenants have distinct names.
+func validateTenants(tenants []Tenant) error {
+	names := make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		if t.Name == "" {
+			return errors.New("tenant without a name")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("tenant %s is defined twice", t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
+
+// HasTenant reports whether name is the tenant of a license or listed in
+// Tenants.
+func (cfg *Config) HasTenant(name string) bool {
+	for _, t := range cfg.Tenants {
+		if t.Name == name {
+			return true
+		}
+	}
+	for _, l := range cfg.Licenses {
+		if l.Tenant == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForTenant returns a copy of cfg with only the licenses of tenant.
+func (cfg *Config) ForTenant(tenant string) *Config {
+	scoped := *cfg
+	scoped.Licenses = nil
+	scoped.Rejected = nil
+	for _, l := range cfg.Licenses {
+		if l.Tenant == tenant {
+			scoped.Licenses = append(scoped.Licenses, l)
+		}
+	}
+	return &scoped
+}
+
+// HasTenantTokens reports whether any tenant authenticates with a token.
+func (cfg *Config) HasTenantTokens() bool {
+	for _, t := range cfg.Tenants {
+		if t.TokenFile != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantOfToken returns the tenant whose token file holds token.
+func (cfg *Config) TenantOfToken(token string) (Tenant, bool) {
+	if token == "" {
+		return Tenant{}, false
+	}
+	for _, t := range cfg.Tenants {
+		if t.TokenFile == "" {
+			continue
+		}
+		data, err := os.ReadFile(t.TokenFile)
+		if err != nil {
+			level.Warn(cfgLogger).Log("msg", "couldn't read tenant token", "tenant", t.Name, "path", t.TokenFile, "err", err)
+			continue
+		}
+		expected := strings.TrimSpace(string(data))
+		if expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1 {
+			return t, true
+		}
+	}
+	return Tenant{}, false
+}
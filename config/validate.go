@@ -0,0 +1,190 @@
+// Licensed under the Apache License, Version 2.0.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log/level"
+)
+
+// Reasons a license is rejected, exported as metric label values. The first
+// four are about its target.
+const (
+	ReasonMissingTarget        = "missing_target"
+	ReasonInvalidServer        = "invalid_license_server"
+	ReasonRelativeFile         = "relative_license_file"
+	ReasonShellMetacharacter   = "shell_metacharacter"
+	ReasonInvalidEnv           = "invalid_env"
+	ReasonInvalidFeatureAlias  = "invalid_feature_alias"
+	ReasonInvalidCustomMetric  = "invalid_custom_metric"
+	ReasonInvalidDerivedMetric = "invalid_derived_metric"
+	ReasonInvalidISVs          = "invalid_isvs"
+	ReasonInvalidQueryFeatures = "invalid_query_features"
+	ReasonInvalidOnExhausted   = "invalid_on_exhausted"
+	ReasonMissingName          = "missing_name"
+	ReasonDuplicateName        = "duplicate_name"
+)
+
+// shellMetacharacters may not appear in license targets. rlmstat is never run
+// through a shell, but such values are almost certainly not a real target.
+const shellMetacharacters = "`$;|&<>(){}[]*?!~'\"\\\n\r\t\x00"
+
+// serverShellMetacharacters are shellMetacharacters but the brackets of
+// IPv6 hosts like port@[2001:db8::1].
+var serverShellMetacharacters = strings.NewReplacer("[", "", "]", "").Replace(shellMetacharacters)
+
+var hostnameRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9\-\.]*[A-Za-z0-9])?$`)
+
+// Rejection records a license entry that was dropped while loading.
+type Rejection struct {
+	License string
+	Reason  string
+	Err     error
+}
+
+// TargetError is returned by License.ValidateTarget.
+type TargetError struct {
+	Reason string
+	Err    error
+}
+
+func (e *TargetError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateTarget checks that the license_server is a comma separated list of
+// port@host or host entries, or that the license_file is an absolute path,
+// and that neither contains shell metacharacters.
+func (l License) ValidateTarget() error {
+	switch {
+	case l.LicenseFile != "":
+		if i := strings.IndexAny(l.LicenseFile, shellMetacharacters); i >= 0 && !isPathSeparator(l.LicenseFile[i]) {
+			return &TargetError{ReasonShellMetacharacter,
+				fmt.Errorf("license_file %q contains %q", l.LicenseFile, l.LicenseFile[i])}
+		}
+		if !filepath.IsAbs(l.LicenseFile) {
+			return &TargetError{ReasonRelativeFile,
+				fmt.Errorf("license_file %q is not an absolute path", l.LicenseFile)}
+		}
+	case l.LicenseServer != "":
+		if i := strings.IndexAny(l.LicenseServer, serverShellMetacharacters); i >= 0 {
+			return &TargetError{ReasonShellMetacharacter,
+				fmt.Errorf("license_server %q contains %q", l.LicenseServer, l.LicenseServer[i])}
+		}
+		for _, entry := range strings.Split(l.LicenseServer, ",") {
+			if err := validateServerEntry(entry); err != nil {
+				return &TargetError{ReasonInvalidServer,
+					fmt.Errorf("license_server %q: %w", l.LicenseServer, err)}
+			}
+		}
+	default:
+		return &TargetError{ReasonMissingTarget, fmt.Errorf("neither license_file nor license_server is set")}
+	}
+	return nil
+}
+
+// validateServerEntry checks a single port@host entry, or a host alone whose
+// port is discovered. IPv6 hosts are bracketed, as in 5053@[2001:db8::1].
+func validateServerEntry(entry string) error {
+	host := entry
+	if port, h, ok := strings.Cut(entry, "@"); ok {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q in %q", port, entry)
+		}
+		host = h
+	}
+	if strings.HasPrefix(host, "[") {
+		if ip := net.ParseIP(ServerHost(host)); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address %q in %q", host, entry)
+		}
+		return nil
+	}
+	if !hostnameRegex.MatchString(host) {
+		return fmt.Errorf("invalid host %q in %q", host, entry)
+	}
+	return nil
+}
+
+// ServerHost returns host without the brackets of an IPv6 address, the form
+// used in metric labels.
+func ServerHost(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
+
+// isPathSeparator allows backslashes in Windows license_file paths.
+func isPathSeparator(c byte) bool {
+	return c == '\\' && filepath.Separator == '\\'
+}
+
+// dropInvalidLicenses removes invalid licenses from cfg, recording them in
+// cfg.Rejected, so that a single malformed entry doesn't stop the others
+// from being monitored.
+func (cfg *Config) dropInvalidLicenses() {
+	signatures := make(customMetricSignatures)
+	names := make(map[string]bool, len(cfg.Licenses))
+	valid := cfg.Licenses[:0]
+	for _, license := range cfg.Licenses {
+		var (
+			reason string
+			err    error
+		)
+		switch {
+		case license.Name == "":
+			reason, err = ReasonMissingName, errors.New("license without a name")
+		case names[license.Name]:
+			reason, err = ReasonDuplicateName, fmt.Errorf("license %s is defined twice", license.Name)
+		default:
+			reason, err = license.validate(signatures)
+		}
+		if err == nil {
+			names[license.Name] = true
+			valid = append(valid, license)
+			continue
+		}
+		level.Error(cfgLogger).Log("msg", "rejecting invalid license", "license", license.Name, "reason", reason, "err", err)
+		cfg.Rejected = append(cfg.Rejected, Rejection{License: license.Name, Reason: reason, Err: err})
+	}
+	cfg.Licenses = valid
+}
+
+// validate checks license, returning the reason it is rejected for if it is
+// invalid. The custom metrics of valid licenses are added to signatures.
+func (l License) validate(signatures customMetricSignatures) (string, error) {
+	if err := l.ValidateTarget(); err != nil {
+		reason := ReasonMissingTarget
+		if te, ok := err.(*TargetError); ok {
+			reason = te.Reason
+		}
+		return reason, err
+	}
+	if err := l.validateEnv(); err != nil {
+		return ReasonInvalidEnv, err
+	}
+	if err := l.validateFeatureAliases(); err != nil {
+		return ReasonInvalidFeatureAlias, err
+	}
+	if err := l.validateISVs(); err != nil {
+		return ReasonInvalidISVs, err
+	}
+	if err := l.validateQueryFeatures(); err != nil {
+		return ReasonInvalidQueryFeatures, err
+	}
+	if err := l.validateOnExhausted(); err != nil {
+		return ReasonInvalidOnExhausted, err
+	}
+	return signatures.add(l)
+}
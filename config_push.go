@@ -0,0 +1,129 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// maxConfigSize bounds the configurations accepted by PUT /api/v1/config.
+const maxConfigSize = 4 << 20
+
+// configPushHandler replaces the configuration in use and the file at
+// configPath with the YAML body, for configuration management tools. The
+// body is rejected as a whole if any license entry is invalid, and the
+// running configuration is restored if it can't be swapped in.
+func configPushHandler(configPath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if configVarsPath != "" {
+			http.Error(w, "The configuration file is a template rendered with --path.config-vars, push the template and variables files instead", http.StatusConflict)
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigSize))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, fmt.Sprintf("The configuration is larger than %d bytes", maxConfigSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Couldn't read configuration: %s", err), http.StatusBadRequest)
+			return
+		}
+		cfg, err := validatePushedConfig(data)
+		if err != nil {
+			level.Warn(baseLogger).Log("msg", "rejected pushed configuration", "err", err)
+			http.Error(w, fmt.Sprintf("Invalid configuration: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		stateMu.Lock()
+		defer stateMu.Unlock()
+		if err := swapConfig(configPath, data, cfg); err != nil {
+			level.Error(baseLogger).Log("msg", "failed to apply pushed configuration", "path", configPath, "err", err)
+			http.Error(w, fmt.Sprintf("Couldn't apply configuration, the previous one is kept: %s", err), http.StatusInternalServerError)
+			return
+		}
+		level.Info(baseLogger).Log("msg", "configuration pushed", "path", configPath, "licenses", len(cfg.Licenses))
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"licenses": len(cfg.Licenses)}); err != nil {
+			level.Error(baseLogger).Log("msg", "failed to write configuration state", "err", err)
+		}
+	}
+}
+
+// validatePushedConfig parses data, failing on license entries that loading
+// the file would only skip and on conflicting metric descriptors.
+func validatePushedConfig(data []byte) (*config.Config, error) {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Rejected) > 0 {
+		msgs := make([]string, 0, len(cfg.Rejected))
+		for _, rejected := range cfg.Rejected {
+			msgs = append(msgs, fmt.Sprintf("%s (%s): %s", rejected.License, rejected.Reason, rejected.Err))
+		}
+		return nil, fmt.Errorf("invalid licenses: %s", strings.Join(msgs, "; "))
+	}
+	if err := collector.CheckDescriptors(cfg, baseLogger); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// swapConfig applies cfg and replaces the file at path with data, which
+// holds it. On failure the previous configuration and file are kept. The
+// caller must hold stateMu.
+func swapConfig(path string, data []byte, cfg *config.Config) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	prev := appConfig
+	rollback := func(err error) error {
+		if rbErr := applyConfig(prev); rbErr != nil {
+			level.Error(baseLogger).Log("msg", "failed to restore the previous configuration", "err", rbErr)
+		}
+		return err
+	}
+	if err := applyConfig(cfg); err != nil {
+		return rollback(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return rollback(err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestConfigPushHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "licenses.yml")
+	initial := "licenses:\n  - name: app1\n    license_server: 5053@host1\n"
+	if err := os.WriteFile(path, []byte(initial), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prev := &config.Config{Licenses: []config.License{{Name: "app1", LicenseServer: "5053@host1"}}}
+	appConfig = prev
+	defer func() {
+		appConfig = nil
+		collector.SetConfig(nil)
+	}()
+	mux := http.NewServeMux()
+	registerAdminHandlers(mux, func(h http.Handler) http.Handler { return h }, path)
+
+	push := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("PUT", "/api/v1/config", strings.NewReader(body)))
+		return w
+	}
+	for _, body := range []string{
+		"licenses: [",
+		"licenses:\n  - name: app2\n    license_file: relative.lic\n",
+		"licenses:\n  - name: app2\n    license_server: 5053@host2\n  - name: app2\n    license_server: 5053@host3\n",
+	} {
+		if w := push(body); w.Code != http.StatusBadRequest {
+			t.Fatalf("Unexpected status %d for %q: %s", w.Code, body, w.Body)
+		}
+	}
+	if data, _ := os.ReadFile(path); appConfig != prev || string(data) != initial {
+		t.Fatal("Expected the configuration to be kept after invalid pushes")
+	}
+
+	pushed := "licenses:\n  - name: app2\n    license_server: 5053@host2\n"
+	if w := push(pushed); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"licenses":1}` {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body)
+	}
+	if len(appConfig.Licenses) != 1 || appConfig.Licenses[0].Name != "app2" {
+		t.Fatalf("Unexpected configuration %+v", appConfig.Licenses)
+	}
+	if data, _ := os.ReadFile(path); string(data) != pushed {
+		t.Fatalf("Expected the configuration file to be replaced, got %q", data)
+	}
+
+	configVarsPath = "site.yml"
+	defer func() { configVarsPath = "" }()
+	if w := push(pushed); w.Code != http.StatusConflict {
+		t.Fatalf("Unexpected status %d for a templated configuration", w.Code)
+	}
+}
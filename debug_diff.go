@@ -0,0 +1,202 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// diffTimeout bounds scraping the other exporter.
+const diffTimeout = 30 * time.Second
+
+var diffClient = &http.Client{Timeout: diffTimeout}
+
+// metricsDiff is how the metrics of this exporter differ from another's.
+// Series are only compared within families both expose.
+type metricsDiff struct {
+	Other     string          `json:"other"`
+	OnlyHere  diffSide        `json:"only_here"`
+	OnlyOther diffSide        `json:"only_other"`
+	Changed   []changedSeries `json:"changed,omitempty"`
+}
+
+type diffSide struct {
+	Families []string `json:"families"`
+	Series   []string `json:"series"`
+}
+
+// changedSeries is a series whose value differs, reported with values=true.
+type changedSeries struct {
+	Series string `json:"series"`
+	Here   string `json:"here"`
+	Other  string `json:"other"`
+}
+
+// diffHandler scrapes the metrics URL in the other query parameter, like
+// another version of the exporter, and reports the metric families and
+// series that differ from this exporter's as JSON, to validate parser
+// changes during rolling upgrades. With values=true series whose values
+// differ are reported too.
+func diffHandler(w http.ResponseWriter, r *http.Request) {
+	other := r.URL.Query().Get("other")
+	if u, err := url.Parse(other); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "The other parameter must be an http or https URL", http.StatusBadRequest)
+		return
+	}
+	values, _ := strconv.ParseBool(r.URL.Query().Get("values"))
+
+	there, err := scrapeFamilies(r, other)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Couldn't scrape %s: %s", other, err), http.StatusBadGateway)
+		return
+	}
+	here, err := gatherMetrics()
+	if err != nil && len(here) == 0 {
+		http.Error(w, fmt.Sprintf("Couldn't gather metrics: %s", err), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		level.Warn(baseLogger).Log("msg", "error gathering metrics for /debug/diff", "err", err)
+	}
+
+	d := diffMetrics(here, there, values)
+	d.Other = other
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write metrics diff", "err", err)
+	}
+}
+
+// scrapeFamilies scrapes the metric families served at target.
+func scrapeFamilies(r *http.Request, target string) ([]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	resp, err := diffClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var families []*dto.MetricFamily
+	dec := expfmt.NewDecoder(resp.Body, expfmt.ResponseFormat(resp.Header))
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return families, nil
+			}
+			return nil, err
+		}
+		families = append(families, mf)
+	}
+}
+
+// diffMetrics compares the families here with the families there.
+func diffMetrics(here, there []*dto.MetricFamily, values bool) metricsDiff {
+	hereFamilies, hereSeries := indexSeries(here)
+	thereFamilies, thereSeries := indexSeries(there)
+
+	d := metricsDiff{
+		OnlyHere:  diffSide{Families: []string{}, Series: []string{}},
+		OnlyOther: diffSide{Families: []string{}, Series: []string{}},
+	}
+	for name := range hereFamilies {
+		if !thereFamilies[name] {
+			d.OnlyHere.Families = append(d.OnlyHere.Families, name)
+		}
+	}
+	for name := range thereFamilies {
+		if !hereFamilies[name] {
+			d.OnlyOther.Families = append(d.OnlyOther.Families, name)
+		}
+	}
+	for key, s := range hereSeries {
+		if !thereFamilies[s.family] {
+			continue
+		}
+		t, ok := thereSeries[key]
+		switch {
+		case !ok:
+			d.OnlyHere.Series = append(d.OnlyHere.Series, key)
+		case values && s.value != t.value:
+			d.Changed = append(d.Changed, changedSeries{Series: key, Here: s.value, Other: t.value})
+		}
+	}
+	for key, t := range thereSeries {
+		if _, ok := hereSeries[key]; !ok && hereFamilies[t.family] {
+			d.OnlyOther.Series = append(d.OnlyOther.Series, key)
+		}
+	}
+
+	sort.Strings(d.OnlyHere.Families)
+	sort.Strings(d.OnlyOther.Families)
+	sort.Strings(d.OnlyHere.Series)
+	sort.Strings(d.OnlyOther.Series)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Series < d.Changed[j].Series })
+	return d
+}
+
+// seriesValue is a flattened series and the family it belongs to.
+type seriesValue struct {
+	family string
+	value  string
+}
+
+// indexSeries returns the family names and the series of families by their
+// text format representation, like name{label="value"}.
+func indexSeries(families []*dto.MetricFamily) (map[string]bool, map[string]seriesValue) {
+	names := make(map[string]bool, len(families))
+	series := make(map[string]seriesValue)
+	for _, mf := range families {
+		names[mf.GetName()] = true
+		for _, ts := range toSeries([]*dto.MetricFamily{mf}, nil, time.Time{}) {
+			series[seriesKey(ts)] = seriesValue{family: mf.GetName(), value: formatFloat(ts.samples[0].value)}
+		}
+	}
+	return names, series
+}
+
+func seriesKey(ts timeSeries) string {
+	var (
+		name   string
+		labels []string
+	)
+	for _, l := range ts.labels {
+		if l.name == "__name__" {
+			name = l.value
+			continue
+		}
+		labels = append(labels, l.name+"="+strconv.Quote(l.value))
+	}
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func parseText(t *testing.T, text string) []*dto.MetricFamily {
+	t.Helper()
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	m, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var families []*dto.MetricFamily
+	for _, mf := range m {
+		families = append(families, mf)
+	}
+	return families
+}
+
+func TestDiffMetrics(t *testing.T) {
+	here := parseText(t, `# TYPE rlmlm_feature_used gauge
+rlmlm_feature_used{feature="feature1",license_name="app1"} 3
+rlmlm_feature_used{feature="feature2",license_name="app1"} 1
+# TYPE rlmlm_feature_soft_limit gauge
+rlmlm_feature_soft_limit{feature="feature1",license_name="app1"} 8
+`)
+	there := parseText(t, `# TYPE rlmlm_feature_used gauge
+rlmlm_feature_used{feature="feature1",license_name="app1"} 2
+rlmlm_feature_used{feature="feature 2",license_name="app1"} 1
+# TYPE rlmlm_lmstat_up gauge
+rlmlm_lmstat_up{license_name="app1"} 1
+`)
+
+	d := diffMetrics(here, there, true)
+	for name, got := range map[string][]string{
+		"only here families":  d.OnlyHere.Families,
+		"only other families": d.OnlyOther.Families,
+		"only here series":    d.OnlyHere.Series,
+		"only other series":   d.OnlyOther.Series,
+	} {
+		want := map[string]string{
+			"only here families":  "rlmlm_feature_soft_limit",
+			"only other families": "rlmlm_lmstat_up",
+			"only here series":    `rlmlm_feature_used{feature="feature2",license_name="app1"}`,
+			"only other series":   `rlmlm_feature_used{feature="feature 2",license_name="app1"}`,
+		}[name]
+		if len(got) != 1 || got[0] != want {
+			t.Fatalf("Unexpected %s %v", name, got)
+		}
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Here != "3" || d.Changed[0].Other != "2" {
+		t.Fatalf("Unexpected changed series %+v", d.Changed)
+	}
+	if d := diffMetrics(here, there, false); len(d.Changed) != 0 {
+		t.Fatalf("Expected no changed values without values=true, got %+v", d.Changed)
+	}
+}
+
+func TestDiffHandler(t *testing.T) {
+	collector.SetConfig(&config.Config{})
+	defer collector.SetConfig(nil)
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "# TYPE rlmlm_old_metric gauge")
+		fmt.Fprintln(w, "rlmlm_old_metric 1")
+	}))
+	defer other.Close()
+
+	w := httptest.NewRecorder()
+	diffHandler(w, httptest.NewRequest("GET", "/debug/diff?other="+url.QueryEscape(other.URL+"/metrics"), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", w.Code, w.Body)
+	}
+	var d metricsDiff
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.OnlyOther.Families) != 1 || d.OnlyOther.Families[0] != "rlmlm_old_metric" {
+		t.Fatalf("Unexpected families only on the other exporter %v", d.OnlyOther.Families)
+	}
+	if len(d.OnlyHere.Families) == 0 {
+		t.Fatal("Expected families only on this exporter")
+	}
+
+	w = httptest.NewRecorder()
+	diffHandler(w, httptest.NewRequest("GET", "/debug/diff?other=file:///etc/passwd", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for a file URL, got %d", w.Code)
+	}
+}
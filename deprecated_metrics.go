@@ -0,0 +1,160 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// metricsLevel is the current metric naming level. Renaming metrics
+	// raises it, the old names are added to deprecatedMetrics with the
+	// previous level.
+	metricsLevel = 2
+	// oldestMetricsLevel is the default of --metrics.compat-level, the oldest
+	// level whose names are still exposed. It is raised, and the older
+	// deprecations removed, two releases after they were deprecated.
+	oldestMetricsLevel = 1
+
+	deprecatedMetricUsed = "rlmlm_deprecated_metric_used"
+)
+
+// metricsCompatLevel is the naming level of --metrics.compat-level.
+var metricsCompatLevel = oldestMetricsLevel
+
+// metricDeprecation is a metric name kept next to its replacement for the
+// dashboards and rules written against it.
+type metricDeprecation struct {
+	name, replacement string
+	// level is the last naming level name belongs to.
+	level int
+	// alias makes name a copy of replacement with the labels renamed by
+	// labels. Otherwise the collectors still export name themselves.
+	alias  bool
+	labels map[string]string
+}
+
+var deprecatedMetrics = []metricDeprecation{
+	{name: "rlmlm_lmstat_up", replacement: "rlmlm_target_up", level: 1},
+	{name: "rlmlm_feature_expiration_seconds", replacement: "rlmlm_feature_line_expiration_seconds", level: 1},
+	{name: "rlmlm_vendor_status", replacement: "rlmlm_isv_status", level: 1, alias: true,
+		labels: map[string]string{"isv": "vendor"}},
+}
+
+// checkMetricsCompatLevel checks that level is a naming level still exposed.
+func checkMetricsCompatLevel(level int) error {
+	if level < oldestMetricsLevel || level > metricsLevel {
+		return fmt.Errorf("metric naming level %d isn't between %d and %d", level, oldestMetricsLevel, metricsLevel)
+	}
+	return nil
+}
+
+// deprecationGatherer exposes the deprecated names of the metrics gathered
+// by g down to a naming level.
+type deprecationGatherer struct {
+	g     prometheus.Gatherer
+	level int
+	// scoped leaves out rlmlm_deprecated_metric_used, which belongs to no
+	// license, from tenant views.
+	scoped bool
+}
+
+// withDeprecations returns g with the deprecated names of
+// --metrics.compat-level, for a tenant view if scoped.
+func withDeprecations(g prometheus.Gatherer, scoped bool) prometheus.Gatherer {
+	return deprecationGatherer{g: g, level: metricsCompatLevel, scoped: scoped}
+}
+
+// Gather implements prometheus.Gatherer. The deprecated names exposed are
+// listed by rlmlm_deprecated_metric_used outside of tenant views.
+func (d deprecationGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := d.g.Gather()
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, mf := range families {
+		byName[mf.GetName()] = mf
+	}
+
+	var (
+		gathered = make([]*dto.MetricFamily, 0, len(families)+len(deprecatedMetrics)+1)
+		used     []metricDeprecation
+	)
+	for _, mf := range families {
+		if dep, ok := findDeprecation(mf.GetName()); ok && !dep.alias {
+			if d.level > dep.level {
+				continue
+			}
+			used = append(used, dep)
+		}
+		gathered = append(gathered, mf)
+	}
+	for _, dep := range deprecatedMetrics {
+		if !dep.alias || d.level > dep.level || byName[dep.name] != nil {
+			continue
+		}
+		mf, ok := byName[dep.replacement]
+		if !ok {
+			continue
+		}
+		alias := renamedFamily(mf, dep.name, func(label string) string {
+			if renamed, ok := dep.labels[label]; ok {
+				return renamed
+			}
+			return label
+		})
+		alias.Help = proto.String(fmt.Sprintf("Deprecated, use %s. %s", dep.replacement, mf.GetHelp()))
+		gathered = append(gathered, alias)
+		used = append(used, dep)
+	}
+	if len(used) > 0 && !d.scoped {
+		gathered = append(gathered, deprecatedMetricsFamily(used))
+	}
+	sort.Slice(gathered, func(i, j int) bool { return gathered[i].GetName() < gathered[j].GetName() })
+	return gathered, err
+}
+
+// findDeprecation returns the deprecation of the metric name, if any.
+func findDeprecation(name string) (metricDeprecation, bool) {
+	for _, dep := range deprecatedMetrics {
+		if dep.name == name {
+			return dep, true
+		}
+	}
+	return metricDeprecation{}, false
+}
+
+// deprecatedMetricsFamily returns rlmlm_deprecated_metric_used for the
+// deprecated names exposed.
+func deprecatedMetricsFamily(used []metricDeprecation) *dto.MetricFamily {
+	mf := &dto.MetricFamily{
+		Name: proto.String(deprecatedMetricUsed),
+		Help: proto.String("rlmlm_exporter: Deprecated metric names exposed, with the metric replacing them and the last naming level they belong to. Dashboards and rules using them stop working once --metrics.compat-level is raised past that level."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	for _, dep := range used {
+		mf.Metric = append(mf.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{
+				{Name: proto.String("level"), Value: proto.String(fmt.Sprint(dep.level))},
+				{Name: proto.String("metric"), Value: proto.String(dep.name)},
+				{Name: proto.String("replacement"), Value: proto.String(dep.replacement)},
+			},
+			Gauge: &dto.Gauge{Value: proto.Float64(1)},
+		})
+	}
+	return mf
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestDeprecationGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	isv := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_isv_status", Help: "ISV."},
+		[]string{"license_name", "isv", "version"})
+	isv.WithLabelValues("app1", "vendor1", "v14.2").Set(1)
+	lmstatUp := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_lmstat_up", Help: "Up."},
+		[]string{"license_name", "license_server"})
+	lmstatUp.WithLabelValues("app1", "5053@host1").Set(1)
+	registry.MustRegister(isv, lmstatUp)
+
+	families, err := deprecationGatherer{g: registry, level: oldestMetricsLevel}.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := familyLabels(families)
+	want := map[string]string{
+		"rlmlm_isv_status":    "isv=vendor1,license_name=app1,version=v14.2",
+		"rlmlm_vendor_status": "license_name=app1,vendor=vendor1,version=v14.2",
+		"rlmlm_lmstat_up":     "license_name=app1,license_server=5053@host1",
+	}
+	for name, labels := range want {
+		if got[name] != labels {
+			t.Fatalf("Expected %s{%s}, got %v", name, labels, got)
+		}
+	}
+	used := make(map[string]string)
+	for _, mf := range families {
+		if mf.GetName() != deprecatedMetricUsed {
+			continue
+		}
+		for _, m := range mf.Metric {
+			used[labelValue(m, "metric")] = labelValue(m, "replacement")
+		}
+	}
+	if len(used) != 2 || used["rlmlm_vendor_status"] != "rlmlm_isv_status" || used["rlmlm_lmstat_up"] != "rlmlm_target_up" {
+		t.Fatalf("Unexpected deprecated metrics used %v", used)
+	}
+
+	// Tenant views keep the deprecated names of their licenses only.
+	families, err = deprecationGatherer{g: registry, level: oldestMetricsLevel, scoped: true}.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = familyLabels(families)
+	if _, ok := got[deprecatedMetricUsed]; ok || got["rlmlm_vendor_status"] == "" {
+		t.Fatalf("Expected no %s in a tenant view, got %v", deprecatedMetricUsed, got)
+	}
+
+	families, err = deprecationGatherer{g: registry, level: metricsLevel}.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := familyLabels(families); len(got) != 1 || got["rlmlm_isv_status"] == "" {
+		t.Fatalf("Expected only the current names at the current level, got %v", got)
+	}
+}
+
+func TestCheckMetricsCompatLevel(t *testing.T) {
+	for level, valid := range map[int]bool{0: false, oldestMetricsLevel: true, metricsLevel: true, metricsLevel + 1: false} {
+		if err := checkMetricsCompatLevel(level); (err == nil) != valid {
+			t.Fatalf("Unexpected result for level %d: %v", level, err)
+		}
+	}
+}
+
+// familyLabels returns the labels of the first metric of every family.
+func familyLabels(families []*dto.MetricFamily) map[string]string {
+	got := make(map[string]string)
+	for _, mf := range families {
+		var labels []string
+		for _, lp := range mf.Metric[0].Label {
+			labels = append(labels, lp.GetName()+"="+lp.GetValue())
+		}
+		got[mf.GetName()] = strings.Join(labels, ",")
+	}
+	return got
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stateHeartbeatInterval is how often the state file is refreshed while the
+// exporter runs, which bounds the downtime overestimated after a crash.
+const stateHeartbeatInterval = time.Minute
+
+var (
+	exporterStartTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rlmlm_exporter_start_time_seconds",
+		Help: "rlmlm_exporter: Time the exporter started, in seconds since the epoch.",
+	})
+	exporterDowntime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rlmlm_exporter_downtime_seconds",
+		Help: "rlmlm_exporter: Time between the last time the previous run was up, as recorded in --path.state-file, and the start of this one.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(exporterStartTime)
+}
+
+// trackDowntime exports the start time and the downtime since the previous
+// run recorded in path, then records that the exporter is up in path until
+// the returned function is called while stopping. Exporters started for an
+// upgrade took over without downtime.
+func trackDowntime(path string, start time.Time, upgraded bool) (stop func()) {
+	exporterStartTime.Set(float64(start.UnixNano()) / 1e9)
+	if path == "" {
+		return func() {}
+	}
+
+	if downtime, ok := previousDowntime(path, start, upgraded); ok {
+		exporterDowntime.Set(downtime.Seconds())
+		prometheus.MustRegister(exporterDowntime)
+		level.Info(baseLogger).Log("msg", "exporter was down before starting", "downtime", downtime)
+	}
+	record := func() {
+		if err := writeLastUp(path, time.Now()); err != nil {
+			level.Warn(baseLogger).Log("msg", "failed to write state file", "path", path, "err", err)
+		}
+	}
+	record()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(stateHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				record()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		record()
+	}
+}
+
+// previousDowntime returns how long before start the previous run was last
+// up according to path. The boolean is false if it isn't known.
+func previousDowntime(path string, start time.Time, upgraded bool) (time.Duration, bool) {
+	if upgraded {
+		return 0, true
+	}
+	lastUp, err := readLastUp(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Warn(baseLogger).Log("msg", "failed to read state file", "path", path, "err", err)
+		}
+		return 0, false
+	}
+	if lastUp.After(start) {
+		// Clock changes, or another exporter sharing the file.
+		return 0, true
+	}
+	return start.Sub(lastUp), true
+}
+
+// readLastUp returns the time recorded in the state file at path.
+func readLastUp(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return time.Unix(0, int64(seconds*1e9)), nil
+}
+
+// writeLastUp records t as seconds since the epoch in the state file at
+// path. The file is replaced atomically so that a crash doesn't leave it
+// truncated.
+func writeLastUp(path string, t time.Time) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	value := strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
+	if _, err := tmp.WriteString(value + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
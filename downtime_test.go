@@ -0,0 +1,41 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPreviousDowntime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	start := time.Unix(1700000600, 0)
+
+	if _, ok := previousDowntime(path, start, false); ok {
+		t.Fatal("Expected no downtime without a state file")
+	}
+	if err := writeLastUp(path, time.Unix(1700000000, 500000000)); err != nil {
+		t.Fatal(err)
+	}
+	if downtime, ok := previousDowntime(path, start, false); !ok || downtime != 599500*time.Millisecond {
+		t.Fatalf("Expected 599.5s of downtime, got %s", downtime)
+	}
+	if downtime, ok := previousDowntime(path, start, true); !ok || downtime != 0 {
+		t.Fatalf("Expected no downtime after an upgrade, got %s", downtime)
+	}
+	if downtime, ok := previousDowntime(path, time.Unix(1699999000, 0), false); !ok || downtime != 0 {
+		t.Fatalf("Expected no downtime for a state file from the future, got %s", downtime)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+// Exit codes, so that wrappers and service managers can tell failures apart.
+const (
+	// exitFailure is returned when the server stops on an error.
+	exitFailure = 1
+	// exitConfig is returned for invalid configuration, product mapping or
+	// compatibility mapping files.
+	exitConfig = 2
+	// exitListen is returned when the listen address can't be bound.
+	exitListen = 3
+	// exitSetup is returned when the collectors, tracing, the CA
+	// certificates, the feature seen file or the admin authentication can't
+	// be set up.
+	exitSetup = 4
+	// exitLint is returned by the lint command when it found issues.
+	exitLint = 5
+)
+
+// exitReasons name the exit codes in the summary logged before exiting.
+var exitReasons = map[int]string{
+	exitFailure: "server_error",
+	exitConfig:  "config_error",
+	exitListen:  "listen_error",
+	exitSetup:   "setup_error",
+	exitLint:    "lint_issues",
+}
+
+var (
+	// startTime is when the exporter started, for the uptime of the summary.
+	startTime = time.Now()
+	// osExit is replaced in tests.
+	osExit = os.Exit
+)
+
+// fatal logs msg with keyvals as an error, then a summary line with the exit
+// code, its reason and the error, and exits with code.
+func fatal(code int, msg string, keyvals ...interface{}) {
+	level.Error(baseLogger).Log(append([]interface{}{"msg", msg}, keyvals...)...)
+
+	summary := []interface{}{"msg", "exiting", "exit_code", code, "exit_reason", exitReasons[code], "cause", msg}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == "err" {
+			summary = append(summary, "err", keyvals[i+1])
+		}
+	}
+	summary = append(summary, "uptime", time.Since(startTime).Round(time.Millisecond))
+	level.Error(baseLogger).Log(summary...)
+	osExit(code)
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	gokitlog "github.com/go-kit/log"
+)
+
+func TestFatal(t *testing.T) {
+	var buf bytes.Buffer
+	prevLogger, prevExit := baseLogger, osExit
+	defer func() { baseLogger, osExit = prevLogger, prevExit }()
+	baseLogger = gokitlog.NewLogfmtLogger(&buf)
+	code := -1
+	osExit = func(c int) { code = c }
+
+	fatal(exitListen, "failed to listen", "address", ":9319", "err", errors.New("address already in use"))
+	if code != exitListen {
+		t.Fatalf("Expected exit code %d, got %d", exitListen, code)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the error and the summary, got:\n%s", buf.String())
+	}
+	for _, want := range []string{`msg=exiting`, `exit_code=3`, `exit_reason=listen_error`, `cause="failed to listen"`, `err="address already in use"`, `uptime=`} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("Expected %s in the summary, got %s", want, lines[1])
+		}
+	}
+
+}
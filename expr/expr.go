@@ -0,0 +1,261 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expr evaluates the expressions of derived metrics. They use the
+// arithmetic subset of the CEL syntax, which is also valid Go: number
+// literals, parentheses, the arithmetic, comparison and logical operators,
+// true and false, and calls of min, max, abs and the functions provided by
+// the caller, which may take string literals. Comparisons and logical
+// operators yield 1 for true and 0 for false.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+// Function is a function expressions may call. String literals are passed as
+// string, all other arguments as float64.
+type Function func(args []any) (float64, error)
+
+// builtins are available to every expression.
+var builtins = map[string]Function{
+	"min": func(args []any) (float64, error) { return fold(args, math.Min) },
+	"max": func(args []any) (float64, error) { return fold(args, math.Max) },
+	"abs": func(args []any) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("abs takes a single argument")
+		}
+		x, ok := args[0].(float64)
+		if !ok {
+			return 0, errors.New("abs takes a number")
+		}
+		return math.Abs(x), nil
+	},
+}
+
+// fold applies f to numeric args from the left.
+func fold(args []any, f func(x, y float64) float64) (float64, error) {
+	if len(args) == 0 {
+		return 0, errors.New("at least one argument is required")
+	}
+	var result float64
+	for i, arg := range args {
+		x, ok := arg.(float64)
+		if !ok {
+			return 0, fmt.Errorf("argument %d isn't a number", i+1)
+		}
+		if i == 0 {
+			result = x
+		} else {
+			result = f(result, x)
+		}
+	}
+	return result, nil
+}
+
+// Program is a compiled expression.
+type Program struct {
+	root ast.Expr
+}
+
+// Compile parses src and checks that it only calls the builtins and the
+// named functions.
+func Compile(src string, functions ...string) (*Program, error) {
+	root, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	known := make(map[string]bool, len(functions))
+	for _, name := range functions {
+		known[name] = true
+	}
+	if err := check(root, known, false); err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	return &Program{root: root}, nil
+}
+
+// check walks e, rejecting everything but the supported syntax. Strings are
+// only allowed as the arguments of calls.
+func check(e ast.Expr, functions map[string]bool, argument bool) error {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING && !argument {
+			return fmt.Errorf("string %s outside of a function call", e.Value)
+		}
+		if e.Kind != token.INT && e.Kind != token.FLOAT && e.Kind != token.STRING {
+			return fmt.Errorf("unsupported literal %s", e.Value)
+		}
+	case *ast.Ident:
+		if e.Name != "true" && e.Name != "false" {
+			return fmt.Errorf("unknown identifier %s", e.Name)
+		}
+	case *ast.ParenExpr:
+		return check(e.X, functions, false)
+	case *ast.UnaryExpr:
+		switch e.Op {
+		case token.ADD, token.SUB, token.NOT:
+		default:
+			return fmt.Errorf("unsupported operator %s", e.Op)
+		}
+		return check(e.X, functions, false)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM,
+			token.LSS, token.LEQ, token.GTR, token.GEQ, token.EQL, token.NEQ,
+			token.LAND, token.LOR:
+		default:
+			return fmt.Errorf("unsupported operator %s", e.Op)
+		}
+		if err := check(e.X, functions, false); err != nil {
+			return err
+		}
+		return check(e.Y, functions, false)
+	case *ast.CallExpr:
+		name, ok := e.Fun.(*ast.Ident)
+		if !ok || e.Ellipsis.IsValid() {
+			return errors.New("only functions can be called")
+		}
+		if _, ok := builtins[name.Name]; !ok && !functions[name.Name] {
+			return fmt.Errorf("unknown function %s", name.Name)
+		}
+		for _, arg := range e.Args {
+			if err := check(arg, functions, true); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported syntax at %d", e.Pos())
+	}
+	return nil
+}
+
+// Eval evaluates the program with the given functions, which must include
+// those named when compiling it.
+func (p *Program) Eval(functions map[string]Function) (float64, error) {
+	return eval(p.root, functions)
+}
+
+func eval(e ast.Expr, functions map[string]Function) (float64, error) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		// Integers are floats too, like numbers in PromQL.
+		return strconv.ParseFloat(e.Value, 64)
+	case *ast.Ident:
+		return boolToFloat64(e.Name == "true"), nil
+	case *ast.ParenExpr:
+		return eval(e.X, functions)
+	case *ast.UnaryExpr:
+		x, err := eval(e.X, functions)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Op {
+		case token.SUB:
+			return -x, nil
+		case token.NOT:
+			return boolToFloat64(x == 0), nil
+		}
+		return x, nil
+	case *ast.BinaryExpr:
+		return evalBinary(e, functions)
+	case *ast.CallExpr:
+		name := e.Fun.(*ast.Ident).Name
+		f, ok := builtins[name]
+		if !ok {
+			if f, ok = functions[name]; !ok {
+				return 0, fmt.Errorf("unknown function %s", name)
+			}
+		}
+		args := make([]any, len(e.Args))
+		for i, arg := range e.Args {
+			if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				s, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return 0, err
+				}
+				args[i] = s
+				continue
+			}
+			x, err := eval(arg, functions)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = x
+		}
+		x, err := f(args)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		return x, nil
+	}
+	return 0, fmt.Errorf("unsupported syntax at %d", e.Pos())
+}
+
+// evalBinary evaluates e. The logical operators short-circuit.
+func evalBinary(e *ast.BinaryExpr, functions map[string]Function) (float64, error) {
+	x, err := eval(e.X, functions)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case e.Op == token.LAND && x == 0:
+		return 0, nil
+	case e.Op == token.LOR && x != 0:
+		return 1, nil
+	}
+	y, err := eval(e.Y, functions)
+	if err != nil {
+		return 0, err
+	}
+	switch e.Op {
+	case token.ADD:
+		return x + y, nil
+	case token.SUB:
+		return x - y, nil
+	case token.MUL:
+		return x * y, nil
+	case token.QUO:
+		return x / y, nil
+	case token.REM:
+		return math.Mod(x, y), nil
+	case token.LSS:
+		return boolToFloat64(x < y), nil
+	case token.LEQ:
+		return boolToFloat64(x <= y), nil
+	case token.GTR:
+		return boolToFloat64(x > y), nil
+	case token.GEQ:
+		return boolToFloat64(x >= y), nil
+	case token.EQL:
+		return boolToFloat64(x == y), nil
+	case token.NEQ:
+		return boolToFloat64(x != y), nil
+	case token.LAND, token.LOR:
+		return boolToFloat64(y != 0), nil
+	}
+	return 0, fmt.Errorf("unsupported operator %s", e.Op)
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
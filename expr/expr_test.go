@@ -0,0 +1,80 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	used := map[string]float64{"solver": 3, "solver_hpc": 8}
+	functions := map[string]Function{
+		"used": func(args []any) (float64, error) {
+			name, ok := args[0].(string)
+			if !ok {
+				return 0, errors.New("used takes a feature name")
+			}
+			return used[name], nil
+		},
+	}
+
+	for src, expected := range map[string]float64{
+		`used("solver") + used("solver_hpc") / 4`:    5,
+		`-(1 + 2) * 3 % 4`:                           -1,
+		`max(used("solver"), 5, 1.5)`:                5,
+		`min(used("solver"), abs(-2))`:               2,
+		`used("solver") > 2 && used("missing") == 0`: 1,
+		`!true || 1 >= 2`:                            0,
+		// The right side isn't evaluated.
+		`false && used(1)`: 0,
+	} {
+		p, err := Compile(src, "used")
+		if err != nil {
+			t.Fatalf("Unexpected error compiling %s: %v", src, err)
+		}
+		value, err := p.Eval(functions)
+		if err != nil {
+			t.Fatalf("Unexpected error evaluating %s: %v", src, err)
+		}
+		if value != expected {
+			t.Fatalf("Expected %s to be %v, got %v", src, expected, value)
+		}
+	}
+
+	p, err := Compile(`used(1)`, "used")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := p.Eval(functions); err == nil || err.Error() != "used: used takes a feature name" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		`used("solver") +`,
+		`issued("solver")`,
+		`"solver"`,
+		`x + 1`,
+		`1 << 2`,
+		`os.Exit(1)`,
+		`used("a")[0]`,
+		`func() int { return 1 }()`,
+	} {
+		if _, err := Compile(src, "used"); err == nil {
+			t.Fatalf("Expected an error compiling %s", src)
+		}
+	}
+}
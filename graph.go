@@ -0,0 +1,166 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/iambengiey/rlmlm_exporter/collector"
+)
+
+const (
+	graphWidth  = 720
+	graphHeight = 200
+)
+
+var graphTemplate = template.Must(template.New("graph").Parse(`<html>
+<head><title>RLMlm Exporter - {{if .Feature}}{{.Feature}}{{else}}Usage{{end}}</title></head>
+<body>
+{{- if .Feature}}
+<h1>{{.Feature}}</h1>
+{{- range .Charts}}
+<h2>{{.License}}</h2>
+<p>{{.From}} to {{.To}} UTC, {{.Used}} of {{.Issued}} in use, highest {{.Peak}}.</p>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" style="border:1px solid #ccc">
+<polyline points="{{.IssuedPoints}}" fill="none" stroke="#999" stroke-dasharray="4"/>
+<polyline points="{{.UsedPoints}}" fill="none" stroke="#1f77b4" stroke-width="2"/>
+</svg>
+{{- end}}
+<p><a href="graph">All features</a></p>
+{{- else}}
+<h1>Usage</h1>
+<ul>
+{{- range .Features}}
+<li><a href="graph?feature={{.}}">{{.}}</a></li>
+{{- else}}
+<li>No usage collected yet.</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// graphPage is the data of graphTemplate.
+type graphPage struct {
+	Feature  string
+	Features []string
+	Charts   []graphChart
+}
+
+// graphChart is the usage of the feature on a license, with the issued
+// licenses dashed above it.
+type graphChart struct {
+	License                  string
+	From, To                 string
+	Used, Issued, Peak       float64
+	Width, Height            int
+	UsedPoints, IssuedPoints string
+}
+
+// graphHandler serves the usage history of the feature query parameter as a
+// chart per license, or the features with a history without it.
+func graphHandler(w http.ResponseWriter, r *http.Request) {
+	stateMu.RLock()
+	cfg := appConfig
+	stateMu.RUnlock()
+	tenant, ok := requestTenant(w, r, cfg)
+	if !ok {
+		return
+	}
+	var scope map[string]bool
+	if tenant != "" {
+		scope = tenantLicenses(cfg, tenant)
+	}
+
+	page := graphPage{Feature: r.URL.Query().Get("feature")}
+	if page.Feature == "" {
+		for _, feature := range collector.UsageHistoryFeatures() {
+			if len(scopeHistory(collector.UsageHistory(feature), scope)) > 0 {
+				page.Features = append(page.Features, feature)
+			}
+		}
+	} else {
+		history := scopeHistory(collector.UsageHistory(page.Feature), scope)
+		if len(history) == 0 {
+			http.Error(w, fmt.Sprintf("No usage of feature %q collected", page.Feature), http.StatusNotFound)
+			return
+		}
+		licenses := make([]string, 0, len(history))
+		for license := range history {
+			licenses = append(licenses, license)
+		}
+		sort.Strings(licenses)
+		for _, license := range licenses {
+			page.Charts = append(page.Charts, newGraphChart(license, history[license]))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := graphTemplate.Execute(w, page); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write graph page", "feature", page.Feature, "err", err)
+	}
+}
+
+// scopeHistory returns the history of the licenses in scope, every license if
+// scope is nil.
+func scopeHistory(history map[string][]collector.UsageSample, scope map[string]bool) map[string][]collector.UsageSample {
+	if scope == nil {
+		return history
+	}
+	for license := range history {
+		if !scope[license] {
+			delete(history, license)
+		}
+	}
+	return history
+}
+
+// newGraphChart scales samples, oldest first, to the chart. The vertical axis
+// goes from zero to the highest issued or used value.
+func newGraphChart(license string, samples []collector.UsageSample) graphChart {
+	first, last := samples[0], samples[len(samples)-1]
+	chart := graphChart{
+		License: license,
+		From:    first.Time.UTC().Format("2006-01-02 15:04"),
+		To:      last.Time.UTC().Format("2006-01-02 15:04"),
+		Used:    last.Used,
+		Issued:  last.Issued,
+		Width:   graphWidth,
+		Height:  graphHeight,
+	}
+	top := 1.0
+	for _, s := range samples {
+		top = max(top, s.Used, s.Issued)
+		chart.Peak = max(chart.Peak, s.Used)
+	}
+	span := last.Time.Sub(first.Time).Seconds()
+	var used, issued strings.Builder
+	for _, s := range samples {
+		x := 0.0
+		if span > 0 {
+			x = s.Time.Sub(first.Time).Seconds() / span * graphWidth
+		}
+		fmt.Fprintf(&used, "%.1f,%.1f ", x, graphHeight-s.Used/top*graphHeight)
+		fmt.Fprintf(&issued, "%.1f,%.1f ", x, graphHeight-s.Issued/top*graphHeight)
+	}
+	chart.UsedPoints = strings.TrimSpace(used.String())
+	chart.IssuedPoints = strings.TrimSpace(issued.String())
+	return chart
+}
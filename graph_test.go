@@ -0,0 +1,55 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+)
+
+func TestGraphHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	graphHandler(w, httptest.NewRequest("GET", "/graph?feature=missing", nil))
+	if w.Code != 404 {
+		t.Fatalf("Expected 404 for a feature without usage, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	graphHandler(w, httptest.NewRequest("GET", "/graph", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<h1>Usage</h1>") {
+		t.Fatalf("Unexpected feature list %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestNewGraphChart(t *testing.T) {
+	start := time.Date(2025, 3, 29, 10, 0, 0, 0, time.UTC)
+	chart := newGraphChart("app1", []collector.UsageSample{
+		{Time: start, Issued: 10, Used: 0},
+		{Time: start.Add(time.Hour), Issued: 10, Used: 5},
+		{Time: start.Add(2 * time.Hour), Issued: 10, Used: 2},
+	})
+	if chart.UsedPoints != "0.0,200.0 360.0,100.0 720.0,160.0" {
+		t.Fatalf("Unexpected used points %q", chart.UsedPoints)
+	}
+	if chart.IssuedPoints != "0.0,0.0 360.0,0.0 720.0,0.0" {
+		t.Fatalf("Unexpected issued points %q", chart.IssuedPoints)
+	}
+	if chart.Used != 2 || chart.Peak != 5 || chart.From != "2025-03-29 10:00" {
+		t.Fatalf("Unexpected chart %+v", chart)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// etherRegex matches a bare ethernet hostid like 0a1b2c3d4e5f.
+var etherRegex = regexp.MustCompile(`^[0-9a-fA-F]{12}$`)
+
+// Host holds the identities of a host that hostids are checked against.
+type Host struct {
+	Hostname string
+	IPs      []string
+	// MACs are lower case without separators, like 0a1b2c3d4e5f.
+	MACs []string
+}
+
+// LocalHost returns the identities of the host the exporter runs on.
+func LocalHost() (*Host, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	host := &Host{Hostname: hostname}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range ifaces {
+		if mac := normalizeMAC(iface.HardwareAddr.String()); mac != "" {
+			host.MACs = append(host.MACs, mac)
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				host.IPs = append(host.IPs, ipnet.IP.String())
+			}
+		}
+	}
+	return host, nil
+}
+
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(mac))
+}
+
+// Matches reports whether hostid identifies h. Hostid types that can't be
+// verified from here, like disk serial numbers or dongles, always match.
+func (h *Host) Matches(hostid string) bool {
+	hostid = strings.Trim(hostid, `"`)
+	key, value, ok := strings.Cut(hostid, "=")
+	if !ok {
+		if etherRegex.MatchString(hostid) {
+			return h.hasMAC(hostid)
+		}
+		return true
+	}
+
+	switch strings.ToLower(key) {
+	case "host", "hostname":
+		return strings.EqualFold(value, h.Hostname) ||
+			strings.EqualFold(value, strings.SplitN(h.Hostname, ".", 2)[0])
+	case "ip", "internet":
+		for _, ip := range h.IPs {
+			if ipMatches(value, ip) {
+				return true
+			}
+		}
+		return false
+	case "ether":
+		return h.hasMAC(value)
+	}
+	return true
+}
+
+func (h *Host) hasMAC(mac string) bool {
+	mac = normalizeMAC(mac)
+	for _, m := range h.MACs {
+		if m == mac {
+			return true
+		}
+	}
+	return false
+}
+
+// ipMatches matches an IPv4 address against a pattern with * wildcards, like
+// 192.168.1.*.
+func ipMatches(pattern, ip string) bool {
+	patternParts := strings.Split(pattern, ".")
+	ipParts := strings.Split(ip, ".")
+	if len(patternParts) != len(ipParts) {
+		return pattern == ip
+	}
+	for i := range patternParts {
+		if patternParts[i] != "*" && patternParts[i] != ipParts[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint checks RLM and FlexLM license files and the ISV option files
+// they reference for common mistakes.
+package lint
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Checks reported in Issue.Check.
+const (
+	CheckUnreadable      = "unreadable"
+	CheckSyntax          = "syntax"
+	CheckExpired         = "expired"
+	CheckHostIDMismatch  = "hostid_mismatch"
+	CheckIncludeExclude  = "include_exclude_overlap"
+	CheckUnknownKeyword  = "unknown_keyword"
+	noLine               = 0
+	optionsKeywordPrefix = "options="
+)
+
+// Issue is a single finding.
+type Issue struct {
+	File    string
+	Line    int
+	Check   string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Line == noLine {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Check, i.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Check, i.Message)
+}
+
+// Linter checks files against the local host at a given time.
+type Linter struct {
+	// Now is the reference time for expired lines.
+	Now time.Time
+	// Host identifies the local host for hostid checks, nil skips them.
+	Host *Host
+}
+
+// line is a logical line of a license or options file, with continuation
+// lines joined.
+type line struct {
+	number int
+	fields []string
+}
+
+// readLines returns the logical lines of path, skipping comments and blank
+// lines. A trailing backslash continues a line.
+func readLines(path string) ([]line, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		lines   []line
+		current *line
+		number  int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if current == nil && (text == "" || strings.HasPrefix(text, "#")) {
+			continue
+		}
+		continued := strings.HasSuffix(text, "\\")
+		text = strings.TrimSuffix(text, "\\")
+		if current == nil {
+			current = &line{number: number}
+		}
+		current.fields = append(current.fields, splitFields(text)...)
+		if !continued {
+			lines = append(lines, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		lines = append(lines, *current)
+	}
+	return lines, scanner.Err()
+}
+
+// splitFields splits on whitespace, keeping double quoted strings together.
+func splitFields(text string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t'):
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// LicenseFile checks the license file at path and every options file its
+// ISV or VENDOR lines reference.
+func (l Linter) LicenseFile(path string) []Issue {
+	lines, err := readLines(path)
+	if err != nil {
+		return []Issue{{File: path, Check: CheckUnreadable, Message: err.Error()}}
+	}
+
+	var (
+		issues  []Issue
+		options []string
+	)
+	add := func(ln line, check, format string, args ...interface{}) {
+		issues = append(issues, Issue{File: path, Line: ln.number, Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, ln := range lines {
+		keyword := strings.ToUpper(ln.fields[0])
+		args := ln.fields[1:]
+		switch keyword {
+		case "HOST", "SERVER":
+			// HOST hostname hostid [port]
+			if len(args) < 2 {
+				add(ln, CheckSyntax, "%s needs a hostname and a hostid", keyword)
+				continue
+			}
+			if l.Host != nil && !l.Host.Matches(args[1]) {
+				add(ln, CheckHostIDMismatch, "hostid %s of %s doesn't match the local host", args[1], args[0])
+			}
+		case "ISV", "VENDOR", "DAEMON":
+			// ISV name [binary [options [port]]] or key=value settings.
+			if len(args) < 1 {
+				add(ln, CheckSyntax, "%s needs a name", keyword)
+				continue
+			}
+			if opt := optionsPath(args); opt != "" {
+				if !filepath.IsAbs(opt) {
+					opt = filepath.Join(filepath.Dir(path), opt)
+				}
+				options = append(options, opt)
+			}
+		case "LICENSE", "FEATURE", "INCREMENT", "UPGRADE":
+			// LICENSE isv product version exp-date count ...
+			// FEATURE product vendor version exp-date count ...
+			if len(args) < 5 {
+				add(ln, CheckSyntax, "%s needs product, vendor, version, expiration date and count", keyword)
+				continue
+			}
+			product := args[0]
+			if keyword == "LICENSE" {
+				product = args[1]
+			}
+			expires, ok := parseDate(args[3])
+			if !ok {
+				add(ln, CheckSyntax, "invalid expiration date %q for %s", args[3], product)
+				continue
+			}
+			if !expires.IsZero() && !l.Now.Before(expires.AddDate(0, 0, 1)) {
+				add(ln, CheckExpired, "%s expired on %s", product, expires.Format("2006-01-02"))
+			}
+		case "USE_SERVER", "PACKAGE", "UPGRADE_FILE", "CUSTOMER", "_PRIMARY_SERVER":
+		default:
+			add(ln, CheckUnknownKeyword, "unknown keyword %s", ln.fields[0])
+		}
+	}
+
+	for _, opt := range options {
+		issues = append(issues, l.OptionsFile(opt)...)
+	}
+	sortIssues(issues)
+	return issues
+}
+
+// optionsPath returns the options file of an ISV line, empty if none is set.
+func optionsPath(args []string) string {
+	for _, arg := range args[1:] {
+		if len(arg) > len(optionsKeywordPrefix) && strings.EqualFold(arg[:len(optionsKeywordPrefix)], optionsKeywordPrefix) {
+			return strings.Trim(arg[len(optionsKeywordPrefix):], `"`)
+		}
+	}
+	// Positional form: name binary options [port].
+	if len(args) >= 3 && !strings.Contains(args[2], "=") {
+		return strings.Trim(args[2], `"`)
+	}
+	return ""
+}
+
+// parseDate parses a license expiration date. The zero time means the line
+// never expires.
+func parseDate(raw string) (time.Time, bool) {
+	switch strings.ToLower(raw) {
+	case "permanent", "none", "0", "unlimited":
+		return time.Time{}, true
+	}
+	parts := strings.Split(raw, "-")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	if parts[2] == "0" || parts[2] == "0000" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse("2-Jan-2006", fmt.Sprintf("%s-%s-%s", parts[0],
+		strings.Title(strings.ToLower(parts[1])), parts[2]))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// optionsKeywords lists the options file keywords taking a feature, a type
+// and a name.
+var optionsKeywords = map[string]bool{
+	"INCLUDE": true, "EXCLUDE": true, "INCLUDE_BORROW": true, "EXCLUDE_BORROW": true,
+	"INCLUDE_ENFORCE": true, "RESERVE": true, "MAX": true,
+}
+
+// otherOptionsKeywords are accepted without further checks.
+var otherOptionsKeywords = map[string]bool{
+	"INCLUDEALL": true, "EXCLUDEALL": true, "GROUP": true, "HOST_GROUP": true,
+	"DEBUGLOG": true, "REPORTLOG": true, "TIMEOUT": true, "TIMEOUTALL": true,
+	"NOLOG": true, "GROUPCASEINSENSITIVE": true, "MAX_OVERDRAFT": true,
+	"LINGER": true, "BORROW_LOWWATER": true, "MAX_BORROW_HOURS": true,
+	"ACCESS": true, "ROAM_MAX_DAYS": true, "MINREMOVE": true, "PRIORITY": true,
+	"KEEPALIVE": true,
+}
+
+// OptionsFile checks the ISV options file at path.
+func (l Linter) OptionsFile(path string) []Issue {
+	lines, err := readLines(path)
+	if err != nil {
+		return []Issue{{File: path, Check: CheckUnreadable, Message: err.Error()}}
+	}
+
+	var issues []Issue
+	// Rules by "feature type name" and keyword, pointing to their line.
+	rules := map[string]map[string]int{}
+	for _, ln := range lines {
+		keyword := strings.ToUpper(ln.fields[0])
+		args := ln.fields[1:]
+		switch {
+		case optionsKeywords[keyword]:
+			// RESERVE and MAX take a count first.
+			if keyword == "RESERVE" || keyword == "MAX" {
+				if len(args) > 0 {
+					args = args[1:]
+				}
+			}
+			if len(args) < 3 {
+				issues = append(issues, Issue{File: path, Line: ln.number, Check: CheckSyntax,
+					Message: fmt.Sprintf("%s needs a feature, a type and a name", keyword)})
+				continue
+			}
+			if keyword != "INCLUDE" && keyword != "EXCLUDE" {
+				continue
+			}
+			key := strings.Join([]string{args[0], strings.ToUpper(args[1]), args[2]}, " ")
+			if rules[key] == nil {
+				rules[key] = map[string]int{}
+			}
+			if _, ok := rules[key][keyword]; !ok {
+				rules[key][keyword] = ln.number
+			}
+		case otherOptionsKeywords[keyword]:
+		default:
+			issues = append(issues, Issue{File: path, Line: ln.number, Check: CheckUnknownKeyword,
+				Message: fmt.Sprintf("unknown keyword %s", ln.fields[0])})
+		}
+	}
+
+	for key, keywords := range rules {
+		include, hasInclude := keywords["INCLUDE"]
+		exclude, hasExclude := keywords["EXCLUDE"]
+		if hasInclude && hasExclude {
+			issues = append(issues, Issue{File: path, Line: exclude, Check: CheckIncludeExclude,
+				Message: fmt.Sprintf("%s is both included (line %d) and excluded", key, include)})
+		}
+	}
+	sortIssues(issues)
+	return issues
+}
+
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+}
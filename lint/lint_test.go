@@ -0,0 +1,77 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testLicenseFile = "fixtures/license.lic"
+
+func TestLicenseFile(t *testing.T) {
+	linter := Linter{
+		Now:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Host: &Host{Hostname: "otherhost", MACs: []string{"ffffffffffff"}},
+	}
+
+	found := make(map[string]int)
+	for _, issue := range linter.LicenseFile(testLicenseFile) {
+		found[filepath.Base(issue.File)+" "+issue.Check]++
+	}
+
+	expected := map[string]int{
+		"license.lic " + CheckHostIDMismatch: 1,
+		"license.lic " + CheckExpired:        1,
+		"license.lic " + CheckSyntax:         1,
+		"license.lic " + CheckUnknownKeyword: 1,
+		"vendor1.opt " + CheckIncludeExclude: 1,
+		"vendor1.opt " + CheckSyntax:         1,
+	}
+	for key, count := range expected {
+		if found[key] != count {
+			t.Fatalf("Expected %d %s issues, found %v", count, key, found)
+		}
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Unexpected issues %v", found)
+	}
+}
+
+func TestLicenseFileUnreadable(t *testing.T) {
+	issues := Linter{}.LicenseFile("fixtures/missing.lic")
+	if len(issues) != 1 || issues[0].Check != CheckUnreadable {
+		t.Fatalf("Unexpected issues %v", issues)
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	host := &Host{Hostname: "rlmhost.domain.net", IPs: []string{"192.168.1.10"}, MACs: []string{"0a1b2c3d4e5f"}}
+	for hostid, expected := range map[string]bool{
+		"0A1B2C3D4E5F":       true,
+		"ffffffffffff":       false,
+		"ether=0a1b2c3d4e5f": true,
+		"host=rlmhost":       true,
+		"HOSTNAME=other":     false,
+		"ip=192.168.1.*":     true,
+		"INTERNET=10.0.0.1":  false,
+		"ANY":                true,
+		"disksn=ABC123":      true,
+	} {
+		if host.Matches(hostid) != expected {
+			t.Fatalf("Matches(%q) != %v", hostid, expected)
+		}
+	}
+}
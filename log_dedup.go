@@ -0,0 +1,128 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// dedupLogger drops warnings and errors identical to one logged less than
+// interval ago, so that a server staying down doesn't log the same lines on
+// every scrape. Once the interval is over, the number of dropped lines is
+// logged as repeated along with the line.
+type dedupLogger struct {
+	next     gokitlog.Logger
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	lines map[string]*dedupLine
+}
+
+type dedupLine struct {
+	logged   time.Time
+	keyvals  []interface{}
+	repeated int
+}
+
+func newDedupLogger(next gokitlog.Logger, interval time.Duration) gokitlog.Logger {
+	if interval <= 0 {
+		return next
+	}
+	return &dedupLogger{next: next, interval: interval, now: time.Now, lines: make(map[string]*dedupLine)}
+}
+
+func (l *dedupLogger) Log(keyvals ...interface{}) error {
+	now := l.now()
+
+	l.mu.Lock()
+	summaries := l.expireLocked(now)
+	var drop bool
+	if dedupLevel(keyvals) {
+		key := dedupKey(keyvals)
+		if line, ok := l.lines[key]; ok {
+			line.repeated++
+			drop = true
+		} else {
+			l.lines[key] = &dedupLine{logged: now, keyvals: keyvals}
+		}
+	}
+	l.mu.Unlock()
+
+	for _, summary := range summaries {
+		_ = l.next.Log(summary...)
+	}
+	if drop {
+		return nil
+	}
+	return l.next.Log(keyvals...)
+}
+
+// expireLocked forgets the lines logged at least interval ago and returns a
+// summary of those repeated since.
+func (l *dedupLogger) expireLocked(now time.Time) [][]interface{} {
+	var summaries [][]interface{}
+	for key, line := range l.lines {
+		if now.Sub(line.logged) < l.interval {
+			continue
+		}
+		delete(l.lines, key)
+		if line.repeated == 0 {
+			continue
+		}
+		summary := make([]interface{}, 0, len(line.keyvals)+2)
+		for i := 0; i+1 < len(line.keyvals); i += 2 {
+			if line.keyvals[i] == "ts" {
+				summary = append(summary, "ts", gokitlog.DefaultTimestampUTC())
+				continue
+			}
+			summary = append(summary, line.keyvals[i], line.keyvals[i+1])
+		}
+		summaries = append(summaries, append(summary, "repeated", line.repeated))
+	}
+	// Keep the output deterministic when several lines expire at once.
+	sort.Slice(summaries, func(i, j int) bool {
+		return fmt.Sprint(summaries[i]...) < fmt.Sprint(summaries[j]...)
+	})
+	return summaries
+}
+
+// dedupLevel returns whether keyvals is a warning or an error.
+func dedupLevel(keyvals []interface{}) bool {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == level.Key() {
+			return keyvals[i+1] == level.WarnValue() || keyvals[i+1] == level.ErrorValue()
+		}
+	}
+	return false
+}
+
+// dedupKey identifies identical lines, whatever their timestamp.
+func dedupKey(keyvals []interface{}) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == "ts" {
+			continue
+		}
+		fmt.Fprintf(&b, "%v=%v\x00", keyvals[i], keyvals[i+1])
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+func TestDedupLogger(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	dedup := newDedupLogger(gokitlog.NewLogfmtLogger(&buf), 5*time.Minute).(*dedupLogger)
+	dedup.now = func() time.Time { return now }
+	logger := gokitlog.With(dedup, "ts", gokitlog.DefaultTimestampUTC)
+
+	for i := 0; i < 3; i++ {
+		level.Error(logger).Log("msg", "collection failed", "license", "app1")
+		level.Info(logger).Log("msg", "collected")
+		now = now.Add(time.Minute)
+	}
+	level.Error(logger).Log("msg", "collection failed", "license", "app2")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "license=app1") || strings.Contains(lines[0], "repeated") {
+		t.Fatalf("Unexpected first line: %s", lines[0])
+	}
+	if !strings.Contains(lines[4], "license=app2") {
+		t.Fatalf("Unexpected last line: %s", lines[4])
+	}
+
+	buf.Reset()
+	now = now.Add(5 * time.Minute)
+	level.Warn(logger).Log("msg", "slow collection")
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "license=app1") || !strings.HasSuffix(lines[0], "repeated=2") {
+		t.Fatalf("Unexpected summary: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "slow collection") {
+		t.Fatalf("Unexpected last line: %s", lines[1])
+	}
+
+	// The interval is over, the next failure is logged again.
+	buf.Reset()
+	level.Error(logger).Log("msg", "collection failed", "license", "app1")
+	if !strings.Contains(buf.String(), "license=app1") {
+		t.Fatalf("Expected the error to be logged again, got %q", buf.String())
+	}
+}
+
+func TestDedupLoggerDisabled(t *testing.T) {
+	next := gokitlog.NewNopLogger()
+	if logger := newDedupLogger(next, 0); logger != next {
+		t.Fatalf("Expected the logger to be returned as is with a zero interval")
+	}
+}
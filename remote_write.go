@@ -0,0 +1,279 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+)
+
+var (
+	remoteWriteSamples = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rlmlm_remote_write_samples_total",
+		Help: "rlmlm_exporter: Samples sent with remote write.",
+	})
+	remoteWriteFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rlmlm_remote_write_failures_total",
+		Help: "rlmlm_exporter: Remote write requests that failed.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(remoteWriteSamples, remoteWriteFailures)
+}
+
+// remoteWriter periodically sends the exporter's own metrics to a
+// Prometheus remote write endpoint, for hosts without a Prometheus server.
+type remoteWriter struct {
+	url             string
+	bearerTokenFile string
+	interval        time.Duration
+	// labels are added to every series, like the job and instance labels
+	// a Prometheus server would add.
+	labels map[string]string
+	client *http.Client
+	gather func() ([]*dto.MetricFamily, error)
+	now    func() time.Time
+}
+
+// run sends the metrics every interval until ctx is done. Failed requests
+// aren't retried, the next interval sends fresh samples.
+func (w *remoteWriter) run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.send(ctx); err != nil {
+			remoteWriteFailures.Inc()
+			level.Error(baseLogger).Log("msg", "remote write failed", "url", w.url, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// send gathers the metrics and writes them to the endpoint.
+func (w *remoteWriter) send(ctx context.Context) error {
+	families, err := w.gather()
+	if err != nil && len(families) == 0 {
+		return fmt.Errorf("couldn't gather metrics: %w", err)
+	}
+	series := toSeries(families, w.labels, w.now())
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.bearerTokenFile != "" {
+		// Read on every request so that rotated tokens are picked up.
+		token, err := os.ReadFile(w.bearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("couldn't read bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var samples int
+	for _, s := range series {
+		samples += len(s.samples)
+	}
+	remoteWriteSamples.Add(float64(samples))
+	return nil
+}
+
+// gatherMetrics gathers what /metrics serves without collect[] filters.
+func gatherMetrics() ([]*dto.MetricFamily, error) {
+	return gatherScoped(nil)
+}
+
+// gatherScoped gathers what /metrics serves without collect[] filters for the
+// licenses of a tenant, or every license if licenses is nil.
+func gatherScoped(licenses map[string]bool) ([]*dto.MetricFamily, error) {
+	stateMu.RLock()
+	c := cache
+	stateMu.RUnlock()
+
+	registry := prometheus.NewRegistry()
+	switch {
+	case c != nil:
+		if err := registry.Register(c); err != nil {
+			return nil, err
+		}
+	case licenses == nil || len(licenses) > 0:
+		names := make([]string, 0, len(licenses))
+		for name := range licenses {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		nc, err := collector.ScrapeCollector(nil, names)
+		if err != nil {
+			return nil, err
+		}
+		if err := registry.Register(nc); err != nil {
+			return nil, err
+		}
+	}
+	return withCompat(withDeprecations(withTenant(prometheus.Gatherers{prometheus.DefaultGatherer, registry}, licenses), licenses != nil)).Gather()
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+// timeSeries is a remote write time series, with labels sorted by name.
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// toSeries flattens families into series the way the text format does:
+// summaries and histograms become one series per quantile or bucket plus
+// _sum and _count.
+func toSeries(families []*dto.MetricFamily, extra map[string]string, now time.Time) []timeSeries {
+	var series []timeSeries
+	for _, family := range families {
+		name := family.GetName()
+		for _, m := range family.GetMetric() {
+			ts := now.UnixMilli()
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			add := func(suffix string, value float64, more ...label) {
+				labels := []label{{"__name__", name + suffix}}
+				for _, l := range m.GetLabel() {
+					labels = append(labels, label{l.GetName(), l.GetValue()})
+				}
+				labels = append(labels, more...)
+				for k, v := range extra {
+					if !hasLabel(labels, k) {
+						labels = append(labels, label{k, v})
+					}
+				}
+				sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+				series = append(series, timeSeries{labels: labels, samples: []sample{{value, ts}}})
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add("", m.GetGauge().GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.GetSummary().GetQuantile() {
+					add("", q.GetValue(), label{"quantile", formatFloat(q.GetQuantile())})
+				}
+				add("_sum", m.GetSummary().GetSampleSum())
+				add("_count", float64(m.GetSummary().GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				buckets := m.GetHistogram().GetBucket()
+				for _, b := range buckets {
+					add("_bucket", float64(b.GetCumulativeCount()), label{"le", formatFloat(b.GetUpperBound())})
+				}
+				if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].GetUpperBound(), 1) {
+					add("_bucket", float64(m.GetHistogram().GetSampleCount()), label{"le", "+Inf"})
+				}
+				add("_sum", m.GetHistogram().GetSampleSum())
+				add("_count", float64(m.GetHistogram().GetSampleCount()))
+			default:
+				add("", m.GetUntyped().GetValue())
+			}
+		}
+	}
+	return series
+}
+
+func hasLabel(labels []label, name string) bool {
+	for _, l := range labels {
+		if l.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest encodes series as a prometheus.WriteRequest protobuf
+// message.
+func encodeWriteRequest(series []timeSeries) []byte {
+	var buf []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		for _, smp := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.timestamp))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, ts)
+	}
+	return buf
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeWriteRequest decodes the series of a WriteRequest as label sets
+// joined with commas, mapped to their first sample value.
+func decodeWriteRequest(t *testing.T, buf []byte) map[string]float64 {
+	t.Helper()
+	fields := func(b []byte, f func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("Invalid tag")
+			}
+			b = b[n:]
+			n = f(num, typ, b)
+			if n < 0 {
+				t.Fatalf("Invalid field %d", num)
+			}
+			b = b[n:]
+		}
+	}
+
+	series := make(map[string]float64)
+	fields(buf, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+		ts, n := protowire.ConsumeBytes(b)
+		var (
+			labels []string
+			value  float64
+		)
+		fields(ts, func(num protowire.Number, _ protowire.Type, b []byte) int {
+			msg, n := protowire.ConsumeBytes(b)
+			var parts []string
+			fields(msg, func(num protowire.Number, typ protowire.Type, b []byte) int {
+				switch typ {
+				case protowire.BytesType:
+					s, n := protowire.ConsumeString(b)
+					parts = append(parts, s)
+					return n
+				case protowire.Fixed64Type:
+					v, n := protowire.ConsumeFixed64(b)
+					value = math.Float64frombits(v)
+					return n
+				default:
+					_, n := protowire.ConsumeVarint(b)
+					return n
+				}
+			})
+			if num == 1 {
+				labels = append(labels, strings.Join(parts, "="))
+			}
+			return n
+		})
+		series[strings.Join(labels, ",")] = value
+		return n
+	})
+	return series
+}
+
+func TestRemoteWriterSend(t *testing.T) {
+	token := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(token, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		auth   string
+		series map[string]float64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		compressed, _ := io.ReadAll(r.Body)
+		body, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Errorf("Unexpected error decompressing: %v", err)
+		}
+		series = decodeWriteRequest(t, body)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	up := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_lmstat_up"}, []string{"license_name"})
+	up.WithLabelValues("app1").Set(1)
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds", Buckets: []float64{1}})
+	hist.Observe(0.5)
+	registry.MustRegister(up, hist)
+
+	w := &remoteWriter{
+		url:             server.URL,
+		bearerTokenFile: token,
+		labels:          map[string]string{"job": "rlmlm_exporter", "instance": "host1"},
+		client:          server.Client(),
+		gather:          func() ([]*dto.MetricFamily, error) { return registry.Gather() },
+		now:             time.Now,
+	}
+	if err := w.send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Fatalf("Unexpected Authorization header %q", auth)
+	}
+	for labels, expected := range map[string]float64{
+		"__name__=rlmlm_lmstat_up,instance=host1,job=rlmlm_exporter,license_name=app1": 1,
+		"__name__=duration_seconds_bucket,instance=host1,job=rlmlm_exporter,le=1":      1,
+		"__name__=duration_seconds_bucket,instance=host1,job=rlmlm_exporter,le=+Inf":   1,
+		"__name__=duration_seconds_sum,instance=host1,job=rlmlm_exporter":              0.5,
+	} {
+		if got, ok := series[labels]; !ok || got != expected {
+			t.Fatalf("Unexpected value %v for %s in %v", got, labels, series)
+		}
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	})
+	if err := w.send(context.Background()); err == nil || !strings.Contains(err.Error(), "out of order") {
+		t.Fatalf("Unexpected error for a rejected write: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"math"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+var (
+	reportsSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rlmlm_report_emails_sent_total",
+		Help: "rlmlm_exporter: Email reports sent.",
+	})
+	reportFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rlmlm_report_email_failures_total",
+		Help: "rlmlm_exporter: Email reports that couldn't be built or sent.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(reportsSent, reportFailures)
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<html>
+<head><title>{{.Subject}}</title></head>
+<body>
+<h1>{{.Subject}}</h1>
+<p>Generated on {{.Generated.Format "2006-01-02 15:04 MST"}}.</p>
+<h2>Down</h2>
+{{if .Down}}<table border="1" cellpadding="4">
+<tr><th>License</th><th>What</th><th>Name</th></tr>
+{{range .Down}}<tr><td>{{.License}}</td><td>{{.Kind}}</td><td>{{.Name}}</td></tr>
+{{end}}</table>{{else}}<p>Every license server and ISV is up.</p>{{end}}
+<h2>Expiring within {{.ExpiringDays}} days</h2>
+{{if .Expiring}}<table border="1" cellpadding="4">
+<tr><th>License</th><th>Feature</th><th>Expires</th><th>Days left</th></tr>
+{{range .Expiring}}<tr><td>{{.License}}</td><td>{{.Feature}}</td><td>{{.Expires.Format "2006-01-02"}}</td><td>{{if lt .Days 0}}expired{{else}}{{.Days}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No feature expires.</p>{{end}}
+<h2>Peak usage since the previous report</h2>
+{{if .Peaks}}<table border="1" cellpadding="4">
+<tr><th>License</th><th>Feature</th><th>Peak used</th><th>Issued</th></tr>
+{{range .Peaks}}<tr><td>{{.License}}</td><td>{{.Feature}}</td><td>{{.Used}}</td><td>{{.Issued}}</td></tr>
+{{end}}</table>{{else}}<p>No feature was collected.</p>{{end}}
+</body>
+</html>
+`))
+
+// reportData is what the email report shows.
+type reportData struct {
+	Subject      string
+	Generated    time.Time
+	ExpiringDays int
+	Down         []reportDown
+	Expiring     []reportExpiry
+	Peaks        []reportPeak
+}
+
+// reportDown is a license target, server or ISV that is down.
+type reportDown struct {
+	License, Kind, Name string
+}
+
+// reportExpiry is the earliest expiration of a feature. Days is negative once
+// expired.
+type reportExpiry struct {
+	License, Feature string
+	Expires          time.Time
+	Days             int
+}
+
+// reportPeak is the peak usage of a feature next to its current issued
+// licenses.
+type reportPeak struct {
+	License, Feature string
+	Used, Issued     float64
+}
+
+// buildReport extracts the down targets, servers and ISVs and the features
+// expiring before now+within from families, next to peaks.
+func buildReport(families []*dto.MetricFamily, peaks []collector.FeaturePeak, now time.Time, within time.Duration) reportData {
+	data := reportData{Generated: now, ExpiringDays: int(within.Hours() / 24)}
+	issued := make(map[[2]string]float64)
+	ignored := make(map[[2]string]bool)
+	expires := make(map[[2]string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			license := labelValue(m, "license_name")
+			key := [2]string{license, labelValue(m, "feature")}
+			value := m.GetGauge().GetValue()
+			switch mf.GetName() {
+			case targetUpMetric:
+				if value == 0 {
+					data.Down = append(data.Down, reportDown{license, "license server", labelValue(m, "license_server")})
+				}
+			case "rlmlm_server_status":
+				if value == 0 {
+					data.Down = append(data.Down, reportDown{license, "server", labelValue(m, "port") + "@" + labelValue(m, "fqdn")})
+				}
+			case "rlmlm_isv_status":
+				if value == 0 {
+					data.Down = append(data.Down, reportDown{license, "ISV", labelValue(m, "isv")})
+				}
+			case "rlmlm_feature_issued":
+				issued[key] = value
+			case "rlmlm_feature_expiration_ignored":
+				ignored[key] = true
+			case "rlmlm_feature_line_expiration_seconds":
+				if earliest, ok := expires[key]; !ok || value < earliest {
+					expires[key] = value
+				}
+			}
+		}
+	}
+
+	for key, seconds := range expires {
+		if ignored[key] || math.IsInf(seconds, 0) {
+			continue
+		}
+		at := time.Unix(int64(seconds), 0).In(now.Location())
+		if at.After(now.Add(within)) {
+			continue
+		}
+		days := int(math.Floor(at.Sub(now).Hours() / 24))
+		data.Expiring = append(data.Expiring, reportExpiry{License: key[0], Feature: key[1], Expires: at, Days: days})
+	}
+	sort.Slice(data.Expiring, func(i, j int) bool {
+		a, b := data.Expiring[i], data.Expiring[j]
+		if !a.Expires.Equal(b.Expires) {
+			return a.Expires.Before(b.Expires)
+		}
+		return a.License+"\x00"+a.Feature < b.License+"\x00"+b.Feature
+	})
+	sort.Slice(data.Down, func(i, j int) bool {
+		a, b := data.Down[i], data.Down[j]
+		return a.License+"\x00"+a.Kind+"\x00"+a.Name < b.License+"\x00"+b.Kind+"\x00"+b.Name
+	})
+	for _, p := range peaks {
+		data.Peaks = append(data.Peaks, reportPeak{p.License, p.Feature, p.Used, issued[[2]string{p.License, p.Feature}]})
+	}
+	return data
+}
+
+// reporter emails a report when the schedule of the report settings in use
+// is due.
+type reporter struct {
+	settings func() *config.Report
+	gather   func() ([]*dto.MetricFamily, error)
+	send     func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	now      func() time.Time
+}
+
+// run checks the schedule at the start of every minute until ctx is done.
+// Reports are enabled, changed or disabled by reloading the configuration.
+func (r *reporter) run(ctx context.Context) {
+	for {
+		now := r.now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+		}
+		settings := r.settings()
+		if settings == nil || !settings.Due(r.now()) {
+			continue
+		}
+		if err := r.report(settings); err != nil {
+			reportFailures.Inc()
+			level.Error(baseLogger).Log("msg", "failed to send the email report", "smtp", settings.SMTP.Address, "err", err)
+			continue
+		}
+		reportsSent.Inc()
+		level.Info(baseLogger).Log("msg", "email report sent", "to", strings.Join(settings.SMTP.To, ","))
+	}
+}
+
+// report builds the report from the metrics and sends it.
+func (r *reporter) report(settings *config.Report) error {
+	families, err := r.gather()
+	if err != nil && len(families) == 0 {
+		return fmt.Errorf("couldn't gather metrics: %w", err)
+	}
+	data := buildReport(families, collector.TakeReportPeaks(), r.now(), settings.ExpiringWithin)
+	data.Subject = settings.Subject
+	msg, err := reportMessage(settings, data)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if settings.SMTP.Username != "" {
+		password, err := os.ReadFile(settings.SMTP.PasswordFile)
+		if err != nil {
+			return err
+		}
+		host, _, _ := net.SplitHostPort(settings.SMTP.Address)
+		auth = smtp.PlainAuth("", settings.SMTP.Username, strings.TrimSpace(string(password)), host)
+	}
+	return r.send(settings.SMTP.Address, auth, settings.SMTP.From, settings.SMTP.To, msg)
+}
+
+// reportMessage renders data as an HTML email.
+func reportMessage(settings *config.Report, data reportData) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", settings.SMTP.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(settings.SMTP.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", settings.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", data.Generated.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\nContent-Type: text/html; charset=utf-8\r\n\r\n")
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("couldn't render the report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// currentReport returns the report settings of the configuration in use.
+func currentReport() *config.Report {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	if appConfig == nil {
+		return nil
+	}
+	return appConfig.Report
+}
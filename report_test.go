@@ -0,0 +1,114 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// reportFamilies returns the metrics of two licenses, app2 being down.
+func reportFamilies(t *testing.T, now time.Time) []*dto.MetricFamily {
+	registry := prometheus.NewRegistry()
+	targetUp := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_target_up"}, []string{"license_name", "license_server"})
+	isvStatus := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_isv_status"}, []string{"license_name", "isv", "version"})
+	issued := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_feature_issued"}, []string{"license_name", "feature"})
+	expiration := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_feature_line_expiration_seconds"}, []string{"license_name", "feature", "index", "licenses"})
+	ignored := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_feature_expiration_ignored"}, []string{"license_name", "feature"})
+	registry.MustRegister(targetUp, isvStatus, issued, expiration, ignored)
+
+	targetUp.WithLabelValues("app1", "5053@host1").Set(1)
+	targetUp.WithLabelValues("app2", "5053@host2").Set(0)
+	isvStatus.WithLabelValues("app1", "vendor1", "v14.2").Set(0)
+	issued.WithLabelValues("app1", "feature1").Set(10)
+	day := float64(24 * 60 * 60)
+	expiration.WithLabelValues("app1", "feature1", "1", "5").Set(float64(now.Unix()) + 10*day)
+	expiration.WithLabelValues("app1", "feature1", "2", "5").Set(float64(now.Unix()) + 3*day)
+	expiration.WithLabelValues("app1", "feature2", "3", "5").Set(float64(now.Unix()) + 90*day)
+	expiration.WithLabelValues("app1", "eval", "4", "1").Set(float64(now.Unix()) + day)
+	ignored.WithLabelValues("app1", "eval").Set(1)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return families
+}
+
+func TestBuildReport(t *testing.T) {
+	now := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	peaks := []collector.FeaturePeak{{License: "app1", Feature: "feature1", Used: 7}}
+	data := buildReport(reportFamilies(t, now), peaks, now, 30*24*time.Hour)
+
+	if len(data.Down) != 2 || data.Down[0] != (reportDown{"app1", "ISV", "vendor1"}) || data.Down[1] != (reportDown{"app2", "license server", "5053@host2"}) {
+		t.Fatalf("Unexpected down list %+v", data.Down)
+	}
+	// The earliest line of feature1 only, feature2 is too far and eval ignored.
+	if len(data.Expiring) != 1 || data.Expiring[0].Feature != "feature1" || data.Expiring[0].Days != 3 {
+		t.Fatalf("Unexpected expiring list %+v", data.Expiring)
+	}
+	if len(data.Peaks) != 1 || data.Peaks[0] != (reportPeak{"app1", "feature1", 7, 10}) {
+		t.Fatalf("Unexpected peaks %+v", data.Peaks)
+	}
+}
+
+func TestReporterSend(t *testing.T) {
+	cfg, err := config.Parse([]byte(`report:
+  schedule: "0 8 * * *"
+  subject: Licenses
+  smtp:
+    address: localhost:25
+    from: rlmlm@example.com
+    to: [it@example.com, finance@example.com]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC)
+	var (
+		sentTo  []string
+		message string
+	)
+	r := &reporter{
+		settings: func() *config.Report { return cfg.Report },
+		gather:   func() ([]*dto.MetricFamily, error) { return reportFamilies(t, now), nil },
+		send: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			if addr != "localhost:25" || a != nil || from != "rlmlm@example.com" {
+				t.Fatalf("Unexpected SMTP settings %s %v %s", addr, a, from)
+			}
+			sentTo, message = to, string(msg)
+			return nil
+		},
+		now: func() time.Time { return now },
+	}
+	if err := r.report(cfg.Report); err != nil {
+		t.Fatal(err)
+	}
+	if len(sentTo) != 2 {
+		t.Fatalf("Expected the report to be sent to both recipients, got %v", sentTo)
+	}
+	for _, want := range []string{"Subject: Licenses\r\n", "Content-Type: text/html; charset=utf-8", "<td>5053@host2</td>", "<td>2025-03-06</td>"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("Expected %q in the report:\n%s", want, message)
+		}
+	}
+}
@@ -16,19 +16,33 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	stdlog "log"
 	"net/http"
-	_ "net/http/pprof"
+	"net/smtp"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	gokitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/iambengiey/rlmlm_exporter/collector"
 	"github.com/iambengiey/rlmlm_exporter/config"
+	"github.com/iambengiey/rlmlm_exporter/lint"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/version"
 )
@@ -36,28 +50,109 @@ import (
 var (
 	appConfig  *config.Config
 	baseLogger gokitlog.Logger = gokitlog.NewNopLogger()
+	// cache serves unfiltered scrapes when background collection is enabled.
+	cache       *collector.Cache
+	cancelCache context.CancelFunc
+	// stateMu guards appConfig and the cache, which are replaced on reload.
+	stateMu sync.RWMutex
+
+	cacheInterval       time.Duration
+	maxStaleness        time.Duration
+	scrapeTimeoutOffset time.Duration
+	// configVarsPath holds the variables the configuration file is rendered
+	// with, empty if it isn't a template.
+	configVarsPath string
 )
 
 func init() {
-	prometheus.MustRegister(version.NewCollector("rlmlm_exporter"))
+	prometheus.MustRegister(versioncollector.NewCollector("rlmlm_exporter"))
+	// Replace the Go and process collectors client_golang registers by default
+	// with explicit ones, so that the exporter's own memory, GC, scheduler, fd
+	// and thread metrics don't depend on the defaults of the library.
+	prometheus.Unregister(collectors.NewGoCollector())
+	prometheus.Unregister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	prometheus.MustRegister(
+		collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsGC, collectors.MetricsScheduler)),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	if slowScrapes != nil {
+		defer slowScrapes.watch()()
+	}
 	filters := r.URL.Query()["collect[]"]
-	level.Debug(baseLogger).Log("msg", "collect query", "filters", strings.Join(filters, ","))
+	licenses := r.URL.Query()["license[]"]
+	level.Debug(baseLogger).Log("msg", "collect query", "filters", strings.Join(filters, ","), "licenses", strings.Join(licenses, ","))
+
+	stateMu.RLock()
+	c := cache
+	cfg := appConfig
+	stateMu.RUnlock()
+
+	tenant, ok := requestTenant(w, r, cfg)
+	if !ok {
+		return
+	}
+	var scope map[string]bool
+	if tenant != "" {
+		scope = tenantLicenses(cfg, tenant)
+		for _, license := range licenses {
+			if !scope[license] {
+				http.Error(w, fmt.Sprintf("License %q isn't one of tenant %s", license, tenant), http.StatusForbidden)
+				return
+			}
+		}
+	}
 
-	nc, err := collector.NewFlexlmCollector(filters...)
+	var nc prometheus.Collector
+	var err error
+	switch {
+	case c != nil && len(filters) == 0 && len(licenses) == 0:
+		nc = c.Scrape()
+	case scope != nil && len(scope) == 0:
+		// Nothing to collect for a tenant without licenses.
+	default:
+		if scope != nil && len(licenses) == 0 {
+			for license := range scope {
+				licenses = append(licenses, license)
+			}
+			sort.Strings(licenses)
+		}
+		// Scrapes served from the cache don't run rlmstat and aren't limited.
+		if scrapeLimit != nil {
+			release, reason := scrapeLimit.acquire(r.Context())
+			if release == nil {
+				level.Warn(baseLogger).Log("msg", "rejected scrape", "reason", reason, "licenses", strings.Join(licenses, ","))
+				scrapeLimit.reject(w)
+				return
+			}
+			defer release()
+		}
+		var shared *collector.RlmlmCollector
+		shared, err = collector.ScrapeCollector(filters, licenses)
+		if err == nil {
+			scoped := *shared
+			if deadline, ok := scrapeDeadline(r); ok {
+				scoped.Deadline = deadline
+			}
+			nc = scoped
+		}
+	}
 	if err != nil {
-		level.Warn(baseLogger).Log("msg", "failed to create filtered collector", "filters", strings.Join(filters, ","), "err", err)
+		level.Warn(baseLogger).Log("msg", "failed to create filtered collector", "filters", strings.Join(filters, ","), "licenses", strings.Join(licenses, ","), "err", err)
 		http.Error(w, fmt.Sprintf("Couldn't create collector: %s", err), http.StatusBadRequest)
 		return
 	}
 
 	registry := prometheus.NewRegistry()
-	if err := registry.Register(nc); err != nil {
-		level.Error(baseLogger).Log("msg", "failed to register collector", "err", err)
-		http.Error(w, fmt.Sprintf("Couldn't register collector: %s", err), http.StatusInternalServerError)
-		return
+	if nc != nil {
+		if err := registry.Register(nc); err != nil {
+			level.Error(baseLogger).Log("msg", "failed to register collector", "err", err)
+			http.Error(w, fmt.Sprintf("Couldn't register collector: %s", err), http.StatusInternalServerError)
+			return
+		}
 	}
 
 	gatherers := prometheus.Gatherers{
@@ -65,67 +160,466 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		registry,
 	}
 
-	h := promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{
+	h := promhttp.HandlerFor(withCompat(withDeprecations(withScrapeSummary(withTenant(gatherers, scope), start), scope != nil)), promhttp.HandlerOpts{
 		ErrorLog:      stdlog.New(os.Stderr, "promhttp: ", stdlog.LstdFlags),
 		ErrorHandling: promhttp.ContinueOnError,
 	})
 	h.ServeHTTP(w, r)
 }
 
+// scrapeDeadline returns when the scraper gives up on the request, less
+// --web.scrape-timeout-offset to leave time to send the metrics, from the
+// timeout Prometheus sends along.
+func scrapeDeadline(r *http.Request) (time.Time, bool) {
+	seconds, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if scrapeTimeoutOffset < timeout {
+		timeout -= scrapeTimeoutOffset
+	}
+	return time.Now().Add(timeout), true
+}
+
+// featureHandler serves the current state of a single feature as JSON, for
+// Alertmanager webhook receivers and runbooks.
+func featureHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	stateMu.RLock()
+	cfg := appConfig
+	stateMu.RUnlock()
+	tenant, ok := requestTenant(w, r, cfg)
+	if !ok {
+		return
+	}
+	if tenant != "" {
+		cfg = cfg.ForTenant(tenant)
+	}
+	status, err := collector.LookupFeature(r.Context(), cfg, baseLogger, name)
+	if errors.Is(err, collector.ErrFeatureNotFound) {
+		http.Error(w, fmt.Sprintf("Feature %q not found", name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		level.Error(baseLogger).Log("msg", "failed to look up feature", "feature", name, "err", err)
+		http.Error(w, fmt.Sprintf("Couldn't look up feature: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write feature status", "feature", name, "err", err)
+	}
+}
+
+// licensesHandler serves a page of licenses as JSON. The offset and limit
+// query parameters select the page and fields selects among features and
+// users, both by default.
+func licensesHandler(w http.ResponseWriter, r *http.Request) {
+	opts := collector.LicenseListOptions{Features: true, Users: true}
+	query := r.URL.Query()
+	for param, value := range map[string]*int{"offset": &opts.Offset, "limit": &opts.Limit} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("The %s parameter must be a non-negative integer", param), http.StatusBadRequest)
+			return
+		}
+		*value = n
+	}
+	if query.Has("fields") {
+		opts.Features, opts.Users = false, false
+		for _, field := range splitList(query.Get("fields")) {
+			switch field {
+			case "features":
+				opts.Features = true
+			case "users":
+				opts.Users = true
+			default:
+				http.Error(w, fmt.Sprintf("Unknown field %q, must be features or users", field), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	stateMu.RLock()
+	cfg := appConfig
+	stateMu.RUnlock()
+	tenant, ok := requestTenant(w, r, cfg)
+	if !ok {
+		return
+	}
+	if tenant != "" {
+		cfg = cfg.ForTenant(tenant)
+	}
+	list := collector.ListLicenses(r.Context(), cfg, baseLogger, opts)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write licenses", "err", err)
+	}
+}
+
+// jsonSample is a sample of /metrics.json. Values are strings like in the
+// Prometheus HTTP API, as JSON has no NaN or infinity.
+type jsonSample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  string            `json:"value"`
+}
+
+// metricsJSONHandler serves what /metrics serves as a JSON list of samples,
+// for clients without a Prometheus parser. Histograms and summaries are
+// flattened like in the text format.
+func metricsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	stateMu.RLock()
+	cfg := appConfig
+	stateMu.RUnlock()
+	tenant, ok := requestTenant(w, r, cfg)
+	if !ok {
+		return
+	}
+	var scope map[string]bool
+	if tenant != "" {
+		scope = tenantLicenses(cfg, tenant)
+	}
+	families, err := gatherScoped(scope)
+	if err != nil && len(families) == 0 {
+		http.Error(w, fmt.Sprintf("Couldn't gather metrics: %s", err), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		level.Warn(baseLogger).Log("msg", "error gathering metrics for /metrics.json", "err", err)
+	}
+
+	samples := []jsonSample{}
+	for _, s := range toSeries(families, nil, time.Now()) {
+		sample := jsonSample{Labels: make(map[string]string, len(s.labels)-1), Value: formatFloat(s.samples[0].value)}
+		for _, l := range s.labels {
+			if l.name == "__name__" {
+				sample.Name = l.value
+			} else {
+				sample.Labels[l.name] = l.value
+			}
+		}
+		samples = append(samples, sample)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(samples); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write metrics", "err", err)
+	}
+}
+
+// loadConfig loads the configuration file at path, rendered with the
+// variables of --path.config-vars if set, falling back to the license client
+// environment if it doesn't exist.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.LoadTemplate(path, configVarsPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if envCfg, envErr := config.FromEnv(); envErr == nil {
+			level.Info(baseLogger).Log("msg", "no configuration file, using licenses from environment", "path", path)
+			return envCfg, nil
+		}
+	}
+	return cfg, err
+}
+
+// startCache replaces the background cache with one collecting nc. The
+// caller must hold stateMu or be the only goroutine touching the state.
+func startCache(nc *collector.RlmlmCollector) {
+	if cancelCache != nil {
+		cancelCache()
+	}
+	var ctx context.Context
+	ctx, cancelCache = context.WithCancel(context.Background())
+	cache = collector.NewCache(nc, cacheInterval, maxStaleness, baseLogger)
+	cache.Start(ctx)
+}
+
+// printDryRun writes one line per command, grouped by collector and license.
+func printDryRun(w io.Writer, cmds []collector.Command) {
+	for _, cmd := range cmds {
+		license := cmd.License
+		if license == "" {
+			license = "-"
+		}
+		line := fmt.Sprintf("collector=%s license=%q command: %s", cmd.Collector, license, cmd)
+		if cmd.Condition != "" {
+			line += fmt.Sprintf(" (%s)", cmd.Condition)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// printLint writes the lint issues of every license and returns their number.
+func printLint(w io.Writer, results map[string][]lint.Issue) int {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var count int
+	for _, name := range names {
+		for _, issue := range results[name] {
+			fmt.Fprintf(w, "license=%q %s\n", name, issue)
+			count++
+		}
+	}
+	fmt.Fprintf(w, "%d issue(s) in %d license file(s)\n", count, len(results))
+	return count
+}
+
+// newLogger returns a go-kit logger writing to stderr in the given format and
+// filtered to the given level, logging identical warnings and errors at most
+// once per dedupInterval.
+func newLogger(lvl, format string, dedupInterval time.Duration) gokitlog.Logger {
+	var logger gokitlog.Logger
+	if format == "json" {
+		logger = gokitlog.NewJSONLogger(gokitlog.NewSyncWriter(os.Stderr))
+	} else {
+		logger = gokitlog.NewLogfmtLogger(gokitlog.NewSyncWriter(os.Stderr))
+	}
+	logger = level.NewFilter(logger, level.Allow(level.ParseDefault(lvl, level.InfoValue())))
+	logger = newDedupLogger(logger, dedupInterval)
+	return gokitlog.With(logger, "ts", gokitlog.DefaultTimestampUTC, "caller", gokitlog.DefaultCaller)
+}
+
 func main() {
+	collector.SandboxInit()
+
 	var (
-		listenAddress = kingpin.Flag("web.listen-address", "Address on which to expose metrics and web interface.").Default(":9319").String()
-		metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
-		configPath    = kingpin.Flag("path.config", "Configuration YAML file path.").Default("licenses.yml").String()
+		listenAddress  = kingpin.Flag("web.listen-address", "Address on which to expose metrics and web interface.").Default(":9319").String()
+		metricsPath    = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+		configPath     = kingpin.Flag("path.config", "Configuration YAML file path.").Default("licenses.yml").String()
+		logLevel       = kingpin.Flag("log.level", "Only log messages with the given severity or above. One of: [debug, info, warn, error]").Default("info").Enum("debug", "info", "warn", "error")
+		dryRun         = kingpin.Flag("dry-run", "Print the commands every collector would run for each license and exit without executing them.").Bool()
+		logFormat      = kingpin.Flag("log.format", "Output format of log messages. One of: [logfmt, json]").Default("logfmt").Enum("logfmt", "json")
+		logDedup       = kingpin.Flag("log.dedup-interval", "Log identical warnings and errors at most once per interval, with the number of repeats. Zero logs every one.").Default("5m").Duration()
+		adminAuth      = kingpin.Flag("web.admin-auth", "Authentication of the admin endpoints (/-/reload, /config, /api/v1/ changes, /debug/), which aren't served with none. One of: [none, token, negotiate]").Default("none").Enum("none", "token", "negotiate")
+		adminTokenFile = kingpin.Flag("web.admin-token-file", "File holding the bearer token of the admin endpoints for --web.admin-auth=token, read on every request.").Default("").String()
+		adminKeytab    = kingpin.Flag("web.admin-keytab", "Keytab of the HTTP service principal for --web.admin-auth=negotiate.").Default("").String()
+		adminSPN       = kingpin.Flag("web.admin-spn", "Service principal to use from the keytab, like HTTP/exporter.example.com. Defaults to the one matching the ticket.").Default("").String()
+		adminGroups    = kingpin.Flag("web.admin-groups", "Comma separated AD group SIDs allowed to use the admin endpoints, empty allows every authenticated user.").Default("").String()
+		otlpEndpoint   = kingpin.Flag("tracing.otlp-endpoint", "OTLP/HTTP endpoint (host:port) to send a trace of every scrape to, with spans per collector, license and rlmstat run. Empty disables tracing.").Default("").String()
+		otlpInsecure   = kingpin.Flag("tracing.otlp-insecure", "Send traces over plain HTTP instead of HTTPS.").Bool()
+		rwURL          = kingpin.Flag("remote-write.url", "Prometheus remote write endpoint to send the metrics to every --remote-write.interval, e.g. Mimir or Thanos receive. Empty disables it.").Default("").String()
+		rwInterval     = kingpin.Flag("remote-write.interval", "Interval between two remote writes.").Default("1m").Duration()
+		rwTokenFile    = kingpin.Flag("remote-write.bearer-token-file", "File holding the bearer token sent with remote writes.").Default("").String()
+		rwJob          = kingpin.Flag("remote-write.job", "Value of the job label added to remote written series.").Default("rlmlm_exporter").String()
+		rwInstance     = kingpin.Flag("remote-write.instance", "Value of the instance label added to remote written series. Defaults to the hostname.").Default("").String()
 	)
+	var (
+		reusePort       = kingpin.Flag("web.reuse-port", "Listen with SO_REUSEPORT, so that a new exporter can start listening before the old one stops (not on Windows).").Bool()
+		shutdownTimeout = kingpin.Flag("web.shutdown-timeout", "Time given to the requests in progress to finish when stopping, or handing over to a new binary on SIGUSR2.").Default("10s").Duration()
+		pidFile         = kingpin.Flag("web.pid-file", "Write the process ID to this file, also after handing over to a new binary on SIGUSR2.").Default("").String()
+		compatFlexlm    = kingpin.Flag("compat.flexlm", "Additionally expose the metrics under the metric and label names of flexlm_exporter.").Bool()
+		compatFile      = kingpin.Flag("compat.mapping-file", "Additionally expose the metrics under the metric and label names of this mapping file.").Default("").String()
+		compatLevel     = kingpin.Flag("metrics.compat-level", "Oldest metric naming level to expose the names of, next to the current ones. The current level, "+strconv.Itoa(metricsLevel)+", exposes no deprecated name, to check dashboards before upgrading.").Default(strconv.Itoa(oldestMetricsLevel)).Int()
+		stateFile       = kingpin.Flag("path.state-file", "File to record the last time the exporter was up in, to export rlmlm_exporter_downtime_seconds after a restart. Empty disables it.").Default("").String()
+		featureSeenFile = kingpin.Flag("path.feature-seen-file", "CSV file to record the first and last time every feature was served in, to export rlmlm_feature_first_seen_timestamp_seconds across restarts. Empty disables it.").Default("").String()
+		graphRetention  = kingpin.Flag("web.graph-retention", "How long the usage of features is kept in memory for the charts under /graph. Zero disables them.").Default("6h").Duration()
+		discoverEvery   = kingpin.Flag("config.auto-discover-interval", "Interval between two scans of the auto_discover_dir of the license groups for added or removed ISVs. Zero only scans on load.").Default("1m").Duration()
+		slowScrapeDir   = kingpin.Flag("debug.slow-scrape-dir", "Directory to save a goroutine dump and a CPU profile of the scrapes taking longer than --debug.slow-scrape-threshold to. Empty disables it.").Default("").String()
+		slowScrapeAfter = kingpin.Flag("debug.slow-scrape-threshold", "Scrape duration after which the goroutines are dumped and the CPU is profiled until the scrape ends.").Default("30s").Duration()
+		slowScrapeMax   = kingpin.Flag("debug.slow-scrape-max-captures", "Number of slow scrape captures to keep, the oldest are removed.").Default("5").Int()
+		maxScrapes      = kingpin.Flag("web.max-concurrent-scrapes", "Live scrapes collected at once, for many targets scraped at the same time. Zero doesn't limit them.").Default("0").Int()
+		maxQueued       = kingpin.Flag("web.max-queued-scrapes", "Live scrapes waiting for a slot with --web.max-concurrent-scrapes; further ones are answered with 503.").Default("32").Int()
+		queueTimeout    = kingpin.Flag("web.scrape-queue-timeout", "How long a live scrape waits for a slot before it is answered with 503, and the Retry-After sent with it.").Default("10s").Duration()
+	)
+	kingpin.Flag("path.config-vars", "YAML file of variables to render the configuration file with as a Go text/template, so that sites can share it. Empty loads the configuration file as is.").Default("").StringVar(&configVarsPath)
+	kingpin.Flag("cache.interval", "Collect licenses in the background at this interval and serve scrapes from the results. Zero collects on every scrape.").Default("0s").DurationVar(&cacheInterval)
+	kingpin.Flag("web.scrape-timeout-offset", "Stop collecting this long before the scrape timeout sent by Prometheus, sending the licenses collected so far.").Default("500ms").DurationVar(&scrapeTimeoutOffset)
+	kingpin.Flag("web.require-tenant-token", "Refuse the requests to /metrics, /metrics.json, the JSON API, the billing periods and /graph without the bearer token of one of the tenants of the configuration.").BoolVar(&requireTenantToken)
+	kingpin.Flag("log.scrape-summary", "Log one line per scrape with its duration, the licenses up and down and the series of every license.").BoolVar(&logScrapeSummary)
+	kingpin.Flag("cache.max-staleness", "Drop cached license metrics last collected successfully longer ago than this. Zero never drops them.").Default("0s").DurationVar(&maxStaleness)
+
+	kingpin.Command("serve", "Serve the metrics (the default).").Default()
+	lintCmd := kingpin.Command("lint", "Check the configured license files and the options files they reference, and exit.")
 
-	promlogConfig := promlog.Config{}
-	promlogflag.AddFlags(kingpin.CommandLine, &promlogConfig)
 	kingpin.Version(version.Print("rlmlm_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
 
-	baseLogger = promlog.New(promlogConfig)
+	baseLogger = newLogger(*logLevel, *logFormat, *logDedup)
 	collector.SetLogger(baseLogger)
 	config.SetLogger(baseLogger)
 
 	level.Info(baseLogger).Log("msg", "Starting rlmlm_exporter", "version", version.Info())
 	level.Info(baseLogger).Log("msg", "Build context", "context", version.BuildContext())
 
-	cfg, err := config.Load(*configPath)
+	cfg, err := loadConfig(*configPath)
 	if err != nil {
-		level.Error(baseLogger).Log("msg", "failed to load configuration", "path", *configPath, "err", err)
-		os.Exit(1)
+		fatal(exitConfig, "failed to load configuration", "path", *configPath, "err", err)
 	}
 	appConfig = cfg
 	collector.SetConfig(appConfig)
+	collector.SetUsageHistoryRetention(*graphRetention)
+	// The remote write and diff clients use the default transport.
+	roots, err := collector.SystemRoots()
+	if err != nil {
+		fatal(exitSetup, "failed to load the CA certificates", "err", err)
+	}
+	if roots != nil {
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	if err := collector.LoadFeatureSeen(*featureSeenFile); err != nil {
+		fatal(exitSetup, "failed to load the feature seen file", "path", *featureSeenFile, "err", err)
+	}
+	if err := collector.LoadProductMapping(); err != nil {
+		fatal(exitConfig, "failed to load the product mapping", "err", err)
+	}
+	// A missing binary is reported here once and then skipped on scrapes.
+	_ = collector.CheckRlmstatBinary(baseLogger)
+
+	if command == lintCmd.FullCommand() {
+		if n := printLint(os.Stdout, collector.LintLicenses(appConfig, baseLogger)); n > 0 {
+			fatal(exitLint, "license files have issues", "issues", n)
+		}
+		return
+	}
 
+	if err := collector.CheckDescriptors(appConfig, baseLogger); err != nil {
+		fatal(exitConfig, "inconsistent metric descriptors", "err", err)
+	}
 	nc, err := collector.NewFlexlmCollector()
 	if err != nil {
-		level.Error(baseLogger).Log("msg", "failed to create collector", "err", err)
-		os.Exit(1)
+		fatal(exitSetup, "failed to create collector", "err", err)
 	}
+	if *dryRun {
+		printDryRun(os.Stdout, nc.DryRun())
+		return
+	}
+
 	level.Info(baseLogger).Log("msg", "Enabled collectors")
 	for name := range nc.Collectors {
 		level.Info(baseLogger).Log("msg", "collector enabled", "collector", name)
 	}
 
-	http.HandleFunc(*metricsPath, handler)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	if *compatFlexlm || *compatFile != "" {
+		if compat, err = loadCompatMapping(*compatFile); err != nil {
+			fatal(exitConfig, "failed to load the compatibility mapping", "path", *compatFile, "err", err)
+		}
+	}
+
+	if err := checkMetricsCompatLevel(*compatLevel); err != nil {
+		fatal(exitConfig, "invalid --metrics.compat-level", "err", err)
+	}
+	metricsCompatLevel = *compatLevel
+
+	if *otlpEndpoint != "" {
+		if err := setupTracing(*otlpEndpoint, *otlpInsecure); err != nil {
+			fatal(exitSetup, "failed to set up tracing", "err", err)
+		}
+		level.Info(baseLogger).Log("msg", "tracing enabled", "endpoint", *otlpEndpoint)
+	}
+
+	if cacheInterval > 0 {
+		startCache(nc)
+		level.Info(baseLogger).Log("msg", "collecting in the background", "interval", cacheInterval, "max_staleness", maxStaleness)
+	}
+
+	if *maxScrapes > 0 {
+		scrapeLimit = newScrapeLimiter(*maxScrapes, *maxQueued, *queueTimeout)
+	}
+
+	if *slowScrapeDir != "" {
+		slowScrapes = &slowScrapeProfiler{
+			dir:         *slowScrapeDir,
+			threshold:   *slowScrapeAfter,
+			maxCaptures: *slowScrapeMax,
+			logger:      baseLogger,
+		}
+		level.Info(baseLogger).Log("msg", "profiling slow scrapes", "dir", *slowScrapeDir, "threshold", *slowScrapeAfter)
+	}
+
+	if *discoverEvery > 0 {
+		go runAutoDiscover(context.Background(), *discoverEvery)
+	}
+
+	if *rwURL != "" {
+		instance := *rwInstance
+		if instance == "" {
+			instance, _ = os.Hostname()
+		}
+		w := &remoteWriter{
+			url:             *rwURL,
+			bearerTokenFile: *rwTokenFile,
+			interval:        *rwInterval,
+			labels:          map[string]string{"job": *rwJob, "instance": instance},
+			client:          &http.Client{Timeout: *rwInterval},
+			gather:          gatherMetrics,
+			now:             time.Now,
+		}
+		go w.run(context.Background())
+		level.Info(baseLogger).Log("msg", "remote write enabled", "url", *rwURL, "interval", *rwInterval)
+	}
+
+	reports := &reporter{settings: currentReport, gather: gatherMetrics, send: smtp.SendMail, now: time.Now}
+	go reports.run(context.Background())
+
+	admin, err := newAdminAuth(*adminAuth, *adminKeytab, *adminSPN, *adminGroups, *adminTokenFile)
+	if err != nil {
+		fatal(exitSetup, "failed to set up admin authentication", "err", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(*metricsPath, handler)
+	mux.HandleFunc("GET /api/v1/feature/{name}", featureHandler)
+	mux.HandleFunc("GET /api/v1/licenses", licensesHandler)
+	mux.HandleFunc("GET /api/v1/billing", billingPeriodsHandler)
+	mux.HandleFunc("GET /api/v1/billing/{period}", billingPeriodHandler)
+	mux.HandleFunc("GET /metrics.json", metricsJSONHandler)
+	graphLink := ""
+	if *graphRetention > 0 {
+		mux.HandleFunc("GET /graph", graphHandler)
+		graphLink = `<p><a href="graph">Usage</a></p>`
+	}
+	if admin != nil {
+		registerAdminHandlers(mux, admin, *configPath)
+	} else {
+		level.Info(baseLogger).Log("msg", "admin endpoints disabled, set --web.admin-auth to serve them")
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if _, err := fmt.Fprintf(w, `<html>
                         <head><title>RLMlm Exporter</title></head>
                         <body>
                         <h1>RLMlm Exporter</h1>
                         <p><a href="%s">Metrics</a></p>
+                        %s
                         </body>
-                        </html>`, *metricsPath); err != nil {
+                        </html>`, *metricsPath, graphLink); err != nil {
 			level.Error(baseLogger).Log("msg", "failed to write index page", "err", err)
 		}
 	})
 
-	level.Info(baseLogger).Log("msg", "Listening", "address", *listenAddress)
-	if err := http.ListenAndServe(*listenAddress, nil); err != nil {
-		level.Error(baseLogger).Log("msg", "server exited", "err", err)
-		os.Exit(1)
+	// The listener of an upgrade is handed over, so check before taking it.
+	stopDowntime := trackDowntime(*stateFile, startTime, os.Getenv(listenFDEnv) != "")
+	ln, err := listen(*listenAddress, *reusePort)
+	if err != nil {
+		fatal(exitListen, "failed to listen", "address", *listenAddress, "err", err)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, stopSignals...)
+	if err := notifyReady(); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to report readiness for the upgrade", "err", err)
+	}
+	if err := writePIDFile(*pidFile); err != nil {
+		level.Error(baseLogger).Log("msg", "failed to write PID file", "path", *pidFile, "err", err)
+	}
+
+	level.Info(baseLogger).Log("msg", "Listening", "address", ln.Addr())
+	err = serve(ln, mux, *shutdownTimeout, signals)
+	stopDowntime()
+	if err := collector.FlushFeatureSeen(); err != nil {
+		level.Warn(baseLogger).Log("msg", "failed to write the feature seen file", "path", *featureSeenFile, "err", err)
+	}
+	if err != nil {
+		fatal(exitFailure, "server exited", "err", err)
 	}
 }
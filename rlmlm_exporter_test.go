@@ -0,0 +1,110 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+func TestHandlerProtobuf(t *testing.T) {
+	collector.SetConfig(&config.Config{})
+	defer collector.SetConfig(nil)
+
+	for accept, expected := range map[string]string{
+		// What Prometheus 3.x sends with native histograms enabled.
+		"application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.6,text/plain;version=0.0.4;q=0.3": "application/vnd.google.protobuf",
+		"text/plain;version=0.0.4": "text/plain",
+		"":                         "text/plain",
+	} {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, expected) {
+			t.Fatalf("Unexpected content type %q for Accept %q", got, accept)
+		}
+		if w.Body.Len() == 0 {
+			t.Fatalf("Empty response for Accept %q", accept)
+		}
+	}
+}
+
+func TestHandlerRuntimeMetrics(t *testing.T) {
+	collector.SetConfig(&config.Config{})
+	defer collector.SetConfig(nil)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/metrics", nil))
+	expected := []string{"go_goroutines", "go_gc_duration_seconds", "go_sched_latencies_seconds", "go_threads"}
+	if runtime.GOOS == "linux" {
+		expected = append(expected, "process_open_fds", "process_resident_memory_bytes")
+	}
+	for _, name := range expected {
+		if !strings.Contains(w.Body.String(), "\n# TYPE "+name+" ") {
+			t.Fatalf("Expected %s in the metrics", name)
+		}
+	}
+}
+
+func TestMetricsJSONHandler(t *testing.T) {
+	collector.SetConfig(&config.Config{})
+	defer collector.SetConfig(nil)
+
+	w := httptest.NewRecorder()
+	metricsJSONHandler(w, httptest.NewRequest("GET", "/metrics.json", nil))
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Unexpected content type %q", got)
+	}
+	var samples []jsonSample
+	if err := json.Unmarshal(w.Body.Bytes(), &samples); err != nil {
+		t.Fatalf("Unexpected error decoding response: %v", err)
+	}
+	var found bool
+	for _, s := range samples {
+		if s.Name == "rlmlm_rlm_binary_available" {
+			_, found = s.Labels["path"]
+		}
+	}
+	if !found {
+		t.Fatalf("rlmlm_rlm_binary_available not found in %d samples", len(samples))
+	}
+}
+
+func TestHandlerDeadlineFailure(t *testing.T) {
+	collector.SetConfig(&config.Config{Licenses: []config.License{{Name: "app1", LicenseServer: "5053@127.0.0.1"}}})
+	defer collector.SetConfig(nil)
+	// Flags aren't parsed in tests, so every collector is disabled.
+	if err := collector.SetCollectorEnabled("lmstat", true); err != nil {
+		t.Fatal(err)
+	}
+	defer collector.SetCollectorEnabled("lmstat", false)
+
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "10")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	// Without rlmstat binary the license fails.
+	if !strings.Contains(w.Body.String(), `rlmlm_scrape_collector_success{collector="lmstat"} 0`) {
+		t.Fatalf("Expected the lmstat collector to fail, got:\n%s", w.Body)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons of rlmlm_scrape_rejected_total.
+const (
+	rejectQueueFull = "queue_full"
+	rejectTimeout   = "timeout"
+	rejectCanceled  = "canceled"
+)
+
+var (
+	scrapesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rlmlm_scrape_in_flight",
+		Help: "rlmlm_exporter: Live scrapes being collected, at most --web.max-concurrent-scrapes.",
+	})
+	scrapesQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rlmlm_scrape_queued",
+		Help: "rlmlm_exporter: Live scrapes waiting for one of --web.max-concurrent-scrapes to finish.",
+	})
+	scrapesRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rlmlm_scrape_rejected_total",
+		Help: "rlmlm_exporter: Live scrapes answered with 503 as the queue was full, they waited longer than --web.scrape-queue-timeout or the scraper gave up.",
+	}, []string{"reason"})
+
+	// scrapeLimit bounds the live scrapes, nil if unlimited.
+	scrapeLimit *scrapeLimiter
+)
+
+func init() {
+	prometheus.MustRegister(scrapesInFlight, scrapesQueued, scrapesRejected)
+	for _, reason := range []string{rejectQueueFull, rejectTimeout, rejectCanceled} {
+		scrapesRejected.WithLabelValues(reason)
+	}
+}
+
+// scrapeLimiter lets a fixed number of live scrapes collect at once, so
+// that many targets scraped together don't fork an rlmstat process each, and
+// queues a bounded number of the others for a while.
+type scrapeLimiter struct {
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+func newScrapeLimiter(concurrent, queued int, timeout time.Duration) *scrapeLimiter {
+	return &scrapeLimiter{
+		slots:   make(chan struct{}, concurrent),
+		queue:   make(chan struct{}, queued),
+		timeout: timeout,
+	}
+}
+
+// acquire waits for a free slot and returns the function releasing it, or
+// the reason why the scrape is rejected.
+func (l *scrapeLimiter) acquire(ctx context.Context) (func(), string) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.started(), ""
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		scrapesRejected.WithLabelValues(rejectQueueFull).Inc()
+		return nil, rejectQueueFull
+	}
+	scrapesQueued.Inc()
+	defer func() {
+		<-l.queue
+		scrapesQueued.Dec()
+	}()
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return l.started(), ""
+	case <-timer.C:
+		scrapesRejected.WithLabelValues(rejectTimeout).Inc()
+		return nil, rejectTimeout
+	case <-ctx.Done():
+		scrapesRejected.WithLabelValues(rejectCanceled).Inc()
+		return nil, rejectCanceled
+	}
+}
+
+// started accounts for a scrape that got a slot and returns the function
+// releasing it.
+func (l *scrapeLimiter) started() func() {
+	scrapesInFlight.Inc()
+	return func() {
+		scrapesInFlight.Dec()
+		<-l.slots
+	}
+}
+
+// reject answers a rejected scrape with 503, asking to retry after the
+// queue timeout.
+func (l *scrapeLimiter) reject(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(l.timeout.Seconds()))))
+	http.Error(w, "Too many scrapes in progress, retry later", http.StatusServiceUnavailable)
+}
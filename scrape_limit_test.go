@@ -0,0 +1,62 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScrapeLimiter(t *testing.T) {
+	l := newScrapeLimiter(1, 1, 50*time.Millisecond)
+	release, _ := l.acquire(context.Background())
+	if release == nil {
+		t.Fatal("Expected a free slot")
+	}
+
+	queued := make(chan string)
+	go func() {
+		_, reason := l.acquire(context.Background())
+		queued <- reason
+	}()
+	// Wait for the second scrape to be queued.
+	for len(l.queue) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if next, reason := l.acquire(context.Background()); next != nil || reason != rejectQueueFull {
+		t.Fatalf("Expected a full queue, got %q", reason)
+	}
+	if reason := <-queued; reason != rejectTimeout {
+		t.Fatalf("Expected the queued scrape to time out, got %q", reason)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	next, reason := l.acquire(context.Background())
+	if next == nil {
+		t.Fatalf("Expected the released slot, got %q", reason)
+	}
+	next()
+
+	rec := httptest.NewRecorder()
+	l.reject(rec)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Unexpected rejection %d with Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
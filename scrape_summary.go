@@ -0,0 +1,123 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const targetUpMetric = "rlmlm_target_up"
+
+// logScrapeSummary enables the summary log line of every scrape.
+var logScrapeSummary bool
+
+// scrapeSummary sums up the metrics gathered by a scrape.
+type scrapeSummary struct {
+	series int
+	// up and down list the licenses whose target could be queried or not.
+	up, down []string
+	// licenseSeries counts the series of every license.
+	licenseSeries map[string]int
+}
+
+// summarizeScrape counts the series of families by license and splits the
+// licenses by rlmlm_target_up.
+func summarizeScrape(families []*dto.MetricFamily) scrapeSummary {
+	s := scrapeSummary{licenseSeries: make(map[string]int)}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			s.series++
+			license := labelValue(m, "license_name")
+			if license == "" {
+				continue
+			}
+			s.licenseSeries[license]++
+			if mf.GetName() != targetUpMetric {
+				continue
+			}
+			if m.GetGauge().GetValue() == 1 {
+				s.up = append(s.up, license)
+			} else {
+				s.down = append(s.down, license)
+			}
+		}
+	}
+	sort.Strings(s.up)
+	sort.Strings(s.down)
+	return s
+}
+
+// labelValue returns the value of the label name of m, empty if missing.
+func labelValue(m *dto.Metric, name string) string {
+	for _, lp := range m.GetLabel() {
+		if lp.GetName() == name {
+			return lp.GetValue()
+		}
+	}
+	return ""
+}
+
+// keyvals returns the summary as log key-value pairs, the series of every
+// license as a single "license=count ..." value.
+func (s scrapeSummary) keyvals() []interface{} {
+	licenses := make([]string, 0, len(s.licenseSeries))
+	for license := range s.licenseSeries {
+		licenses = append(licenses, license)
+	}
+	sort.Strings(licenses)
+	counts := make([]string, 0, len(licenses))
+	for _, license := range licenses {
+		counts = append(counts, fmt.Sprintf("%s=%d", license, s.licenseSeries[license]))
+	}
+	return []interface{}{
+		"series", s.series,
+		"licenses_up", len(s.up),
+		"licenses_down", len(s.down),
+		"down", strings.Join(s.down, ","),
+		"license_series", strings.Join(counts, " "),
+	}
+}
+
+// summaryGatherer logs the summary of every gathering of g, timed from start.
+type summaryGatherer struct {
+	g     prometheus.Gatherer
+	start time.Time
+}
+
+// withScrapeSummary returns g, or g logging a summary of the scrape started at
+// start if --log.scrape-summary is set.
+func withScrapeSummary(g prometheus.Gatherer, start time.Time) prometheus.Gatherer {
+	if !logScrapeSummary {
+		return g
+	}
+	return summaryGatherer{g: g, start: start}
+}
+
+// Gather implements prometheus.Gatherer.
+func (s summaryGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := s.g.Gather()
+	keyvals := append([]interface{}{"msg", "scrape summary", "duration", time.Since(s.start)}, summarizeScrape(families).keyvals()...)
+	if err != nil {
+		keyvals = append(keyvals, "err", err)
+	}
+	level.Info(baseLogger).Log(keyvals...)
+	return families, err
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSummarizeScrape(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	up := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_target_up"}, []string{"license_name", "license_server"})
+	issued := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "rlmlm_feature_issued"}, []string{"license_name", "feature"})
+	info := prometheus.NewGauge(prometheus.GaugeOpts{Name: "rlmlm_exporter_info"})
+	registry.MustRegister(up, issued, info)
+	up.WithLabelValues("app1", "5053@host1").Set(1)
+	up.WithLabelValues("app2", "5053@host2").Set(0)
+	up.WithLabelValues("app3", "5053@host3").Set(0)
+	issued.WithLabelValues("app1", "feature1").Set(10)
+	issued.WithLabelValues("app1", "feature2").Set(5)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := summarizeScrape(families)
+	if s.series != 6 {
+		t.Fatalf("Unexpected series count %d", s.series)
+	}
+	if len(s.up) != 1 || s.up[0] != "app1" || len(s.down) != 2 {
+		t.Fatalf("Unexpected licenses up %v and down %v", s.up, s.down)
+	}
+	expected := []interface{}{
+		"series", 6,
+		"licenses_up", 1,
+		"licenses_down", 2,
+		"down", "app2,app3",
+		"license_series", "app1=3 app2=1 app3=1",
+	}
+	if got := s.keyvals(); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("Unexpected summary %v", got)
+	}
+}
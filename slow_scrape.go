@@ -0,0 +1,150 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	slowScrapePrefix = "scrape-"
+	// maxSlowScrapeProfile bounds the CPU profile of a scrape that never
+	// ends.
+	maxSlowScrapeProfile = time.Minute
+)
+
+// slowScrapes profiles scrapes exceeding a threshold, nil if disabled.
+var slowScrapes *slowScrapeProfiler
+
+// slowScrapeProfiler captures a goroutine dump and a CPU profile of the
+// scrapes still running after threshold, to a subdirectory of dir per
+// capture, keeping the newest maxCaptures.
+type slowScrapeProfiler struct {
+	dir         string
+	threshold   time.Duration
+	maxCaptures int
+	logger      gokitlog.Logger
+	// capturing allows a single capture at a time, the CPU profiler is
+	// global.
+	capturing sync.Mutex
+}
+
+// watch starts watching a scrape and returns the function to call when it
+// ends. If it is still running after the threshold, the goroutines are dumped
+// and the CPU is profiled until it ends.
+func (p *slowScrapeProfiler) watch() func() {
+	done := make(chan struct{})
+	timer := time.AfterFunc(p.threshold, func() { p.capture(done) })
+	return func() {
+		timer.Stop()
+		close(done)
+	}
+}
+
+// capture saves the goroutines and the CPU profile until done is closed.
+// Scrapes slow at the same time as another, or while /debug/pprof/profile
+// runs, aren't captured.
+func (p *slowScrapeProfiler) capture(done <-chan struct{}) {
+	if !p.capturing.TryLock() {
+		return
+	}
+	defer p.capturing.Unlock()
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	start := time.Now()
+	dir := filepath.Join(p.dir, slowScrapePrefix+start.UTC().Format("20060102T150405.000Z"))
+	if err := p.writeProfiles(dir, done); err != nil {
+		level.Warn(p.logger).Log("msg", "couldn't capture slow scrape", "dir", dir, "err", err)
+		return
+	}
+	level.Warn(p.logger).Log("msg", "captured slow scrape profiles", "threshold", p.threshold,
+		"duration", p.threshold+time.Since(start), "dir", dir)
+
+	if err := pruneSlowScrapes(p.dir, p.maxCaptures); err != nil {
+		level.Warn(p.logger).Log("msg", "couldn't prune slow scrape captures", "dir", p.dir, "err", err)
+	}
+}
+
+// writeProfiles writes goroutines.txt and cpu.pprof to dir.
+func (p *slowScrapeProfiler) writeProfiles(dir string, done <-chan struct{}) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	goroutines, err := os.Create(filepath.Join(dir, "goroutines.txt"))
+	if err != nil {
+		return err
+	}
+	err = pprof.Lookup("goroutine").WriteTo(goroutines, 2)
+	if closeErr := goroutines.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	cpu, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return err
+	}
+	defer cpu.Close()
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		return fmt.Errorf("couldn't start CPU profile: %w", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(maxSlowScrapeProfile):
+	}
+	pprof.StopCPUProfile()
+	return cpu.Close()
+}
+
+// pruneSlowScrapes removes the oldest captures in dir beyond max. Capture
+// names start with their timestamp, so they sort chronologically.
+func pruneSlowScrapes(dir string, max int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var captures []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), slowScrapePrefix) {
+			captures = append(captures, entry.Name())
+		}
+	}
+	if len(captures) <= max {
+		return nil
+	}
+	sort.Strings(captures)
+	for _, name := range captures[:len(captures)-max] {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
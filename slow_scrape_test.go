@@ -0,0 +1,80 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestSlowScrapeProfiler(t *testing.T) {
+	dir := t.TempDir()
+	p := &slowScrapeProfiler{dir: dir, threshold: 10 * time.Millisecond, maxCaptures: 2, logger: log.NewNopLogger()}
+
+	// Fast scrapes aren't captured.
+	p.threshold = time.Hour
+	p.watch()()
+
+	p.threshold = 10 * time.Millisecond
+	done := p.watch()
+	time.Sleep(100 * time.Millisecond)
+	done()
+	// Wait for the capture to be saved.
+	p.capturing.Lock()
+	p.capturing.Unlock()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 capture, found %d", len(entries))
+	}
+	for _, name := range []string{"goroutines.txt", "cpu.pprof"} {
+		fi, err := os.Stat(filepath.Join(dir, entries[0].Name(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() == 0 {
+			t.Fatalf("Expected a non-empty %s", name)
+		}
+	}
+}
+
+func TestPruneSlowScrapes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"scrape-20250101T000000.000Z", "scrape-20250102T000000.000Z", "scrape-20250103T000000.000Z", "other"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pruneSlowScrapes(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 3 || names[0] != "other" || names[1] != "scrape-20250102T000000.000Z" {
+		t.Fatalf("Unexpected captures after pruning %v", names)
+	}
+}
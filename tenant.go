@@ -0,0 +1,108 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// requireTenantToken refuses the requests without a tenant token, set by
+// --web.require-tenant-token.
+var requireTenantToken bool
+
+// requestTenant returns the tenant r is scoped to, empty for every license:
+// the tenant of its bearer token, or its tenant parameter without token or
+// with the token of a tenant seeing every license. Bearer tokens are ignored
+// if no tenant has a token, they may be meant for a proxy in front. If r may
+// not be served, the error is written to w and false returned.
+func requestTenant(w http.ResponseWriter, r *http.Request, cfg *config.Config) (string, bool) {
+	requested := r.URL.Query().Get("tenant")
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && cfg.HasTenantTokens() {
+		tenant, ok := cfg.TenantOfToken(strings.TrimSpace(token))
+		if !ok {
+			http.Error(w, "Invalid tenant token", http.StatusUnauthorized)
+			return "", false
+		}
+		if !tenant.All {
+			if requested != "" && requested != tenant.Name {
+				http.Error(w, fmt.Sprintf("The token is scoped to tenant %s", tenant.Name), http.StatusForbidden)
+				return "", false
+			}
+			return tenant.Name, true
+		}
+	} else if requireTenantToken {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "A tenant token is required", http.StatusUnauthorized)
+		return "", false
+	}
+	if requested != "" && !cfg.HasTenant(requested) {
+		http.Error(w, fmt.Sprintf("Unknown tenant %q", requested), http.StatusNotFound)
+		return "", false
+	}
+	return requested, true
+}
+
+// tenantLicenses returns the names of the licenses of tenant.
+func tenantLicenses(cfg *config.Config, tenant string) map[string]bool {
+	licenses := make(map[string]bool)
+	for _, license := range cfg.ForTenant(tenant).Licenses {
+		licenses[license.Name] = true
+	}
+	return licenses
+}
+
+// tenantGatherer keeps the metrics of the licenses of a tenant. Metrics of
+// no license, like those of the exporter itself, aren't part of its view.
+type tenantGatherer struct {
+	g        prometheus.Gatherer
+	licenses map[string]bool
+}
+
+// withTenant returns g, or g keeping the metrics of licenses if not nil.
+func withTenant(g prometheus.Gatherer, licenses map[string]bool) prometheus.Gatherer {
+	if licenses == nil {
+		return g
+	}
+	return tenantGatherer{g: g, licenses: licenses}
+}
+
+// Gather implements prometheus.Gatherer.
+func (t tenantGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := t.g.Gather()
+	scoped := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		var metrics []*dto.Metric
+		for _, m := range mf.GetMetric() {
+			if t.licenses[labelValue(m, "license_name")] {
+				metrics = append(metrics, m)
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		mf.Metric = metrics
+		scoped = append(scoped, mf)
+	}
+	return scoped, err
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iambengiey/rlmlm_exporter/collector"
+	"github.com/iambengiey/rlmlm_exporter/config"
+)
+
+// tenantConfig returns a configuration with the licenses a1 of tenant a,
+// whose token is "secret-a", and b1 of tenant b. The token "secret-all" sees
+// both.
+func tenantConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	for name, token := range map[string]string{"a": "secret-a", "all": "secret-all"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(token+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := config.Parse([]byte(`tenants:
+  - name: a
+    token_file: ` + filepath.Join(dir, "a") + `
+  - name: prometheus
+    token_file: ` + filepath.Join(dir, "all") + `
+    all: true
+licenses:
+  - name: a1
+    license_server: 5053@host1
+    tenant: a
+  - name: b1
+    license_server: 5053@host2
+    tenant: b
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestRequestTenant(t *testing.T) {
+	cfg := tenantConfig(t)
+	for _, tc := range []struct {
+		url, token string
+		require    bool
+		tenant     string
+		code       int
+	}{
+		{url: "/metrics", code: http.StatusOK},
+		{url: "/metrics?tenant=b", tenant: "b", code: http.StatusOK},
+		{url: "/metrics?tenant=c", code: http.StatusNotFound},
+		{url: "/metrics", token: "secret-a", tenant: "a", code: http.StatusOK},
+		{url: "/metrics?tenant=a", token: "secret-a", tenant: "a", code: http.StatusOK},
+		{url: "/metrics?tenant=b", token: "secret-a", code: http.StatusForbidden},
+		{url: "/metrics", token: "secret-b", code: http.StatusUnauthorized},
+		{url: "/metrics?tenant=b", require: true, code: http.StatusUnauthorized},
+		{url: "/metrics", token: "secret-a", require: true, tenant: "a", code: http.StatusOK},
+		{url: "/metrics", token: "secret-all", require: true, code: http.StatusOK},
+		{url: "/metrics?tenant=b", token: "secret-all", require: true, tenant: "b", code: http.StatusOK},
+	} {
+		requireTenantToken = tc.require
+		r := httptest.NewRequest("GET", tc.url, nil)
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		tenant, ok := requestTenant(w, r, cfg)
+		if tenant != tc.tenant || ok != (tc.code == http.StatusOK) || w.Code != tc.code {
+			t.Fatalf("Unexpected tenant %q (%v, %d) for %s with token %q, expected %q (%d)",
+				tenant, ok, w.Code, tc.url, tc.token, tc.tenant, tc.code)
+		}
+	}
+	requireTenantToken = false
+
+	// Without tenant tokens, bearer tokens are left to whatever checks them.
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer proxy-token")
+	w := httptest.NewRecorder()
+	if tenant, ok := requestTenant(w, r, &config.Config{}); !ok || tenant != "" || w.Code != http.StatusOK {
+		t.Fatalf("Expected the unscoped view without tenant tokens, got %q (%d)", tenant, w.Code)
+	}
+}
+
+func TestHandlerTenant(t *testing.T) {
+	cfg := tenantConfig(t)
+	collector.SetConfig(cfg)
+	defer collector.SetConfig(nil)
+	stateMu.Lock()
+	appConfig = cfg
+	stateMu.Unlock()
+	defer func() {
+		stateMu.Lock()
+		appConfig = nil
+		stateMu.Unlock()
+	}()
+
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer secret-a")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `license_name="a1"`) {
+		t.Fatalf("Expected the metrics of a1, got %d: %s", w.Code, body)
+	}
+	if !strings.Contains(body, `rlmlm_license_tenant_info{license_name="a1",tenant="a"} 1`) {
+		t.Fatalf("Expected the tenant of a1, got %s", body)
+	}
+	if strings.Contains(body, `license_name="b1"`) || strings.Contains(body, "go_goroutines") {
+		t.Fatalf("Expected only the metrics of tenant a, got %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/metrics?tenant=a&license[]=b1", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected license b1 to be refused to tenant a, got %d", w.Code)
+	}
+}
+
+func TestBillingGraphTenant(t *testing.T) {
+	cfg := tenantConfig(t)
+	cfg.Billing = &config.Billing{Dir: t.TempDir()}
+	csv := "period_start,period_end,license_name,feature,group,seat_hours,rate,cost\n" +
+		"2025-01-01,2025-02-01,a1,solver,design,1.0000,2,2.00\n" +
+		"2025-01-01,2025-02-01,b1,mesher,design,3.0000,0,0.00\n"
+	if err := os.WriteFile(filepath.Join(cfg.Billing.Dir, "2025-01-01.csv"), []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stateMu.Lock()
+	appConfig = cfg
+	stateMu.Unlock()
+	defer func() {
+		stateMu.Lock()
+		appConfig = nil
+		stateMu.Unlock()
+	}()
+
+	r := httptest.NewRequest("GET", "/api/v1/billing/2025-01-01", nil)
+	r.SetPathValue("period", "2025-01-01")
+	r.Header.Set("Authorization", "Bearer secret-a")
+	w := httptest.NewRecorder()
+	billingPeriodHandler(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), ",a1,") || strings.Contains(w.Body.String(), ",b1,") {
+		t.Fatalf("Expected only the rows of a1, got %d: %s", w.Code, w.Body)
+	}
+
+	requireTenantToken = true
+	defer func() { requireTenantToken = false }()
+	for url, h := range map[string]http.HandlerFunc{
+		"/api/v1/billing":            billingPeriodsHandler,
+		"/api/v1/billing/2025-01-01": billingPeriodHandler,
+		"/graph":                     graphHandler,
+	} {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected %s to require a token, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestScopeHistory(t *testing.T) {
+	history := map[string][]collector.UsageSample{"a1": {{Used: 1}}, "b1": {{Used: 2}}}
+	if scoped := scopeHistory(history, map[string]bool{"a1": true}); len(scoped) != 1 || scoped["a1"] == nil {
+		t.Fatalf("Unexpected history of tenant a: %v", scoped)
+	}
+	if all := scopeHistory(map[string][]collector.UsageSample{"b1": nil}, nil); len(all) != 1 {
+		t.Fatalf("Expected every license without scope, got %v", all)
+	}
+}
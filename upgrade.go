@@ -0,0 +1,178 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+// An exporter started for an upgrade finds the listener and the pipe to
+// report that it is serving in these descriptors.
+const (
+	listenFDEnv = "RLMLM_LISTEN_FD"
+	readyFDEnv  = "RLMLM_READY_FD"
+)
+
+// listen returns the listener handed over by the exporter that started this
+// one for an upgrade, or a new listener on address. With reusePort, other
+// processes may listen on address at the same time.
+func listen(address string, reusePort bool) (net.Listener, error) {
+	if fd := os.Getenv(listenFDEnv); fd != "" {
+		os.Unsetenv(listenFDEnv)
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", listenFDEnv, fd)
+		}
+		f := os.NewFile(uintptr(n), "listener")
+		defer f.Close()
+		return net.FileListener(f)
+	}
+
+	var lc net.ListenConfig
+	if reusePort {
+		lc.Control = setReusePort
+	}
+	return lc.Listen(context.Background(), "tcp", address)
+}
+
+// notifyReady tells the exporter that started this one for an upgrade that
+// it is serving, if it was started for one.
+func notifyReady() error {
+	fd := os.Getenv(readyFDEnv)
+	if fd == "" {
+		return nil
+	}
+	os.Unsetenv(readyFDEnv)
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", readyFDEnv, fd)
+	}
+	f := os.NewFile(uintptr(n), "ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
+
+// upgrade starts the exporter binary, which may have been replaced since
+// this one started, with the same arguments and hands ln over to it. It
+// returns once the new exporter serves, after which this one should stop
+// accepting connections, or an error if it didn't start within timeout.
+func upgrade(ln net.Listener, timeout time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// Linux reports a replaced binary like this.
+	exe = strings.TrimSuffix(exe, " (deleted)")
+
+	tcp, ok := ln.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("can't hand over a %T", ln)
+	}
+	lf, err := tcp.File()
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	// ExtraFiles start at descriptor 3.
+	cmd.Env = append(os.Environ(), listenFDEnv+"=3", readyFDEnv+"=4")
+	cmd.ExtraFiles = []*os.File{lf, readyW}
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		return err
+	}
+	level.Info(baseLogger).Log("msg", "started new exporter for upgrade", "path", exe, "pid", cmd.Process.Pid)
+
+	served := make(chan error, 1)
+	go func() {
+		var b [1]byte
+		// Fails once the new exporter exits without reporting.
+		_, err := ready.Read(b[:])
+		served <- err
+	}()
+	select {
+	case err := <-served:
+		if err != nil {
+			_ = cmd.Wait()
+			return fmt.Errorf("new exporter exited before serving: %s", cmd.ProcessState)
+		}
+		// Not waited for, it outlives this process.
+		_ = cmd.Process.Release()
+		return nil
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("new exporter didn't serve within %s", timeout)
+	}
+}
+
+// writePIDFile writes the process ID to path, for service managers to follow
+// the exporter across upgrades.
+func writePIDFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
+}
+
+// serve serves handler on ln until a stop or upgrade signal. Connections in
+// progress get shutdownTimeout to finish.
+func serve(ln net.Listener, handler http.Handler, shutdownTimeout time.Duration, signals <-chan os.Signal) error {
+	srv := &http.Server{Handler: handler}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case sig := <-signals:
+			if isUpgradeSignal(sig) {
+				if err := upgrade(ln, shutdownTimeout+30*time.Second); err != nil {
+					level.Error(baseLogger).Log("msg", "upgrade failed, still serving", "err", err)
+					continue
+				}
+				level.Info(baseLogger).Log("msg", "new exporter serving, stopping")
+			} else {
+				level.Info(baseLogger).Log("msg", "stopping", "signal", sig)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			return nil
+		}
+	}
+}
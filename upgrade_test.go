@@ -0,0 +1,92 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestListenReusePort(t *testing.T) {
+	first, err := listen("127.0.0.1:0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := listen(first.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("Expected a second listener on %s: %v", first.Addr(), err)
+	}
+	second.Close()
+}
+
+func TestListenInherited(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// listen takes over the descriptor.
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(listenFDEnv, strconv.Itoa(fd))
+
+	inherited, err := listen("127.0.0.1:1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inherited.Close()
+	if inherited.Addr().String() != ln.Addr().String() {
+		t.Fatalf("Expected the inherited listener on %s, got %s", ln.Addr(), inherited.Addr())
+	}
+	if os.Getenv(listenFDEnv) != "" {
+		t.Fatalf("Expected %s to be cleared", listenFDEnv)
+	}
+}
+
+func TestNotifyReady(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	// notifyReady takes over the descriptor.
+	fd, err := syscall.Dup(int(w.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(readyFDEnv, strconv.Itoa(fd))
+
+	if err := notifyReady(); err != nil {
+		t.Fatal(err)
+	}
+	var b [1]byte
+	if n, err := r.Read(b[:]); n != 1 || err != nil {
+		t.Fatalf("Expected the readiness byte, got %d bytes: %v", n, err)
+	}
+}
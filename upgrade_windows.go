@@ -0,0 +1,35 @@
+// Copyright 2025 Greg Drake
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// stopSignals stop the exporter gracefully, upgrades aren't supported on
+// Windows.
+var stopSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+func isUpgradeSignal(sig os.Signal) bool {
+	return false
+}
+
+func setReusePort(network, address string, c syscall.RawConn) error {
+	return errors.New("--web.reuse-port isn't supported on Windows")
+}